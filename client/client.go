@@ -1,52 +1,270 @@
 package client
 
 import (
-	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
-	"github.com/aws/aws-sdk-go/aws/session"
+	"sync"
+
 	"github.com/aws/aws-sdk-go/service/codebuild"
-	"github.com/spf13/viper"
+	"github.com/aws/aws-sdk-go/service/sts"
 )
 
 // API defines the client interface
 type API interface {
+	BatchGetBuildBatches(input *codebuild.BatchGetBuildBatchesInput) (*codebuild.BatchGetBuildBatchesOutput, error)
 	BatchGetBuilds(input *codebuild.BatchGetBuildsInput) (*codebuild.BatchGetBuildsOutput, error)
+	BatchGetProjects(input *codebuild.BatchGetProjectsInput) (*codebuild.BatchGetProjectsOutput, error)
+	BatchGetReports(input *codebuild.BatchGetReportsInput) (*codebuild.BatchGetReportsOutput, error)
+	CreateProject(input *codebuild.CreateProjectInput) (*codebuild.CreateProjectOutput, error)
+	DescribeCodeCoverages(input *codebuild.DescribeCodeCoveragesInput) (*codebuild.DescribeCodeCoveragesOutput, error)
+	DescribeTestCases(input *codebuild.DescribeTestCasesInput) (*codebuild.DescribeTestCasesOutput, error)
+	GetCallerIdentity(input *sts.GetCallerIdentityInput) (*sts.GetCallerIdentityOutput, error)
+	ListBuildBatchesForProject(input *codebuild.ListBuildBatchesForProjectInput) (*codebuild.ListBuildBatchesForProjectOutput, error)
 	ListBuildsForProject(input *codebuild.ListBuildsForProjectInput) (*codebuild.ListBuildsForProjectOutput, error)
 	ListProjects(input *codebuild.ListProjectsInput) (*codebuild.ListProjectsOutput, error)
+	ListReportGroups(input *codebuild.ListReportGroupsInput) (*codebuild.ListReportGroupsOutput, error)
+	ListReportsForReportGroup(input *codebuild.ListReportsForReportGroupInput) (*codebuild.ListReportsForReportGroupOutput, error)
+	RetryBuild(input *codebuild.RetryBuildInput) (*codebuild.RetryBuildOutput, error)
+	RetryBuildBatch(input *codebuild.RetryBuildBatchInput) (*codebuild.RetryBuildBatchOutput, error)
+	StartBuild(input *codebuild.StartBuildInput) (*codebuild.StartBuildOutput, error)
+	StartBuildBatch(input *codebuild.StartBuildBatchInput) (*codebuild.StartBuildBatchOutput, error)
+	StopBuild(input *codebuild.StopBuildInput) (*codebuild.StopBuildOutput, error)
+	StopBuildBatch(input *codebuild.StopBuildBatchInput) (*codebuild.StopBuildBatchOutput, error)
+	UpdateProject(input *codebuild.UpdateProjectInput) (*codebuild.UpdateProjectOutput, error)
 }
 
-// Client is the content implementation of the API we are using in the app
+// Client is the content implementation of the API we are using in the app.
+// The codebuild client is created on first use, once the config file and
+// flags have been read.
 type Client struct {
+	once      *sync.Once
+	target    *Target
 	codebuild *codebuild.CodeBuild
+	sts       *sts.STS
+	err       error
 }
 
 // NewClient will return a internal codebuild client.
 func NewClient() Client {
-	sess, _ := session.NewSessionWithOptions(session.Options{
-		SharedConfigState:       session.SharedConfigEnable,
-		Profile:                 viper.GetString("AWS_PROFILE"),
-		AssumeRoleTokenProvider: stscreds.StdinTokenProvider,
+	return Client{once: &sync.Once{}}
+}
+
+// NewClientFor will return a internal codebuild client for the target,
+// rather than the account and region in the config.
+func NewClientFor(target Target) Client {
+	return Client{once: &sync.Once{}, target: &target}
+}
+
+// service creates the codebuild client, and the sts client sharing its
+// session, the first time either is needed
+func (c *Client) service() (*codebuild.CodeBuild, error) {
+	c.once.Do(func() {
+		sess, err := sessionFor(c.target)
+		if err != nil {
+			c.err = err
+			return
+		}
+		c.codebuild = codebuild.New(sess)
+		c.sts = sts.New(sess)
 	})
 
-	svc := codebuild.New(sess)
+	return c.codebuild, c.err
+}
 
-	client := Client{
-		codebuild: svc,
+// BatchGetBuildBatches will call the same function on the codebuild client
+func (c *Client) BatchGetBuildBatches(input *codebuild.BatchGetBuildBatchesInput) (*codebuild.BatchGetBuildBatchesOutput, error) {
+	svc, err := c.service()
+	if err != nil {
+		return nil, err
 	}
 
-	return client
+	return svc.BatchGetBuildBatches(input)
 }
 
 // BatchGetBuilds will call the same function on the codebuild client
 func (c *Client) BatchGetBuilds(input *codebuild.BatchGetBuildsInput) (*codebuild.BatchGetBuildsOutput, error) {
-	return c.codebuild.BatchGetBuilds(input)
+	svc, err := c.service()
+	if err != nil {
+		return nil, err
+	}
+
+	return svc.BatchGetBuilds(input)
+}
+
+// BatchGetProjects will call the same function on the codebuild client
+func (c *Client) BatchGetProjects(input *codebuild.BatchGetProjectsInput) (*codebuild.BatchGetProjectsOutput, error) {
+	svc, err := c.service()
+	if err != nil {
+		return nil, err
+	}
+
+	return svc.BatchGetProjects(input)
+}
+
+// BatchGetReports will call the same function on the codebuild client
+func (c *Client) BatchGetReports(input *codebuild.BatchGetReportsInput) (*codebuild.BatchGetReportsOutput, error) {
+	svc, err := c.service()
+	if err != nil {
+		return nil, err
+	}
+
+	return svc.BatchGetReports(input)
+}
+
+// CreateProject will call the same function on the codebuild client
+func (c *Client) CreateProject(input *codebuild.CreateProjectInput) (*codebuild.CreateProjectOutput, error) {
+	svc, err := c.service()
+	if err != nil {
+		return nil, err
+	}
+
+	return svc.CreateProject(input)
+}
+
+// DescribeCodeCoverages will call the same function on the codebuild client
+func (c *Client) DescribeCodeCoverages(input *codebuild.DescribeCodeCoveragesInput) (*codebuild.DescribeCodeCoveragesOutput, error) {
+	svc, err := c.service()
+	if err != nil {
+		return nil, err
+	}
+
+	return svc.DescribeCodeCoverages(input)
+}
+
+// DescribeTestCases will call the same function on the codebuild client
+func (c *Client) DescribeTestCases(input *codebuild.DescribeTestCasesInput) (*codebuild.DescribeTestCasesOutput, error) {
+	svc, err := c.service()
+	if err != nil {
+		return nil, err
+	}
+
+	return svc.DescribeTestCases(input)
+}
+
+// GetCallerIdentity will call the same function on the sts client, to find
+// out which account the codebuild client is talking to
+func (c *Client) GetCallerIdentity(input *sts.GetCallerIdentityInput) (*sts.GetCallerIdentityOutput, error) {
+	if _, err := c.service(); err != nil {
+		return nil, err
+	}
+
+	return c.sts.GetCallerIdentity(input)
+}
+
+// ListBuildBatchesForProject will call the same function on the codebuild client
+func (c *Client) ListBuildBatchesForProject(input *codebuild.ListBuildBatchesForProjectInput) (*codebuild.ListBuildBatchesForProjectOutput, error) {
+	svc, err := c.service()
+	if err != nil {
+		return nil, err
+	}
+
+	return svc.ListBuildBatchesForProject(input)
 }
 
 // ListBuildsForProject will call the same function on the codebuild client
 func (c *Client) ListBuildsForProject(input *codebuild.ListBuildsForProjectInput) (*codebuild.ListBuildsForProjectOutput, error) {
-	return c.codebuild.ListBuildsForProject(input)
+	svc, err := c.service()
+	if err != nil {
+		return nil, err
+	}
+
+	return svc.ListBuildsForProject(input)
 }
 
 // ListProjects will call the same function on the codebuild client
 func (c *Client) ListProjects(input *codebuild.ListProjectsInput) (*codebuild.ListProjectsOutput, error) {
-	return c.codebuild.ListProjects(input)
+	svc, err := c.service()
+	if err != nil {
+		return nil, err
+	}
+
+	return svc.ListProjects(input)
+}
+
+// ListReportGroups will call the same function on the codebuild client
+func (c *Client) ListReportGroups(input *codebuild.ListReportGroupsInput) (*codebuild.ListReportGroupsOutput, error) {
+	svc, err := c.service()
+	if err != nil {
+		return nil, err
+	}
+
+	return svc.ListReportGroups(input)
+}
+
+// ListReportsForReportGroup will call the same function on the codebuild client
+func (c *Client) ListReportsForReportGroup(input *codebuild.ListReportsForReportGroupInput) (*codebuild.ListReportsForReportGroupOutput, error) {
+	svc, err := c.service()
+	if err != nil {
+		return nil, err
+	}
+
+	return svc.ListReportsForReportGroup(input)
+}
+
+// RetryBuild will call the same function on the codebuild client
+func (c *Client) RetryBuild(input *codebuild.RetryBuildInput) (*codebuild.RetryBuildOutput, error) {
+	svc, err := c.service()
+	if err != nil {
+		return nil, err
+	}
+
+	return svc.RetryBuild(input)
+}
+
+// RetryBuildBatch will call the same function on the codebuild client
+func (c *Client) RetryBuildBatch(input *codebuild.RetryBuildBatchInput) (*codebuild.RetryBuildBatchOutput, error) {
+	svc, err := c.service()
+	if err != nil {
+		return nil, err
+	}
+
+	return svc.RetryBuildBatch(input)
+}
+
+// StartBuild will call the same function on the codebuild client
+func (c *Client) StartBuild(input *codebuild.StartBuildInput) (*codebuild.StartBuildOutput, error) {
+	svc, err := c.service()
+	if err != nil {
+		return nil, err
+	}
+
+	return svc.StartBuild(input)
+}
+
+// StartBuildBatch will call the same function on the codebuild client
+func (c *Client) StartBuildBatch(input *codebuild.StartBuildBatchInput) (*codebuild.StartBuildBatchOutput, error) {
+	svc, err := c.service()
+	if err != nil {
+		return nil, err
+	}
+
+	return svc.StartBuildBatch(input)
+}
+
+// StopBuild will call the same function on the codebuild client
+func (c *Client) StopBuild(input *codebuild.StopBuildInput) (*codebuild.StopBuildOutput, error) {
+	svc, err := c.service()
+	if err != nil {
+		return nil, err
+	}
+
+	return svc.StopBuild(input)
+}
+
+// StopBuildBatch will call the same function on the codebuild client
+func (c *Client) StopBuildBatch(input *codebuild.StopBuildBatchInput) (*codebuild.StopBuildBatchOutput, error) {
+	svc, err := c.service()
+	if err != nil {
+		return nil, err
+	}
+
+	return svc.StopBuildBatch(input)
+}
+
+// UpdateProject will call the same function on the codebuild client
+func (c *Client) UpdateProject(input *codebuild.UpdateProjectInput) (*codebuild.UpdateProjectOutput, error) {
+	svc, err := c.service()
+	if err != nil {
+		return nil, err
+	}
+
+	return svc.UpdateProject(input)
 }
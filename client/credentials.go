@@ -0,0 +1,145 @@
+package client
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+// cacheExpiryWindow is how long before they expire we stop using cached
+// credentials, so they do not run out part way through a command
+const cacheExpiryWindow = time.Minute
+
+// cacheLocks has a lock for each cache file, so targets sharing one fetch
+// credentials once between them, rather than each asking for an MFA code
+var cacheLocks = struct {
+	sync.Mutex
+	paths map[string]*sync.Mutex
+}{paths: map[string]*sync.Mutex{}}
+
+// FetchCredentials gets fresh credentials, and when they expire
+type FetchCredentials func() (credentials.Value, time.Time, error)
+
+// CachedProvider keeps credentials on disk until they expire, so assuming a
+// role with MFA does not ask for a code on every run
+type CachedProvider struct {
+	credentials.Expiry
+
+	path  string
+	fetch FetchCredentials
+}
+
+// cachedCredentials is what we store on disk
+type cachedCredentials struct {
+	AccessKeyID     string    `json:"access_key_id"`
+	SecretAccessKey string    `json:"secret_access_key"`
+	SessionToken    string    `json:"session_token"`
+	Expiration      time.Time `json:"expiration"`
+}
+
+// NewCachedProvider returns a provider which caches the credentials from
+// fetch in the file at path
+func NewCachedProvider(path string, fetch FetchCredentials) *CachedProvider {
+	return &CachedProvider{path: path, fetch: fetch}
+}
+
+// Retrieve returns the cached credentials, fetching new ones if they have
+// expired. Only one provider for a cache file fetches at a time, and the
+// others then use what it cached.
+func (p *CachedProvider) Retrieve() (credentials.Value, error) {
+	lock := cacheLock(p.path)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if cached, ok := p.read(); ok {
+		p.SetExpiration(cached.Expiration, cacheExpiryWindow)
+		return credentials.Value{
+			AccessKeyID:     cached.AccessKeyID,
+			SecretAccessKey: cached.SecretAccessKey,
+			SessionToken:    cached.SessionToken,
+			ProviderName:    "KnopeCachedProvider",
+		}, nil
+	}
+
+	value, expiration, err := p.fetch()
+	if err != nil {
+		return value, err
+	}
+
+	p.SetExpiration(expiration, cacheExpiryWindow)
+
+	// Not being able to cache just means we ask again next time
+	p.write(cachedCredentials{
+		AccessKeyID:     value.AccessKeyID,
+		SecretAccessKey: value.SecretAccessKey,
+		SessionToken:    value.SessionToken,
+		Expiration:      expiration,
+	})
+
+	return value, nil
+}
+
+// read loads the cached credentials, if there are any that are still valid
+func (p *CachedProvider) read() (cachedCredentials, bool) {
+	var cached cachedCredentials
+
+	contents, err := ioutil.ReadFile(p.path)
+	if err != nil {
+		return cached, false
+	}
+
+	if err := json.Unmarshal(contents, &cached); err != nil {
+		return cached, false
+	}
+
+	return cached, time.Now().Add(cacheExpiryWindow).Before(cached.Expiration)
+}
+
+// write stores the credentials, readable only by the user
+func (p *CachedProvider) write(cached cachedCredentials) error {
+	out, err := json.Marshal(cached)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p.path), 0700); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first, so the cache is never seen half written
+	file, err := ioutil.TempFile(filepath.Dir(p.path), filepath.Base(p.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(out); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), p.path)
+}
+
+// cacheLock returns the lock for the cache file at path
+func cacheLock(path string) *sync.Mutex {
+	cacheLocks.Lock()
+	defer cacheLocks.Unlock()
+
+	lock, ok := cacheLocks.paths[path]
+	if !ok {
+		lock = &sync.Mutex{}
+		cacheLocks.paths[path] = lock
+	}
+
+	return lock
+}
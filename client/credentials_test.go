@@ -0,0 +1,157 @@
+package client_test
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/benmatselby/knope/client"
+)
+
+func TestCachedProvider(t *testing.T) {
+	tt := []struct {
+		name       string
+		expiration time.Time
+		fetchErr   error
+		fetches    int
+		err        string
+	}{
+		{name: "reuses credentials until they expire", expiration: time.Now().Add(time.Hour), fetches: 1},
+		{name: "fetches new credentials when they are about to expire", expiration: time.Now().Add(30 * time.Second), fetches: 2},
+		{name: "returns the error from fetching credentials", fetchErr: errors.New("unable to assume role"), fetches: 2, err: "unable to assume role"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "knope")
+			if err != nil {
+				t.Fatalf("expected no error; got %v", err)
+			}
+			defer os.RemoveAll(dir)
+			path := filepath.Join(dir, "cache", "role.json")
+
+			fetches := 0
+			fetch := func() (credentials.Value, time.Time, error) {
+				fetches++
+				return credentials.Value{AccessKeyID: "AKIA", SecretAccessKey: "secret", SessionToken: "token"}, tc.expiration, tc.fetchErr
+			}
+
+			// Each run of knope has a new provider, so only the disk is shared
+			for run := 0; run < 2; run++ {
+				value, err := client.NewCachedProvider(path, fetch).Retrieve()
+
+				if tc.err != "" {
+					if err == nil || err.Error() != tc.err {
+						t.Fatalf("expected err to be %s; got %v", tc.err, err)
+					}
+					continue
+				}
+
+				if err != nil {
+					t.Fatalf("expected no error; got %v", err)
+				}
+
+				if value.AccessKeyID != "AKIA" || value.SecretAccessKey != "secret" || value.SessionToken != "token" {
+					t.Fatalf("expected the credentials to be returned; got %+v", value)
+				}
+			}
+
+			if fetches != tc.fetches {
+				t.Fatalf("expected %d fetches; got %d", tc.fetches, fetches)
+			}
+
+			if tc.err == "" {
+				info, err := os.Stat(path)
+				if err != nil {
+					t.Fatalf("expected the credentials to be cached; got %v", err)
+				}
+				if info.Mode().Perm() != 0600 {
+					t.Fatalf("expected the cache to only be readable by the user; got %v", info.Mode().Perm())
+				}
+			}
+		})
+	}
+}
+
+func TestCachedProviderExpiry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "knope")
+	if err != nil {
+		t.Fatalf("expected no error; got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	provider := client.NewCachedProvider(filepath.Join(dir, "role.json"), func() (credentials.Value, time.Time, error) {
+		return credentials.Value{AccessKeyID: "AKIA"}, time.Now().Add(time.Hour), nil
+	})
+
+	if !provider.IsExpired() {
+		t.Fatalf("expected the provider to start expired")
+	}
+
+	if _, err := provider.Retrieve(); err != nil {
+		t.Fatalf("expected no error; got %v", err)
+	}
+
+	if provider.IsExpired() {
+		t.Fatalf("expected the credentials not to have expired")
+	}
+}
+
+func TestCachedProviderSharedByConcurrentTargets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "knope")
+	if err != nil {
+		t.Fatalf("expected no error; got %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "role.json")
+
+	var fetches int32
+	fetch := func() (credentials.Value, time.Time, error) {
+		atomic.AddInt32(&fetches, 1)
+		// Asking for an MFA code takes a while, giving the others time to race
+		time.Sleep(20 * time.Millisecond)
+		return credentials.Value{AccessKeyID: "AKIA", SecretAccessKey: "secret", SessionToken: "token"}, time.Now().Add(time.Hour), nil
+	}
+
+	// The same role in several regions is a provider per target, sharing a cache file
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for target := 0; target < 10; target++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := client.NewCachedProvider(path, fetch).Retrieve()
+			if err == nil && value.AccessKeyID != "AKIA" {
+				err = fmt.Errorf("expected the credentials to be returned; got %+v", value)
+			}
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("expected no error; got %v", err)
+		}
+	}
+
+	if fetches != 1 {
+		t.Fatalf("expected the credentials to be fetched once; got %d", fetches)
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("expected no error; got %v", err)
+	}
+	if len(files) != 1 || files[0].Name() != "role.json" {
+		t.Fatalf("expected only the cache file to be left behind; got %d files", len(files))
+	}
+}
@@ -0,0 +1,49 @@
+package client
+
+import (
+	"sync"
+
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+)
+
+// LogsAPI defines the CloudWatch Logs client interface
+type LogsAPI interface {
+	GetLogEvents(input *cloudwatchlogs.GetLogEventsInput) (*cloudwatchlogs.GetLogEventsOutput, error)
+}
+
+// LogsClient is the content implementation of the LogsAPI we are using in
+// the app. Like Client, the CloudWatch Logs client is created on first use.
+type LogsClient struct {
+	once *sync.Once
+	logs *cloudwatchlogs.CloudWatchLogs
+	err  error
+}
+
+// NewLogsClient will return a internal CloudWatch Logs client.
+func NewLogsClient() LogsClient {
+	return LogsClient{once: &sync.Once{}}
+}
+
+// service creates the CloudWatch Logs client the first time it is needed
+func (c *LogsClient) service() (*cloudwatchlogs.CloudWatchLogs, error) {
+	c.once.Do(func() {
+		sess, err := sessionFor(nil)
+		if err != nil {
+			c.err = err
+			return
+		}
+		c.logs = cloudwatchlogs.New(sess)
+	})
+
+	return c.logs, c.err
+}
+
+// GetLogEvents will call the same function on the CloudWatch Logs client
+func (c *LogsClient) GetLogEvents(input *cloudwatchlogs.GetLogEventsInput) (*cloudwatchlogs.GetLogEventsOutput, error) {
+	svc, err := c.service()
+	if err != nil {
+		return nil, err
+	}
+
+	return svc.GetLogEvents(input)
+}
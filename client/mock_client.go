@@ -8,6 +8,7 @@ import (
 	reflect "reflect"
 
 	codebuild "github.com/aws/aws-sdk-go/service/codebuild"
+	sts "github.com/aws/aws-sdk-go/service/sts"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -34,6 +35,21 @@ func (m *MockAPI) EXPECT() *MockAPIMockRecorder {
 	return m.recorder
 }
 
+// BatchGetBuildBatches mocks base method
+func (m *MockAPI) BatchGetBuildBatches(input *codebuild.BatchGetBuildBatchesInput) (*codebuild.BatchGetBuildBatchesOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BatchGetBuildBatches", input)
+	ret0, _ := ret[0].(*codebuild.BatchGetBuildBatchesOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BatchGetBuildBatches indicates an expected call of BatchGetBuildBatches
+func (mr *MockAPIMockRecorder) BatchGetBuildBatches(input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchGetBuildBatches", reflect.TypeOf((*MockAPI)(nil).BatchGetBuildBatches), input)
+}
+
 // BatchGetBuilds mocks base method
 func (m *MockAPI) BatchGetBuilds(input *codebuild.BatchGetBuildsInput) (*codebuild.BatchGetBuildsOutput, error) {
 	m.ctrl.T.Helper()
@@ -49,6 +65,111 @@ func (mr *MockAPIMockRecorder) BatchGetBuilds(input interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchGetBuilds", reflect.TypeOf((*MockAPI)(nil).BatchGetBuilds), input)
 }
 
+// BatchGetProjects mocks base method
+func (m *MockAPI) BatchGetProjects(input *codebuild.BatchGetProjectsInput) (*codebuild.BatchGetProjectsOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BatchGetProjects", input)
+	ret0, _ := ret[0].(*codebuild.BatchGetProjectsOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BatchGetProjects indicates an expected call of BatchGetProjects
+func (mr *MockAPIMockRecorder) BatchGetProjects(input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchGetProjects", reflect.TypeOf((*MockAPI)(nil).BatchGetProjects), input)
+}
+
+// BatchGetReports mocks base method
+func (m *MockAPI) BatchGetReports(input *codebuild.BatchGetReportsInput) (*codebuild.BatchGetReportsOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BatchGetReports", input)
+	ret0, _ := ret[0].(*codebuild.BatchGetReportsOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BatchGetReports indicates an expected call of BatchGetReports
+func (mr *MockAPIMockRecorder) BatchGetReports(input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchGetReports", reflect.TypeOf((*MockAPI)(nil).BatchGetReports), input)
+}
+
+// CreateProject mocks base method
+func (m *MockAPI) CreateProject(input *codebuild.CreateProjectInput) (*codebuild.CreateProjectOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateProject", input)
+	ret0, _ := ret[0].(*codebuild.CreateProjectOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateProject indicates an expected call of CreateProject
+func (mr *MockAPIMockRecorder) CreateProject(input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateProject", reflect.TypeOf((*MockAPI)(nil).CreateProject), input)
+}
+
+// DescribeCodeCoverages mocks base method
+func (m *MockAPI) DescribeCodeCoverages(input *codebuild.DescribeCodeCoveragesInput) (*codebuild.DescribeCodeCoveragesOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DescribeCodeCoverages", input)
+	ret0, _ := ret[0].(*codebuild.DescribeCodeCoveragesOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DescribeCodeCoverages indicates an expected call of DescribeCodeCoverages
+func (mr *MockAPIMockRecorder) DescribeCodeCoverages(input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DescribeCodeCoverages", reflect.TypeOf((*MockAPI)(nil).DescribeCodeCoverages), input)
+}
+
+// DescribeTestCases mocks base method
+func (m *MockAPI) DescribeTestCases(input *codebuild.DescribeTestCasesInput) (*codebuild.DescribeTestCasesOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DescribeTestCases", input)
+	ret0, _ := ret[0].(*codebuild.DescribeTestCasesOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DescribeTestCases indicates an expected call of DescribeTestCases
+func (mr *MockAPIMockRecorder) DescribeTestCases(input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DescribeTestCases", reflect.TypeOf((*MockAPI)(nil).DescribeTestCases), input)
+}
+
+// GetCallerIdentity mocks base method
+func (m *MockAPI) GetCallerIdentity(input *sts.GetCallerIdentityInput) (*sts.GetCallerIdentityOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCallerIdentity", input)
+	ret0, _ := ret[0].(*sts.GetCallerIdentityOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCallerIdentity indicates an expected call of GetCallerIdentity
+func (mr *MockAPIMockRecorder) GetCallerIdentity(input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCallerIdentity", reflect.TypeOf((*MockAPI)(nil).GetCallerIdentity), input)
+}
+
+// ListBuildBatchesForProject mocks base method
+func (m *MockAPI) ListBuildBatchesForProject(input *codebuild.ListBuildBatchesForProjectInput) (*codebuild.ListBuildBatchesForProjectOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBuildBatchesForProject", input)
+	ret0, _ := ret[0].(*codebuild.ListBuildBatchesForProjectOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBuildBatchesForProject indicates an expected call of ListBuildBatchesForProject
+func (mr *MockAPIMockRecorder) ListBuildBatchesForProject(input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBuildBatchesForProject", reflect.TypeOf((*MockAPI)(nil).ListBuildBatchesForProject), input)
+}
+
 // ListBuildsForProject mocks base method
 func (m *MockAPI) ListBuildsForProject(input *codebuild.ListBuildsForProjectInput) (*codebuild.ListBuildsForProjectOutput, error) {
 	m.ctrl.T.Helper()
@@ -78,3 +199,138 @@ func (mr *MockAPIMockRecorder) ListProjects(input interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListProjects", reflect.TypeOf((*MockAPI)(nil).ListProjects), input)
 }
+
+// ListReportGroups mocks base method
+func (m *MockAPI) ListReportGroups(input *codebuild.ListReportGroupsInput) (*codebuild.ListReportGroupsOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListReportGroups", input)
+	ret0, _ := ret[0].(*codebuild.ListReportGroupsOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListReportGroups indicates an expected call of ListReportGroups
+func (mr *MockAPIMockRecorder) ListReportGroups(input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListReportGroups", reflect.TypeOf((*MockAPI)(nil).ListReportGroups), input)
+}
+
+// ListReportsForReportGroup mocks base method
+func (m *MockAPI) ListReportsForReportGroup(input *codebuild.ListReportsForReportGroupInput) (*codebuild.ListReportsForReportGroupOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListReportsForReportGroup", input)
+	ret0, _ := ret[0].(*codebuild.ListReportsForReportGroupOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListReportsForReportGroup indicates an expected call of ListReportsForReportGroup
+func (mr *MockAPIMockRecorder) ListReportsForReportGroup(input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListReportsForReportGroup", reflect.TypeOf((*MockAPI)(nil).ListReportsForReportGroup), input)
+}
+
+// RetryBuild mocks base method
+func (m *MockAPI) RetryBuild(input *codebuild.RetryBuildInput) (*codebuild.RetryBuildOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RetryBuild", input)
+	ret0, _ := ret[0].(*codebuild.RetryBuildOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RetryBuild indicates an expected call of RetryBuild
+func (mr *MockAPIMockRecorder) RetryBuild(input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetryBuild", reflect.TypeOf((*MockAPI)(nil).RetryBuild), input)
+}
+
+// RetryBuildBatch mocks base method
+func (m *MockAPI) RetryBuildBatch(input *codebuild.RetryBuildBatchInput) (*codebuild.RetryBuildBatchOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RetryBuildBatch", input)
+	ret0, _ := ret[0].(*codebuild.RetryBuildBatchOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RetryBuildBatch indicates an expected call of RetryBuildBatch
+func (mr *MockAPIMockRecorder) RetryBuildBatch(input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetryBuildBatch", reflect.TypeOf((*MockAPI)(nil).RetryBuildBatch), input)
+}
+
+// StartBuild mocks base method
+func (m *MockAPI) StartBuild(input *codebuild.StartBuildInput) (*codebuild.StartBuildOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartBuild", input)
+	ret0, _ := ret[0].(*codebuild.StartBuildOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StartBuild indicates an expected call of StartBuild
+func (mr *MockAPIMockRecorder) StartBuild(input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartBuild", reflect.TypeOf((*MockAPI)(nil).StartBuild), input)
+}
+
+// StartBuildBatch mocks base method
+func (m *MockAPI) StartBuildBatch(input *codebuild.StartBuildBatchInput) (*codebuild.StartBuildBatchOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartBuildBatch", input)
+	ret0, _ := ret[0].(*codebuild.StartBuildBatchOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StartBuildBatch indicates an expected call of StartBuildBatch
+func (mr *MockAPIMockRecorder) StartBuildBatch(input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartBuildBatch", reflect.TypeOf((*MockAPI)(nil).StartBuildBatch), input)
+}
+
+// StopBuild mocks base method
+func (m *MockAPI) StopBuild(input *codebuild.StopBuildInput) (*codebuild.StopBuildOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StopBuild", input)
+	ret0, _ := ret[0].(*codebuild.StopBuildOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StopBuild indicates an expected call of StopBuild
+func (mr *MockAPIMockRecorder) StopBuild(input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StopBuild", reflect.TypeOf((*MockAPI)(nil).StopBuild), input)
+}
+
+// StopBuildBatch mocks base method
+func (m *MockAPI) StopBuildBatch(input *codebuild.StopBuildBatchInput) (*codebuild.StopBuildBatchOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StopBuildBatch", input)
+	ret0, _ := ret[0].(*codebuild.StopBuildBatchOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StopBuildBatch indicates an expected call of StopBuildBatch
+func (mr *MockAPIMockRecorder) StopBuildBatch(input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StopBuildBatch", reflect.TypeOf((*MockAPI)(nil).StopBuildBatch), input)
+}
+
+// UpdateProject mocks base method
+func (m *MockAPI) UpdateProject(input *codebuild.UpdateProjectInput) (*codebuild.UpdateProjectOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateProject", input)
+	ret0, _ := ret[0].(*codebuild.UpdateProjectOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateProject indicates an expected call of UpdateProject
+func (mr *MockAPIMockRecorder) UpdateProject(input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProject", reflect.TypeOf((*MockAPI)(nil).UpdateProject), input)
+}
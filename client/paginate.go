@@ -0,0 +1,367 @@
+package client
+
+import (
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/codebuild"
+)
+
+// BatchGetBuildsLimit is the maximum number of build IDs BatchGetBuilds will accept in one call
+const BatchGetBuildsLimit = 100
+
+// BatchGetBuildBatchesLimit is the maximum number of batch IDs BatchGetBuildBatches will accept in one call
+const BatchGetBuildBatchesLimit = 100
+
+// BatchGetProjectsLimit is the maximum number of project names BatchGetProjects will accept in one call
+const BatchGetProjectsLimit = 100
+
+// BatchGetReportsLimit is the maximum number of report ARNs BatchGetReports will accept in one call
+const BatchGetReportsLimit = 100
+
+// Iterator walks through a paginated list of names or IDs, requesting the
+// next page from the API only when the current one has been consumed.
+type Iterator struct {
+	fetch   func(token *string) ([]*string, *string, error)
+	limit   int
+	seen    int
+	page    []*string
+	token   *string
+	started bool
+	current *string
+	err     error
+}
+
+// NewProjectIterator returns an iterator over every project returned by
+// ListProjects. A limit of zero or less means there is no limit.
+func NewProjectIterator(api API, input *codebuild.ListProjectsInput, limit int) *Iterator {
+	in := codebuild.ListProjectsInput{}
+	if input != nil {
+		in = *input
+	}
+
+	return &Iterator{
+		limit: limit,
+		fetch: func(token *string) ([]*string, *string, error) {
+			in.NextToken = token
+			out, err := api.ListProjects(&in)
+			if err != nil {
+				return nil, nil, err
+			}
+			return out.Projects, out.NextToken, nil
+		},
+	}
+}
+
+// NewBuildIDIterator returns an iterator over every build ID returned by
+// ListBuildsForProject. A limit of zero or less means there is no limit.
+func NewBuildIDIterator(api API, input *codebuild.ListBuildsForProjectInput, limit int) *Iterator {
+	in := codebuild.ListBuildsForProjectInput{}
+	if input != nil {
+		in = *input
+	}
+
+	return &Iterator{
+		limit: limit,
+		fetch: func(token *string) ([]*string, *string, error) {
+			in.NextToken = token
+			out, err := api.ListBuildsForProject(&in)
+			if err != nil {
+				return nil, nil, err
+			}
+			return out.Ids, out.NextToken, nil
+		},
+	}
+}
+
+// NewBuildBatchIDIterator returns an iterator over every batch ID returned by
+// ListBuildBatchesForProject. A limit of zero or less means there is no limit.
+func NewBuildBatchIDIterator(api API, input *codebuild.ListBuildBatchesForProjectInput, limit int) *Iterator {
+	in := codebuild.ListBuildBatchesForProjectInput{}
+	if input != nil {
+		in = *input
+	}
+
+	return &Iterator{
+		limit: limit,
+		fetch: func(token *string) ([]*string, *string, error) {
+			in.NextToken = token
+			out, err := api.ListBuildBatchesForProject(&in)
+			if err != nil {
+				return nil, nil, err
+			}
+			return out.Ids, out.NextToken, nil
+		},
+	}
+}
+
+// NewReportGroupIterator returns an iterator over every report group ARN
+// returned by ListReportGroups. A limit of zero or less means there is no limit.
+func NewReportGroupIterator(api API, input *codebuild.ListReportGroupsInput, limit int) *Iterator {
+	in := codebuild.ListReportGroupsInput{}
+	if input != nil {
+		in = *input
+	}
+
+	return &Iterator{
+		limit: limit,
+		fetch: func(token *string) ([]*string, *string, error) {
+			in.NextToken = token
+			out, err := api.ListReportGroups(&in)
+			if err != nil {
+				return nil, nil, err
+			}
+			return out.ReportGroups, out.NextToken, nil
+		},
+	}
+}
+
+// NewReportIterator returns an iterator over every report ARN returned by
+// ListReportsForReportGroup. A limit of zero or less means there is no limit.
+func NewReportIterator(api API, input *codebuild.ListReportsForReportGroupInput, limit int) *Iterator {
+	in := codebuild.ListReportsForReportGroupInput{}
+	if input != nil {
+		in = *input
+	}
+
+	return &Iterator{
+		limit: limit,
+		fetch: func(token *string) ([]*string, *string, error) {
+			in.NextToken = token
+			out, err := api.ListReportsForReportGroup(&in)
+			if err != nil {
+				return nil, nil, err
+			}
+			return out.Reports, out.NextToken, nil
+		},
+	}
+}
+
+// Next advances the iterator, returning false when there is nothing left
+// or an error occurred. Check Err once Next returns false.
+func (i *Iterator) Next() bool {
+	if i.err != nil || (i.limit > 0 && i.seen >= i.limit) {
+		return false
+	}
+
+	for len(i.page) == 0 {
+		if i.started && aws.StringValue(i.token) == "" {
+			return false
+		}
+
+		page, token, err := i.fetch(i.token)
+		i.started = true
+		if err != nil {
+			i.err = err
+			return false
+		}
+
+		i.page = page
+		i.token = token
+	}
+
+	i.current = i.page[0]
+	i.page = i.page[1:]
+	i.seen++
+
+	return true
+}
+
+// Value returns the current item
+func (i *Iterator) Value() *string {
+	return i.current
+}
+
+// Err returns the error that stopped the iteration, if any
+func (i *Iterator) Err() error {
+	return i.err
+}
+
+// All drains the iterator and returns everything it had left
+func (i *Iterator) All() ([]*string, error) {
+	var values []*string
+	for i.Next() {
+		values = append(values, i.Value())
+	}
+
+	return values, i.Err()
+}
+
+// BuildIterator walks through the builds for a project, paging through
+// ListBuildsForProject and hydrating the IDs with BatchGetBuilds in chunks.
+type BuildIterator struct {
+	api     API
+	ids     *Iterator
+	limit   int
+	seen    int
+	page    []*codebuild.Build
+	current *codebuild.Build
+	err     error
+}
+
+// NewBuildIterator returns an iterator over the builds for a project. A
+// limit of zero or less means there is no limit.
+func NewBuildIterator(api API, input *codebuild.ListBuildsForProjectInput, limit int) *BuildIterator {
+	return &BuildIterator{
+		api:   api,
+		ids:   NewBuildIDIterator(api, input, limit),
+		limit: limit,
+	}
+}
+
+// Next advances the iterator, returning false when there is nothing left
+// or an error occurred. Check Err once Next returns false.
+func (b *BuildIterator) Next() bool {
+	if b.err != nil || (b.limit > 0 && b.seen >= b.limit) {
+		return false
+	}
+
+	for len(b.page) == 0 {
+		size := BatchGetBuildsLimit
+		if b.limit > 0 && b.limit-b.seen < size {
+			size = b.limit - b.seen
+		}
+
+		var chunk []*string
+		for len(chunk) < size && b.ids.Next() {
+			chunk = append(chunk, b.ids.Value())
+		}
+
+		if err := b.ids.Err(); err != nil {
+			b.err = err
+			return false
+		}
+
+		if len(chunk) == 0 {
+			return false
+		}
+
+		out, err := b.api.BatchGetBuilds(&codebuild.BatchGetBuildsInput{Ids: chunk})
+		if err != nil {
+			b.err = err
+			return false
+		}
+
+		b.page = inOrder(chunk, out.Builds)
+	}
+
+	b.current = b.page[0]
+	b.page = b.page[1:]
+	b.seen++
+
+	return true
+}
+
+// Build returns the current build
+func (b *BuildIterator) Build() *codebuild.Build {
+	return b.current
+}
+
+// Err returns the error that stopped the iteration, if any
+func (b *BuildIterator) Err() error {
+	return b.err
+}
+
+// inOrder sorts the builds to match the order of the IDs we asked for, as
+// BatchGetBuilds does not promise to keep it
+func inOrder(ids []*string, builds []*codebuild.Build) []*codebuild.Build {
+	position := map[string]int{}
+	for i, id := range ids {
+		position[aws.StringValue(id)] = i
+	}
+
+	sorted := append([]*codebuild.Build{}, builds...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return position[aws.StringValue(sorted[i].Id)] < position[aws.StringValue(sorted[j].Id)]
+	})
+
+	return sorted
+}
+
+// GetBuildBatches will call BatchGetBuildBatches as many times as needed to
+// stay within the API limit, returning the batches in the order of the IDs.
+func GetBuildBatches(api API, ids []*string) ([]*codebuild.BuildBatch, error) {
+	var batches []*codebuild.BuildBatch
+	for start := 0; start < len(ids); start += BatchGetBuildBatchesLimit {
+		end := start + BatchGetBuildBatchesLimit
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		out, err := api.BatchGetBuildBatches(&codebuild.BatchGetBuildBatchesInput{Ids: ids[start:end]})
+		if err != nil {
+			return nil, err
+		}
+
+		byID := map[string]*codebuild.BuildBatch{}
+		for _, batch := range out.BuildBatches {
+			byID[aws.StringValue(batch.Id)] = batch
+		}
+		for _, id := range ids[start:end] {
+			if batch, ok := byID[aws.StringValue(id)]; ok {
+				batches = append(batches, batch)
+			}
+		}
+	}
+
+	return batches, nil
+}
+
+// GetReports will call BatchGetReports as many times as needed to stay
+// within the API limit, returning the reports in the order of the ARNs.
+func GetReports(api API, arns []*string) ([]*codebuild.Report, error) {
+	var reports []*codebuild.Report
+	for start := 0; start < len(arns); start += BatchGetReportsLimit {
+		end := start + BatchGetReportsLimit
+		if end > len(arns) {
+			end = len(arns)
+		}
+
+		out, err := api.BatchGetReports(&codebuild.BatchGetReportsInput{ReportArns: arns[start:end]})
+		if err != nil {
+			return nil, err
+		}
+
+		byArn := map[string]*codebuild.Report{}
+		for _, report := range out.Reports {
+			byArn[aws.StringValue(report.Arn)] = report
+		}
+		for _, arn := range arns[start:end] {
+			if report, ok := byArn[aws.StringValue(arn)]; ok {
+				reports = append(reports, report)
+			}
+		}
+	}
+
+	return reports, nil
+}
+
+// GetProjects will call BatchGetProjects as many times as needed to stay
+// within the API limit, returning the projects in the order of the names.
+// Projects that do not exist are left out.
+func GetProjects(api API, names []*string) ([]*codebuild.Project, error) {
+	var projects []*codebuild.Project
+	for start := 0; start < len(names); start += BatchGetProjectsLimit {
+		end := start + BatchGetProjectsLimit
+		if end > len(names) {
+			end = len(names)
+		}
+
+		out, err := api.BatchGetProjects(&codebuild.BatchGetProjectsInput{Names: names[start:end]})
+		if err != nil {
+			return nil, err
+		}
+
+		byName := map[string]*codebuild.Project{}
+		for _, project := range out.Projects {
+			byName[aws.StringValue(project.Name)] = project
+		}
+		for _, name := range names[start:end] {
+			if project, ok := byName[aws.StringValue(name)]; ok {
+				projects = append(projects, project)
+			}
+		}
+	}
+
+	return projects, nil
+}
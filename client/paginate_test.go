@@ -0,0 +1,287 @@
+package client_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/codebuild"
+	"github.com/benmatselby/knope/client"
+	"github.com/golang/mock/gomock"
+)
+
+func makeIDs(prefix string, count int) []*string {
+	var ids []*string
+	for i := 0; i < count; i++ {
+		ids = append(ids, aws.String(fmt.Sprintf("%s-%d", prefix, i)))
+	}
+	return ids
+}
+
+func TestProjectIterator(t *testing.T) {
+	tt := []struct {
+		name     string
+		limit    int
+		expected int
+		calls    int
+	}{
+		{name: "can walk every page", limit: 0, expected: 5, calls: 3},
+		{name: "can stop at the limit", limit: 3, expected: 3, calls: 2},
+		{name: "does not request another page when the limit is on a page boundary", limit: 2, expected: 2, calls: 1},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			api := client.NewMockAPI(ctrl)
+
+			pages := map[string]*codebuild.ListProjectsOutput{
+				"":       {Projects: makeIDs("a", 2), NextToken: aws.String("page-2")},
+				"page-2": {Projects: makeIDs("b", 2), NextToken: aws.String("page-3")},
+				"page-3": {Projects: makeIDs("c", 1)},
+			}
+
+			calls := 0
+			api.
+				EXPECT().
+				ListProjects(gomock.Any()).
+				DoAndReturn(func(input *codebuild.ListProjectsInput) (*codebuild.ListProjectsOutput, error) {
+					calls++
+					return pages[aws.StringValue(input.NextToken)], nil
+				}).
+				AnyTimes()
+
+			projects, err := client.NewProjectIterator(api, nil, tc.limit).All()
+			if err != nil {
+				t.Fatalf("expected no error; got %v", err)
+			}
+
+			if len(projects) != tc.expected {
+				t.Fatalf("expected %d projects; got %d", tc.expected, len(projects))
+			}
+
+			if calls != tc.calls {
+				t.Fatalf("expected %d calls; got %d", tc.calls, calls)
+			}
+		})
+	}
+}
+
+func TestBuildIDIteratorReturnsErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	api := client.NewMockAPI(ctrl)
+
+	expected := errors.New("there was an error")
+	api.
+		EXPECT().
+		ListBuildsForProject(gomock.Any()).
+		Return(nil, expected)
+
+	ids, err := client.NewBuildIDIterator(api, nil, 0).All()
+	if err != expected {
+		t.Fatalf("expected err to be %v; got %v", expected, err)
+	}
+
+	if len(ids) != 0 {
+		t.Fatalf("expected no ids; got %d", len(ids))
+	}
+}
+
+func TestBuildIterator(t *testing.T) {
+	tt := []struct {
+		name     string
+		limit    int
+		expected int
+		chunks   []int
+		reversed bool
+	}{
+		{name: "can chunk the ids given to BatchGetBuilds", limit: 0, expected: 250, chunks: []int{100, 100, 50}},
+		{name: "keeps the builds in the order they were listed", limit: 0, expected: 250, chunks: []int{100, 100, 50}, reversed: true},
+		{name: "only asks for as many builds as the limit", limit: 1, expected: 1, chunks: []int{1}},
+		{name: "can limit across chunks", limit: 150, expected: 150, chunks: []int{100, 50}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			api := client.NewMockAPI(ctrl)
+
+			ids := makeIDs("build", 250)
+			api.
+				EXPECT().
+				ListBuildsForProject(gomock.Any()).
+				DoAndReturn(func(input *codebuild.ListBuildsForProjectInput) (*codebuild.ListBuildsForProjectOutput, error) {
+					if input.NextToken == nil {
+						return &codebuild.ListBuildsForProjectOutput{Ids: ids[:200], NextToken: aws.String("next")}, nil
+					}
+					return &codebuild.ListBuildsForProjectOutput{Ids: ids[200:]}, nil
+				}).
+				AnyTimes()
+
+			var chunks []int
+			api.
+				EXPECT().
+				BatchGetBuilds(gomock.Any()).
+				DoAndReturn(func(input *codebuild.BatchGetBuildsInput) (*codebuild.BatchGetBuildsOutput, error) {
+					chunks = append(chunks, len(input.Ids))
+					var builds []*codebuild.Build
+					for _, id := range input.Ids {
+						if tc.reversed {
+							builds = append([]*codebuild.Build{{Id: id}}, builds...)
+						} else {
+							builds = append(builds, &codebuild.Build{Id: id})
+						}
+					}
+					return &codebuild.BatchGetBuildsOutput{Builds: builds}, nil
+				}).
+				AnyTimes()
+
+			iter := client.NewBuildIterator(api, &codebuild.ListBuildsForProjectInput{ProjectName: aws.String("project")}, tc.limit)
+			count := 0
+			for iter.Next() {
+				if aws.StringValue(iter.Build().Id) != aws.StringValue(ids[count]) {
+					t.Fatalf("expected build %s; got %s", aws.StringValue(ids[count]), aws.StringValue(iter.Build().Id))
+				}
+				count++
+			}
+
+			if iter.Err() != nil {
+				t.Fatalf("expected no error; got %v", iter.Err())
+			}
+
+			if count != tc.expected {
+				t.Fatalf("expected %d builds; got %d", tc.expected, count)
+			}
+
+			if fmt.Sprint(chunks) != fmt.Sprint(tc.chunks) {
+				t.Fatalf("expected chunks %v; got %v", tc.chunks, chunks)
+			}
+		})
+	}
+}
+
+func TestGetReports(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	api := client.NewMockAPI(ctrl)
+
+	var chunks []int
+	api.
+		EXPECT().
+		BatchGetReports(gomock.Any()).
+		DoAndReturn(func(input *codebuild.BatchGetReportsInput) (*codebuild.BatchGetReportsOutput, error) {
+			chunks = append(chunks, len(input.ReportArns))
+			var reports []*codebuild.Report
+			for _, arn := range input.ReportArns {
+				reports = append([]*codebuild.Report{{Arn: arn}}, reports...)
+			}
+			return &codebuild.BatchGetReportsOutput{Reports: reports}, nil
+		}).
+		AnyTimes()
+
+	arns := makeIDs("report", 150)
+	reports, err := client.GetReports(api, arns)
+	if err != nil {
+		t.Fatalf("expected no error; got %v", err)
+	}
+
+	if len(reports) != 150 {
+		t.Fatalf("expected 150 reports; got %d", len(reports))
+	}
+
+	for i, report := range reports {
+		if aws.StringValue(report.Arn) != aws.StringValue(arns[i]) {
+			t.Fatalf("expected report %d to be %s; got %s", i, aws.StringValue(arns[i]), aws.StringValue(report.Arn))
+		}
+	}
+
+	if fmt.Sprint(chunks) != "[100 50]" {
+		t.Fatalf("expected chunks [100 50]; got %v", chunks)
+	}
+}
+
+func TestGetBuildBatches(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	api := client.NewMockAPI(ctrl)
+
+	var chunks []int
+	api.
+		EXPECT().
+		BatchGetBuildBatches(gomock.Any()).
+		DoAndReturn(func(input *codebuild.BatchGetBuildBatchesInput) (*codebuild.BatchGetBuildBatchesOutput, error) {
+			chunks = append(chunks, len(input.Ids))
+			var batches []*codebuild.BuildBatch
+			for _, id := range input.Ids {
+				batches = append([]*codebuild.BuildBatch{{Id: id}}, batches...)
+			}
+			return &codebuild.BatchGetBuildBatchesOutput{BuildBatches: batches}, nil
+		}).
+		AnyTimes()
+
+	ids := makeIDs("batch", 120)
+	batches, err := client.GetBuildBatches(api, ids)
+	if err != nil {
+		t.Fatalf("expected no error; got %v", err)
+	}
+
+	if len(batches) != 120 {
+		t.Fatalf("expected 120 batches; got %d", len(batches))
+	}
+
+	for i, batch := range batches {
+		if aws.StringValue(batch.Id) != aws.StringValue(ids[i]) {
+			t.Fatalf("expected batch %d to be %s; got %s", i, aws.StringValue(ids[i]), aws.StringValue(batch.Id))
+		}
+	}
+
+	if fmt.Sprint(chunks) != "[100 20]" {
+		t.Fatalf("expected chunks [100 20]; got %v", chunks)
+	}
+}
+
+func TestGetProjects(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	api := client.NewMockAPI(ctrl)
+
+	var chunks []int
+	api.
+		EXPECT().
+		BatchGetProjects(gomock.Any()).
+		DoAndReturn(func(input *codebuild.BatchGetProjectsInput) (*codebuild.BatchGetProjectsOutput, error) {
+			chunks = append(chunks, len(input.Names))
+			out := &codebuild.BatchGetProjectsOutput{}
+			for _, name := range input.Names {
+				if aws.StringValue(name) == "project-3" {
+					out.ProjectsNotFound = append(out.ProjectsNotFound, name)
+					continue
+				}
+				out.Projects = append([]*codebuild.Project{{Name: name}}, out.Projects...)
+			}
+			return out, nil
+		}).
+		AnyTimes()
+
+	names := makeIDs("project", 101)
+	projects, err := client.GetProjects(api, names)
+	if err != nil {
+		t.Fatalf("expected no error; got %v", err)
+	}
+
+	if len(projects) != 100 {
+		t.Fatalf("expected 100 projects; got %d", len(projects))
+	}
+
+	if aws.StringValue(projects[3].Name) != "project-4" {
+		t.Fatalf("expected the missing project to be left out; got %s", aws.StringValue(projects[3].Name))
+	}
+
+	if fmt.Sprint(chunks) != "[100 1]" {
+		t.Fatalf("expected chunks [100 1]; got %v", chunks)
+	}
+}
@@ -0,0 +1,173 @@
+package client
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/benmatselby/knope/config"
+	"github.com/spf13/viper"
+)
+
+// Target is an AWS account and region to talk to
+type Target struct {
+	Profile string
+	Region  string
+	// RoleARN is the role to assume, or a comma separated chain of roles
+	// where each is assumed using the credentials of the one before
+	RoleARN    string
+	ExternalID string
+	MFASerial  string
+	Duration   time.Duration
+}
+
+var (
+	sessionOnce sync.Once
+	sess        *session.Session
+	sessErr     error
+
+	// tokenLock stops sessions created at the same time asking for MFA codes
+	// over each other on stdin
+	tokenLock sync.Mutex
+)
+
+// sessionFor creates a session for the target, or uses the one shared by
+// the clients when there is no target
+func sessionFor(target *Target) (*session.Session, error) {
+	if target != nil {
+		return newSession(*target)
+	}
+
+	sessionOnce.Do(func() {
+		sess, sessErr = newSession(Target{
+			Profile:    viper.GetString(config.KeyProfile),
+			Region:     viper.GetString(config.KeyRegion),
+			RoleARN:    viper.GetString(config.KeyRoleARN),
+			ExternalID: viper.GetString(config.KeyExternalID),
+			MFASerial:  viper.GetString(config.KeyMFASerial),
+			Duration:   viper.GetDuration(config.KeySessionDuration),
+		})
+	})
+
+	return sess, sessErr
+}
+
+// newSession creates an AWS session for the target. Problems with the
+// profile, region or credentials are reported now, rather than by the
+// first call to AWS.
+func newSession(target Target) (*session.Session, error) {
+	opts := session.Options{
+		SharedConfigState:       session.SharedConfigEnable,
+		Profile:                 target.Profile,
+		AssumeRoleTokenProvider: stdinTokenProvider,
+	}
+
+	if target.Region != "" {
+		opts.Config.Region = aws.String(target.Region)
+	}
+
+	sess, err := session.NewSessionWithOptions(opts)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create an AWS session%s: %v", describeProfile(target.Profile), err)
+	}
+
+	if aws.StringValue(sess.Config.Region) == "" {
+		return nil, fmt.Errorf("no AWS region is set%s, use region in the config file, a context or AWS_REGION", describeProfile(target.Profile))
+	}
+
+	if target.RoleARN != "" {
+		cache, err := config.CacheDir()
+		if err != nil {
+			return nil, err
+		}
+
+		sess = sess.Copy(&aws.Config{Credentials: credentials.NewCredentials(
+			NewCachedProvider(filepath.Join(cache, cacheKey(target)+".json"), assumeRoles(sess, target)),
+		)})
+	}
+
+	if _, err := sess.Config.Credentials.Get(); err != nil {
+		if target.RoleARN != "" {
+			return nil, err
+		}
+		return nil, fmt.Errorf("unable to find AWS credentials%s: %v", describeProfile(target.Profile), err)
+	}
+
+	return sess, nil
+}
+
+// assumeRoles works through the chain of roles in the target, returning
+// the credentials for the last one
+func assumeRoles(sess *session.Session, target Target) FetchCredentials {
+	return func() (credentials.Value, time.Time, error) {
+		roles := strings.Split(target.RoleARN, ",")
+		current := sess
+
+		for i, role := range roles {
+			role = strings.TrimSpace(role)
+			provider := &stscreds.AssumeRoleProvider{
+				Client:          sts.New(current),
+				RoleARN:         role,
+				RoleSessionName: fmt.Sprintf("knope-%d", time.Now().Unix()),
+				Duration:        target.Duration,
+			}
+
+			// MFA proves who we are at the start of the chain, where as the
+			// external ID is what the account at the end asked for
+			if i == 0 && target.MFASerial != "" {
+				provider.SerialNumber = aws.String(target.MFASerial)
+				provider.TokenProvider = stdinTokenProvider
+			}
+			if i == len(roles)-1 && target.ExternalID != "" {
+				provider.ExternalID = aws.String(target.ExternalID)
+			}
+
+			value, err := provider.Retrieve()
+			if err != nil {
+				return value, time.Time{}, fmt.Errorf("unable to assume role %s: %v", role, err)
+			}
+
+			if i == len(roles)-1 {
+				return value, provider.ExpiresAt(), nil
+			}
+
+			current = sess.Copy(&aws.Config{Credentials: credentials.NewStaticCredentialsFromCreds(value)})
+		}
+
+		return credentials.Value{}, time.Time{}, fmt.Errorf("no role to assume")
+	}
+}
+
+// stdinTokenProvider asks for an MFA code on stdin, one prompt at a time
+func stdinTokenProvider() (string, error) {
+	tokenLock.Lock()
+	defer tokenLock.Unlock()
+
+	return stscreds.StdinTokenProvider()
+}
+
+// cacheKey names the cached credentials for the target, so different roles,
+// source profiles and session durations do not share credentials, and a
+// longer session is never served from a shorter one. The region is left
+// out, as the credentials for a role work in every region, and targets in
+// several regions take turns to fetch them, see CachedProvider.
+func cacheKey(target Target) string {
+	sum := sha1.Sum([]byte(strings.Join([]string{target.Profile, target.RoleARN, target.ExternalID, target.MFASerial, target.Duration.String()}, "|")))
+	return fmt.Sprintf("%x", sum)
+}
+
+// describeProfile adds the profile to error messages, when there is one
+func describeProfile(profile string) string {
+	if profile == "" {
+		return ""
+	}
+	return fmt.Sprintf(" for profile %q", profile)
+}
@@ -0,0 +1,302 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/codebuild"
+	"github.com/benmatselby/knope/client"
+	"github.com/benmatselby/knope/ui"
+	"github.com/spf13/cobra"
+)
+
+// BatchOptions defines what arguments/options the user can provide
+type BatchOptions struct {
+	Args          []string
+	Project       string
+	SourceVersion string
+	Env           []string
+	Buildspec     string
+	FailedOnly    bool
+}
+
+// NewBatchCommand creates a new `batch` command
+func NewBatchCommand(client client.API) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "batch",
+		Short: "Show, start, stop and retry batch builds",
+	}
+
+	cmd.AddCommand(
+		newBatchShowCommand(client),
+		newBatchStartCommand(client),
+		newBatchStopCommand(client),
+		newBatchRetryCommand(client),
+	)
+
+	return cmd
+}
+
+func newBatchShowCommand(client client.API) *cobra.Command {
+	var opts BatchOptions
+
+	return &cobra.Command{
+		Use:   "show [batch-id|project:latest]",
+		Short: "Show a batch build and the graph of builds in it",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Args = args
+			return DisplayBatch(client, opts, os.Stdout)
+		},
+	}
+}
+
+func newBatchStartCommand(client client.API) *cobra.Command {
+	var opts BatchOptions
+
+	cmd := &cobra.Command{
+		Use:   "start",
+		Short: "Start a batch build for a given project",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Args = args
+			return StartBatch(client, opts, os.Stdout)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&opts.Project, "project", "", "Name of the project to start a batch build for")
+	flags.StringVar(&opts.SourceVersion, "source-version", "", "Branch, tag, commit or pull request (e.g. pr/123) to build")
+	flags.StringArrayVar(&opts.Env, "env", nil, "Environment variable override as KEY=VALUE, optionally prefixed with PARAMETER_STORE: or SECRETS_MANAGER:")
+	flags.StringVar(&opts.Buildspec, "buildspec", "", "Path to a buildspec file to use instead of the project's")
+
+	return cmd
+}
+
+func newBatchStopCommand(client client.API) *cobra.Command {
+	var opts BatchOptions
+
+	return &cobra.Command{
+		Use:   "stop [batch-id|project:latest]",
+		Short: "Stop an in progress batch build",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Args = args
+			return StopBatch(client, opts, os.Stdout)
+		},
+	}
+}
+
+func newBatchRetryCommand(client client.API) *cobra.Command {
+	var opts BatchOptions
+
+	cmd := &cobra.Command{
+		Use:   "retry [batch-id|project:latest]",
+		Short: "Retry a finished batch build",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Args = args
+			return RetryBatch(client, opts, os.Stdout)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.BoolVar(&opts.FailedOnly, "failed-only", false, "Only retry the builds in the batch that failed")
+
+	return cmd
+}
+
+// DisplayBatch will render a batch build, with its builds as a tree of
+// what each one depends on
+func DisplayBatch(api client.API, opts BatchOptions, w io.Writer) error {
+	if len(opts.Args) == 0 {
+		return fmt.Errorf("please specify a batch id")
+	}
+
+	id, err := resolveBatchID(api, opts.Args[0])
+	if err != nil {
+		return err
+	}
+
+	batch, err := getBatch(api, id)
+	if err != nil {
+		return err
+	}
+
+	record := newBatchRecord(batch)
+
+	tr := tabwriter.NewWriter(w, 0, 0, 1, ' ', tabwriter.FilterHTML)
+	fmt.Fprintf(tr, "Batch:\t%s\n", record.ID)
+	fmt.Fprintf(tr, "Status:\t%s %s\n", record.Icon(), record.Status)
+	fmt.Fprintf(tr, "Project:\t%s\n", record.Project)
+	fmt.Fprintf(tr, "Initiator:\t%s\n", record.Initiator)
+	fmt.Fprintf(tr, "Source version:\t%s\n", record.SourceVersion)
+	fmt.Fprintf(tr, "Commit:\t%s\n", record.Commit)
+	fmt.Fprintf(tr, "Started:\t%s\n", formatTime(record.Start))
+	fmt.Fprintf(tr, "Finished:\t%s\n", formatTime(record.Finish))
+	fmt.Fprintf(tr, "Duration:\t%s\n", formatDuration(record.Start, record.Finish))
+	tr.Flush()
+
+	fmt.Fprintf(w, "\nBuilds\n")
+	tr = tabwriter.NewWriter(w, 0, 0, 1, ' ', tabwriter.FilterHTML)
+	writeBatchTree(tr, record.Builds)
+	tr.Flush()
+
+	return nil
+}
+
+// writeBatchTree draws the builds in a batch as a tree. A build hangs off
+// the first build it depends on, and lists any others it waits for.
+func writeBatchTree(w io.Writer, builds []BatchBuildRecord) {
+	known := map[string]bool{}
+	for _, build := range builds {
+		known[build.Identifier] = true
+	}
+
+	var roots []BatchBuildRecord
+	children := map[string][]BatchBuildRecord{}
+	for _, build := range builds {
+		if len(build.DependsOn) > 0 && known[build.DependsOn[0]] {
+			children[build.DependsOn[0]] = append(children[build.DependsOn[0]], build)
+		} else {
+			roots = append(roots, build)
+		}
+	}
+
+	var walk func(nodes []BatchBuildRecord, indent string, nested bool)
+	walk = func(nodes []BatchBuildRecord, indent string, nested bool) {
+		for i, build := range nodes {
+			branch, next := "", ""
+			if nested {
+				branch, next = "├─ ", "│  "
+				if i == len(nodes)-1 {
+					branch, next = "└─ ", "   "
+				}
+			}
+
+			var notes []string
+			if len(build.DependsOn) > 1 {
+				notes = append(notes, "after "+strings.Join(build.DependsOn[1:], ", "))
+			}
+			if build.IgnoreFailure {
+				notes = append(notes, "failure ignored")
+			}
+			if build.Attempts > 1 {
+				notes = append(notes, fmt.Sprintf("attempt %d", build.Attempts))
+			}
+
+			fmt.Fprintf(w, "%s%s%s %s\t%s\t%s\t%s\n", indent, branch, build.Icon(), build.Identifier, build.Status, build.ID, strings.Join(notes, "; "))
+			walk(children[build.Identifier], indent+next, true)
+		}
+	}
+
+	walk(roots, "", false)
+}
+
+// StartBatch will start a batch build and render its ID and status
+func StartBatch(api client.API, opts BatchOptions, w io.Writer) error {
+	if opts.Project == "" {
+		return fmt.Errorf("please specify a project name")
+	}
+
+	input := &codebuild.StartBuildBatchInput{ProjectName: aws.String(opts.Project)}
+
+	if opts.SourceVersion != "" {
+		input.SourceVersion = aws.String(opts.SourceVersion)
+	}
+
+	for _, env := range opts.Env {
+		variable, err := parseEnvironmentVariable(env)
+		if err != nil {
+			return err
+		}
+		input.EnvironmentVariablesOverride = append(input.EnvironmentVariablesOverride, variable)
+	}
+
+	if opts.Buildspec != "" {
+		buildspec, err := ioutil.ReadFile(opts.Buildspec)
+		if err != nil {
+			return err
+		}
+		input.BuildspecOverride = aws.String(string(buildspec))
+	}
+
+	started, err := api.StartBuildBatch(input)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "%s %s\n", getBuildIcon(started.BuildBatch.BuildBatchStatus), aws.StringValue(started.BuildBatch.Id))
+
+	return nil
+}
+
+// StopBatch will stop the batch build and render what was stopped
+func StopBatch(api client.API, opts BatchOptions, w io.Writer) error {
+	if len(opts.Args) == 0 {
+		return fmt.Errorf("please specify a batch id")
+	}
+
+	id, err := resolveBatchID(api, opts.Args[0])
+	if err != nil {
+		return err
+	}
+
+	stopped, err := api.StopBuildBatch(&codebuild.StopBuildBatchInput{Id: id})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "%s %s\n", ui.AppStale, aws.StringValue(stopped.BuildBatch.Id))
+
+	return nil
+}
+
+// RetryBatch will retry every build in the batch, or just those that failed,
+// and render the batch ID and status
+func RetryBatch(api client.API, opts BatchOptions, w io.Writer) error {
+	if len(opts.Args) == 0 {
+		return fmt.Errorf("please specify a batch id")
+	}
+
+	id, err := resolveBatchID(api, opts.Args[0])
+	if err != nil {
+		return err
+	}
+
+	retryType := codebuild.RetryBuildBatchTypeRetryAllBuilds
+	if opts.FailedOnly {
+		retryType = codebuild.RetryBuildBatchTypeRetryFailedBuilds
+	}
+
+	retried, err := api.RetryBuildBatch(&codebuild.RetryBuildBatchInput{Id: id, RetryType: aws.String(retryType)})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "%s %s\n", getBuildIcon(retried.BuildBatch.BuildBatchStatus), aws.StringValue(retried.BuildBatch.Id))
+
+	return nil
+}
+
+// BatchBuildRecord gives us a struct to store the builds in a batch
+type BatchBuildRecord struct {
+	Identifier    string   `json:"identifier" yaml:"identifier"`
+	DependsOn     []string `json:"depends_on,omitempty" yaml:"depends_on,omitempty"`
+	ID            string   `json:"id,omitempty" yaml:"id,omitempty"`
+	Status        string   `json:"status,omitempty" yaml:"status,omitempty"`
+	IgnoreFailure bool     `json:"ignore_failure,omitempty" yaml:"ignore_failure,omitempty"`
+	Attempts      int      `json:"attempts" yaml:"attempts"`
+}
+
+// Icon is the status of the build as an icon, or pending if it has not started
+func (r BatchBuildRecord) Icon() string {
+	if r.Status == "" {
+		return ui.AppPending
+	}
+	return getBuildIcon(&r.Status)
+}
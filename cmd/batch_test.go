@@ -0,0 +1,263 @@
+package cmd_test
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/codebuild"
+	"github.com/benmatselby/knope/client"
+	"github.com/benmatselby/knope/cmd"
+	"github.com/golang/mock/gomock"
+)
+
+func TestNewBatchCommand(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := client.NewMockAPI(ctrl)
+
+	cmd := cmd.NewBatchCommand(client)
+
+	use := "batch"
+	short := "Show, start, stop and retry batch builds"
+
+	if cmd.Use != use {
+		t.Fatalf("expected use: %s; got %s", use, cmd.Use)
+	}
+
+	if cmd.Short != short {
+		t.Fatalf("expected use: %s; got %s", short, cmd.Short)
+	}
+
+	if len(cmd.Commands()) != 4 {
+		t.Fatalf("expected 4 subcommands; got %d", len(cmd.Commands()))
+	}
+}
+
+func TestDisplayBatch(t *testing.T) {
+	tt := []struct {
+		name     string
+		args     []string
+		getErr   error
+		expected string
+		err      string
+	}{
+		{
+			name: "can render a batch build as a tree",
+			args: []string{"project-one:latest"},
+			expected: `Batch:          project-one:batch-1
+Status:         ❌ FAILED
+Project:        project-one
+Initiator:      GitHub-Hookshot/abc
+Source version: main
+Commit:         f00ba4
+Started:        19-07-2019 23:00
+Finished:       19-07-2019 23:20
+Duration:       20m0s
+
+Builds
+✅ DOWNLOAD_SOURCE  SUCCEEDED project-one:1 
+├─ ✅ build_linux   SUCCEEDED project-one:2 
+│  └─ 🗂 publish                            after build_windows
+└─ ❌ build_windows FAILED    project-one:4 failure ignored; attempt 2
+`,
+		},
+		{
+			name:   "returns the error from getting the batch build",
+			args:   []string{"project-one:batch-1"},
+			getErr: errors.New("there was an error"),
+			err:    "there was an error",
+		},
+		{
+			name: "needs a batch",
+			err:  "please specify a batch id",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			client := client.NewMockAPI(ctrl)
+
+			client.
+				EXPECT().
+				ListBuildBatchesForProject(gomock.Any()).
+				Return(&codebuild.ListBuildBatchesForProjectOutput{Ids: aws.StringSlice([]string{"project-one:batch-1"})}, nil).
+				AnyTimes()
+
+			client.
+				EXPECT().
+				BatchGetBuildBatches(gomock.Any()).
+				DoAndReturn(func(input *codebuild.BatchGetBuildBatchesInput) (*codebuild.BatchGetBuildBatchesOutput, error) {
+					if aws.StringValue(input.Ids[0]) != "project-one:batch-1" {
+						t.Fatalf("expected to get project-one:batch-1; got %s", aws.StringValue(input.Ids[0]))
+					}
+					return &codebuild.BatchGetBuildBatchesOutput{BuildBatches: []*codebuild.BuildBatch{testBatch()}}, tc.getErr
+				}).
+				AnyTimes()
+
+			var b bytes.Buffer
+			writer := bufio.NewWriter(&b)
+
+			err := cmd.DisplayBatch(client, cmd.BatchOptions{Args: tc.args}, writer)
+			writer.Flush()
+
+			if b.String() != tc.expected {
+				t.Fatalf("expected '%s'; got '%s'", tc.expected, b.String())
+			}
+
+			if tc.err == "" && err != nil {
+				t.Fatalf("expected no error; got %v", err)
+			}
+
+			if tc.err != "" && (err == nil || err.Error() != tc.err) {
+				t.Fatalf("expected err to be %s; got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestStartBatch(t *testing.T) {
+	tt := []struct {
+		name     string
+		opts     cmd.BatchOptions
+		startErr error
+		expected string
+		err      string
+	}{
+		{
+			name:     "can start a batch build",
+			opts:     cmd.BatchOptions{Project: "project-one", SourceVersion: "pr/123", Env: []string{"STAGE=test"}},
+			expected: "🏗 project-one:batch-2\n",
+		},
+		{
+			name:     "returns the error from starting the batch build",
+			opts:     cmd.BatchOptions{Project: "project-one"},
+			startErr: errors.New("there was an error"),
+			err:      "there was an error",
+		},
+		{
+			name: "rejects invalid environment variables",
+			opts: cmd.BatchOptions{Project: "project-one", Env: []string{"STAGE"}},
+			err:  `invalid environment variable "STAGE", expected KEY=VALUE`,
+		},
+		{
+			name: "needs a project",
+			opts: cmd.BatchOptions{},
+			err:  "please specify a project name",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			client := client.NewMockAPI(ctrl)
+
+			client.
+				EXPECT().
+				StartBuildBatch(gomock.Any()).
+				DoAndReturn(func(input *codebuild.StartBuildBatchInput) (*codebuild.StartBuildBatchOutput, error) {
+					if aws.StringValue(input.ProjectName) != tc.opts.Project || aws.StringValue(input.SourceVersion) != tc.opts.SourceVersion {
+						t.Fatalf("expected to start %s at %s; got %s at %s", tc.opts.Project, tc.opts.SourceVersion, aws.StringValue(input.ProjectName), aws.StringValue(input.SourceVersion))
+					}
+					if len(input.EnvironmentVariablesOverride) != len(tc.opts.Env) {
+						t.Fatalf("expected %d environment variables; got %d", len(tc.opts.Env), len(input.EnvironmentVariablesOverride))
+					}
+					return &codebuild.StartBuildBatchOutput{BuildBatch: &codebuild.BuildBatch{
+						Id:               aws.String("project-one:batch-2"),
+						BuildBatchStatus: aws.String("IN_PROGRESS"),
+					}}, tc.startErr
+				}).
+				AnyTimes()
+
+			var b bytes.Buffer
+			writer := bufio.NewWriter(&b)
+
+			err := cmd.StartBatch(client, tc.opts, writer)
+			writer.Flush()
+
+			if b.String() != tc.expected {
+				t.Fatalf("expected '%s'; got '%s'", tc.expected, b.String())
+			}
+
+			if tc.err == "" && err != nil {
+				t.Fatalf("expected no error; got %v", err)
+			}
+
+			if tc.err != "" && (err == nil || err.Error() != tc.err) {
+				t.Fatalf("expected err to be %s; got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestStopBatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := client.NewMockAPI(ctrl)
+
+	client.
+		EXPECT().
+		ListBuildBatchesForProject(gomock.Any()).
+		Return(&codebuild.ListBuildBatchesForProjectOutput{Ids: aws.StringSlice([]string{"project-one:batch-1"})}, nil)
+
+	client.
+		EXPECT().
+		StopBuildBatch(&codebuild.StopBuildBatchInput{Id: aws.String("project-one:batch-1")}).
+		Return(&codebuild.StopBuildBatchOutput{BuildBatch: &codebuild.BuildBatch{Id: aws.String("project-one:batch-1")}}, nil)
+
+	var b bytes.Buffer
+	err := cmd.StopBatch(client, cmd.BatchOptions{Args: []string{"project-one:latest"}}, &b)
+
+	if err != nil {
+		t.Fatalf("expected no error; got %v", err)
+	}
+
+	expected := "🕳 project-one:batch-1\n"
+	if b.String() != expected {
+		t.Fatalf("expected '%s'; got '%s'", expected, b.String())
+	}
+}
+
+func TestRetryBatch(t *testing.T) {
+	tt := []struct {
+		name      string
+		opts      cmd.BatchOptions
+		retryType string
+	}{
+		{name: "can retry every build in the batch", opts: cmd.BatchOptions{Args: []string{"project-one:batch-1"}}, retryType: "RETRY_ALL_BUILDS"},
+		{name: "can retry only the failed builds", opts: cmd.BatchOptions{Args: []string{"project-one:batch-1"}, FailedOnly: true}, retryType: "RETRY_FAILED_BUILDS"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			client := client.NewMockAPI(ctrl)
+
+			client.
+				EXPECT().
+				RetryBuildBatch(&codebuild.RetryBuildBatchInput{Id: aws.String("project-one:batch-1"), RetryType: aws.String(tc.retryType)}).
+				Return(&codebuild.RetryBuildBatchOutput{BuildBatch: &codebuild.BuildBatch{
+					Id:               aws.String("project-one:batch-1"),
+					BuildBatchStatus: aws.String("IN_PROGRESS"),
+				}}, nil)
+
+			var b bytes.Buffer
+			err := cmd.RetryBatch(client, tc.opts, &b)
+
+			if err != nil {
+				t.Fatalf("expected no error; got %v", err)
+			}
+
+			expected := "🏗 project-one:batch-1\n"
+			if b.String() != expected {
+				t.Fatalf("expected '%s'; got '%s'", expected, b.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/codebuild"
+	"github.com/benmatselby/knope/client"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// ListBatchesOptions defines what arguments/options the user can provide
+type ListBatchesOptions struct {
+	Args     []string
+	Project  string
+	Limit    int
+	Output   string
+	Template string
+	JSONPath string
+}
+
+// NewListBatchesCommand creates a new `batches` command
+func NewListBatchesCommand(client client.API) *cobra.Command {
+	var opts ListBatchesOptions
+
+	cmd := &cobra.Command{
+		Use:   "batches",
+		Short: "List the batch builds for a given project",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Args = args
+			opts.Output = viper.GetString("output")
+			opts.Template = viper.GetString("template")
+			opts.JSONPath = viper.GetString("jsonpath")
+			return DisplayBatches(client, opts, os.Stdout)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&opts.Project, "project", "", "Name of the project to list batch builds for")
+	flags.IntVar(&opts.Limit, "limit", 0, "Maximum number of batch builds to list (0 means no limit)")
+	return cmd
+}
+
+// DisplayBatches will render the batch builds for a project, newest first
+func DisplayBatches(api client.API, opts ListBatchesOptions, w io.Writer) error {
+	if opts.Project == "" {
+		return fmt.Errorf("please specify a project name")
+	}
+
+	ids, err := client.NewBuildBatchIDIterator(api, &codebuild.ListBuildBatchesForProjectInput{
+		ProjectName: aws.String(opts.Project),
+		SortOrder:   aws.String(codebuild.SortOrderTypeDescending),
+	}, opts.Limit).All()
+	if err != nil {
+		return err
+	}
+
+	batches, err := client.GetBuildBatches(api, ids)
+	if err != nil {
+		return err
+	}
+
+	records := []BatchRecord{}
+	for _, batch := range batches {
+		records = append(records, newBatchRecord(batch))
+	}
+
+	return render(w, renderOptions{format: opts.Output, template: opts.Template, jsonpath: opts.JSONPath}, records, batchesTable)
+}
+
+// resolveBatchID turns a batch ID, or the project:latest shorthand, into a
+// batch ID that can be given to the API.
+func resolveBatchID(api client.API, id string) (*string, error) {
+	project, latest := latestProject(id)
+	if !latest {
+		return aws.String(id), nil
+	}
+
+	ids, err := client.NewBuildBatchIDIterator(api, &codebuild.ListBuildBatchesForProjectInput{
+		ProjectName: aws.String(project),
+		SortOrder:   aws.String(codebuild.SortOrderTypeDescending),
+	}, 1).All()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("there are no batch builds for project %s", project)
+	}
+
+	return ids[0], nil
+}
+
+// getBatch gets a single batch build
+func getBatch(api client.API, id *string) (*codebuild.BuildBatch, error) {
+	batches, err := client.GetBuildBatches(api, []*string{id})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(batches) == 0 {
+		return nil, fmt.Errorf("unable to find batch build %s", aws.StringValue(id))
+	}
+
+	return batches[0], nil
+}
+
+// BatchRecord gives us a struct to store batch builds
+type BatchRecord struct {
+	Project         string             `json:"project" yaml:"project"`
+	ID              string             `json:"id" yaml:"id"`
+	Number          int64              `json:"number,omitempty" yaml:"number,omitempty"`
+	Status          string             `json:"status" yaml:"status"`
+	CurrentPhase    string             `json:"current_phase,omitempty" yaml:"current_phase,omitempty"`
+	SourceVersion   string             `json:"source_version,omitempty" yaml:"source_version,omitempty"`
+	Commit          string             `json:"commit,omitempty" yaml:"commit,omitempty"`
+	Initiator       string             `json:"initiator,omitempty" yaml:"initiator,omitempty"`
+	Start           *time.Time         `json:"start,omitempty" yaml:"start,omitempty"`
+	Finish          *time.Time         `json:"finish,omitempty" yaml:"finish,omitempty"`
+	DurationSeconds int64              `json:"duration_seconds" yaml:"duration_seconds"`
+	Builds          []BatchBuildRecord `json:"builds,omitempty" yaml:"builds,omitempty"`
+}
+
+// newBatchRecord flattens a batch build into a record
+func newBatchRecord(batch *codebuild.BuildBatch) BatchRecord {
+	record := BatchRecord{
+		Project:         aws.StringValue(batch.ProjectName),
+		ID:              aws.StringValue(batch.Id),
+		Number:          aws.Int64Value(batch.BuildBatchNumber),
+		Status:          aws.StringValue(batch.BuildBatchStatus),
+		CurrentPhase:    aws.StringValue(batch.CurrentPhase),
+		SourceVersion:   aws.StringValue(batch.SourceVersion),
+		Commit:          aws.StringValue(batch.ResolvedSourceVersion),
+		Initiator:       aws.StringValue(batch.Initiator),
+		Start:           batch.StartTime,
+		Finish:          batch.EndTime,
+		DurationSeconds: durationSeconds(batch.StartTime, batch.EndTime),
+	}
+
+	for _, group := range batch.BuildGroups {
+		build := BatchBuildRecord{
+			Identifier:    aws.StringValue(group.Identifier),
+			DependsOn:     aws.StringValueSlice(group.DependsOn),
+			IgnoreFailure: aws.BoolValue(group.IgnoreFailure),
+			Attempts:      len(group.PriorBuildSummaryList),
+		}
+
+		if summary := group.CurrentBuildSummary; summary != nil {
+			build.ID = arnResource(aws.StringValue(summary.Arn))
+			build.Status = aws.StringValue(summary.BuildStatus)
+			build.Attempts++
+		}
+
+		record.Builds = append(record.Builds, build)
+	}
+
+	return record
+}
+
+// Icon is the status of the batch as an icon
+func (r BatchRecord) Icon() string {
+	return getBuildIcon(&r.Status)
+}
+
+// Succeeded counts the builds in the batch that succeeded, out of them all
+func (r BatchRecord) Succeeded() string {
+	succeeded := 0
+	for _, build := range r.Builds {
+		if build.Status == codebuild.StatusTypeSucceeded {
+			succeeded++
+		}
+	}
+
+	return fmt.Sprintf("%d/%d", succeeded, len(r.Builds))
+}
+
+// batchField adapts a BatchRecord function for use as a column value
+func batchField(f func(r BatchRecord) string) func(record interface{}) string {
+	return func(record interface{}) string {
+		return f(record.(BatchRecord))
+	}
+}
+
+var batchesTable = table{columns: []column{
+	{
+		name:   "status",
+		header: "Status",
+		icon:   true,
+		value:  batchField(func(r BatchRecord) string { return r.Icon() }),
+		raw:    batchField(func(r BatchRecord) string { return r.Status }),
+	},
+	{name: "id", header: "Batch", value: batchField(func(r BatchRecord) string { return r.ID })},
+	{name: "source_version", header: "Source version", value: batchField(func(r BatchRecord) string { return r.SourceVersion })},
+	{
+		name:   "start",
+		header: "Started",
+		value:  batchField(func(r BatchRecord) string { return formatTime(r.Start) }),
+		raw:    batchField(func(r BatchRecord) string { return rawTime(r.Start) }),
+	},
+	{
+		name:   "finish",
+		header: "Finished",
+		value:  batchField(func(r BatchRecord) string { return formatTime(r.Finish) }),
+		raw:    batchField(func(r BatchRecord) string { return rawTime(r.Finish) }),
+	},
+	{name: "succeeded", header: "Succeeded", value: batchField(func(r BatchRecord) string { return r.Succeeded() })},
+	{name: "commit", header: "Commit", wide: true, value: batchField(func(r BatchRecord) string { return r.Commit })},
+	{name: "initiator", header: "Initiator", wide: true, value: batchField(func(r BatchRecord) string { return r.Initiator })},
+	{
+		name:   "duration_seconds",
+		header: "Duration",
+		wide:   true,
+		value: batchField(func(r BatchRecord) string {
+			if r.Start == nil {
+				return ""
+			}
+			return (time.Duration(r.DurationSeconds) * time.Second).String()
+		}),
+		raw: batchField(func(r BatchRecord) string { return strconv.FormatInt(r.DurationSeconds, 10) }),
+	},
+}}
@@ -0,0 +1,143 @@
+package cmd_test
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/codebuild"
+	"github.com/benmatselby/knope/client"
+	"github.com/benmatselby/knope/cmd"
+	"github.com/golang/mock/gomock"
+)
+
+// testBatch is a batch build of project-one with a small build graph
+func testBatch() *codebuild.BuildBatch {
+	start := time.Date(2019, time.July, 19, 23, 0, 0, 0, time.UTC)
+	finish := time.Date(2019, time.July, 19, 23, 20, 0, 0, time.UTC)
+	summary := func(build, status string) *codebuild.BuildSummary {
+		return &codebuild.BuildSummary{
+			Arn:         aws.String("arn:aws:codebuild:eu-west-2:123456789012:build/" + build),
+			BuildStatus: aws.String(status),
+		}
+	}
+
+	return &codebuild.BuildBatch{
+		Id:                    aws.String("project-one:batch-1"),
+		ProjectName:           aws.String("project-one"),
+		BuildBatchStatus:      aws.String("FAILED"),
+		BuildBatchNumber:      aws.Int64(7),
+		Initiator:             aws.String("GitHub-Hookshot/abc"),
+		SourceVersion:         aws.String("main"),
+		ResolvedSourceVersion: aws.String("f00ba4"),
+		StartTime:             &start,
+		EndTime:               &finish,
+		BuildGroups: []*codebuild.BuildGroup{
+			{Identifier: aws.String("DOWNLOAD_SOURCE"), CurrentBuildSummary: summary("project-one:1", "SUCCEEDED")},
+			{Identifier: aws.String("build_linux"), DependsOn: aws.StringSlice([]string{"DOWNLOAD_SOURCE"}), CurrentBuildSummary: summary("project-one:2", "SUCCEEDED")},
+			{
+				Identifier:            aws.String("build_windows"),
+				DependsOn:             aws.StringSlice([]string{"DOWNLOAD_SOURCE"}),
+				IgnoreFailure:         aws.Bool(true),
+				CurrentBuildSummary:   summary("project-one:4", "FAILED"),
+				PriorBuildSummaryList: []*codebuild.BuildSummary{summary("project-one:3", "FAILED")},
+			},
+			{Identifier: aws.String("publish"), DependsOn: aws.StringSlice([]string{"build_linux", "build_windows"})},
+		},
+	}
+}
+
+func TestNewListBatchesCommand(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := client.NewMockAPI(ctrl)
+
+	cmd := cmd.NewListBatchesCommand(client)
+
+	use := "batches"
+	short := "List the batch builds for a given project"
+
+	if cmd.Use != use {
+		t.Fatalf("expected use: %s; got %s", use, cmd.Use)
+	}
+
+	if cmd.Short != short {
+		t.Fatalf("expected use: %s; got %s", short, cmd.Short)
+	}
+}
+
+func TestDisplayBatches(t *testing.T) {
+	tt := []struct {
+		name     string
+		opts     cmd.ListBatchesOptions
+		listErr  error
+		expected string
+		err      string
+	}{
+		{
+			name: "can list the batch builds for a project",
+			opts: cmd.ListBatchesOptions{Project: "project-one"},
+			expected: `Status  Batch               Source version Started          Finished         Succeeded
+❌       project-one:batch-1 main           19-07-2019 23:00 19-07-2019 23:20 2/4
+`,
+		},
+		{
+			name: "can render each batch build with a template",
+			opts: cmd.ListBatchesOptions{Project: "project-one", Template: "{{.ID}} {{len .Builds}}"},
+			expected: `project-one:batch-1 4
+`,
+		},
+		{
+			name:    "returns the error from listing batch builds",
+			opts:    cmd.ListBatchesOptions{Project: "project-one"},
+			listErr: errors.New("there was an error"),
+			err:     "there was an error",
+		},
+		{
+			name: "needs a project",
+			opts: cmd.ListBatchesOptions{},
+			err:  "please specify a project name",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			client := client.NewMockAPI(ctrl)
+
+			client.
+				EXPECT().
+				ListBuildBatchesForProject(gomock.Any()).
+				Return(&codebuild.ListBuildBatchesForProjectOutput{Ids: aws.StringSlice([]string{"project-one:batch-1"})}, tc.listErr).
+				AnyTimes()
+
+			client.
+				EXPECT().
+				BatchGetBuildBatches(gomock.Any()).
+				Return(&codebuild.BatchGetBuildBatchesOutput{BuildBatches: []*codebuild.BuildBatch{testBatch()}}, nil).
+				AnyTimes()
+
+			var b bytes.Buffer
+			writer := bufio.NewWriter(&b)
+
+			err := cmd.DisplayBatches(client, tc.opts, writer)
+			writer.Flush()
+
+			if b.String() != tc.expected {
+				t.Fatalf("expected '%s'; got '%s'", tc.expected, b.String())
+			}
+
+			if tc.err == "" && err != nil {
+				t.Fatalf("expected no error; got %v", err)
+			}
+
+			if tc.err != "" && (err == nil || err.Error() != tc.err) {
+				t.Fatalf("expected err to be %s; got %v", tc.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/codebuild"
+	"github.com/benmatselby/knope/client"
+	"github.com/benmatselby/knope/ui"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// BuildOptions defines what arguments/options the user can provide
+type BuildOptions struct {
+	Args     []string
+	Output   string
+	Template string
+	JSONPath string
+}
+
+// NewBuildCommand creates a new `build` command
+func NewBuildCommand(client client.API) *cobra.Command {
+	var opts BuildOptions
+
+	cmd := &cobra.Command{
+		Use:   "build [build-id|project:latest]",
+		Short: "Show the details of a build",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Args = args
+			opts.Output = viper.GetString("output")
+			opts.Template = viper.GetString("template")
+			opts.JSONPath = viper.GetString("jsonpath")
+			return DisplayBuild(client, opts, os.Stdout)
+		},
+	}
+
+	return cmd
+}
+
+// DisplayBuild will render everything we know about a single build. Other
+// output formats have the build as a record, as the builds command does.
+func DisplayBuild(api client.API, opts BuildOptions, w io.Writer) error {
+	if len(opts.Args) == 0 {
+		return fmt.Errorf("please specify a build id")
+	}
+
+	id, err := resolveBuildID(api, opts.Args[0])
+	if err != nil {
+		return err
+	}
+
+	build, err := getBuild(api, id)
+	if err != nil {
+		return err
+	}
+
+	return renderBuild(w, build, opts)
+}
+
+// renderBuild renders a build we already have, so the ui can show the
+// details and the logs of a build from one request
+func renderBuild(w io.Writer, build *codebuild.Build, opts BuildOptions) error {
+	if (opts.Output != "" && opts.Output != OutputTable && opts.Output != OutputWide) || opts.Template != "" || opts.JSONPath != "" {
+		return render(w, renderOptions{format: opts.Output, template: opts.Template, jsonpath: opts.JSONPath}, []BuildRecord{newBuildRecord(build)}, buildsTable)
+	}
+
+	tr := tabwriter.NewWriter(w, 0, 0, 1, ' ', tabwriter.FilterHTML)
+	fmt.Fprintf(tr, "Build:\t%s\n", aws.StringValue(build.Id))
+	fmt.Fprintf(tr, "Status:\t%s %s\n", getBuildIcon(build.BuildStatus), aws.StringValue(build.BuildStatus))
+	fmt.Fprintf(tr, "Project:\t%s\n", aws.StringValue(build.ProjectName))
+	fmt.Fprintf(tr, "Initiator:\t%s\n", aws.StringValue(build.Initiator))
+
+	if build.Source != nil {
+		fmt.Fprintf(tr, "Source:\t%s %s\n", aws.StringValue(build.Source.Type), aws.StringValue(build.Source.Location))
+	}
+	fmt.Fprintf(tr, "Source version:\t%s\n", aws.StringValue(build.SourceVersion))
+	fmt.Fprintf(tr, "Commit:\t%s\n", aws.StringValue(build.ResolvedSourceVersion))
+
+	if build.Environment != nil {
+		fmt.Fprintf(tr, "Image:\t%s\n", aws.StringValue(build.Environment.Image))
+		fmt.Fprintf(tr, "Compute type:\t%s\n", aws.StringValue(build.Environment.ComputeType))
+	}
+
+	fmt.Fprintf(tr, "Started:\t%s\n", formatTime(build.StartTime))
+	fmt.Fprintf(tr, "Finished:\t%s\n", formatTime(build.EndTime))
+	fmt.Fprintf(tr, "Duration:\t%s\n", formatDuration(build.StartTime, build.EndTime))
+	tr.Flush()
+
+	fmt.Fprintf(w, "\nPhases\n")
+	tr = tabwriter.NewWriter(w, 0, 0, 1, ' ', tabwriter.FilterHTML)
+	for _, phase := range build.Phases {
+		icon := ""
+		if phase.PhaseStatus != nil {
+			icon = getBuildIcon(phase.PhaseStatus)
+		}
+
+		duration := ""
+		if phase.DurationInSeconds != nil {
+			duration = (time.Duration(aws.Int64Value(phase.DurationInSeconds)) * time.Second).String()
+		}
+
+		var contexts []string
+		if aws.StringValue(phase.PhaseStatus) != codebuild.StatusTypeSucceeded {
+			for _, context := range phase.Contexts {
+				if aws.StringValue(context.Message) != "" {
+					contexts = append(contexts, fmt.Sprintf("%s: %s", aws.StringValue(context.StatusCode), aws.StringValue(context.Message)))
+				}
+			}
+		}
+
+		fmt.Fprintf(tr, "%s \t%s\t%s\t%s\t%s\n", icon, aws.StringValue(phase.PhaseType), aws.StringValue(phase.PhaseStatus), duration, strings.Join(contexts, "; "))
+	}
+	tr.Flush()
+
+	var artifacts []string
+	if build.Artifacts != nil && aws.StringValue(build.Artifacts.Location) != "" {
+		artifacts = append(artifacts, aws.StringValue(build.Artifacts.Location))
+	}
+	for _, artifact := range build.SecondaryArtifacts {
+		if aws.StringValue(artifact.Location) != "" {
+			artifacts = append(artifacts, aws.StringValue(artifact.Location))
+		}
+	}
+
+	if len(artifacts) > 0 {
+		fmt.Fprintf(w, "\nArtifacts\n")
+		for _, location := range artifacts {
+			fmt.Fprintf(w, "%s\n", location)
+		}
+	}
+
+	if build.VpcConfig != nil && aws.StringValue(build.VpcConfig.VpcId) != "" {
+		fmt.Fprintf(w, "\nVPC\n")
+		tr = tabwriter.NewWriter(w, 0, 0, 1, ' ', tabwriter.FilterHTML)
+		fmt.Fprintf(tr, "VPC:\t%s\n", aws.StringValue(build.VpcConfig.VpcId))
+		fmt.Fprintf(tr, "Subnets:\t%s\n", strings.Join(aws.StringValueSlice(build.VpcConfig.Subnets), ", "))
+		fmt.Fprintf(tr, "Security groups:\t%s\n", strings.Join(aws.StringValueSlice(build.VpcConfig.SecurityGroupIds), ", "))
+		tr.Flush()
+	}
+
+	if len(build.ExportedEnvironmentVariables) > 0 {
+		fmt.Fprintf(w, "\nExported environment variables\n")
+		for _, variable := range build.ExportedEnvironmentVariables {
+			fmt.Fprintf(w, "%s=%s\n", aws.StringValue(variable.Name), aws.StringValue(variable.Value))
+		}
+	}
+
+	return nil
+}
+
+// formatTime renders an optional time in the app format
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.Format(ui.AppDateTimeFormat)
+}
+
+// formatDuration renders the time between start and end, or until now if
+// there is no end yet
+func formatDuration(start, end *time.Time) string {
+	if start == nil {
+		return ""
+	}
+
+	finish := time.Now()
+	if end != nil {
+		finish = *end
+	}
+
+	return finish.Sub(*start).Round(time.Second).String()
+}
@@ -0,0 +1,181 @@
+package cmd_test
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/codebuild"
+	"github.com/benmatselby/knope/client"
+	"github.com/benmatselby/knope/cmd"
+	"github.com/golang/mock/gomock"
+)
+
+func TestNewBuildCommand(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := client.NewMockAPI(ctrl)
+
+	cmd := cmd.NewBuildCommand(client)
+
+	use := "build [build-id|project:latest]"
+	short := "Show the details of a build"
+
+	if cmd.Use != use {
+		t.Fatalf("expected use: %s; got %s", use, cmd.Use)
+	}
+
+	if cmd.Short != short {
+		t.Fatalf("expected use: %s; got %s", short, cmd.Short)
+	}
+}
+
+func TestDisplayBuild(t *testing.T) {
+	start := time.Date(2019, time.July, 19, 23, 0, 0, 0, time.UTC)
+	finish := time.Date(2019, time.July, 19, 23, 10, 0, 0, time.UTC)
+
+	failed := &codebuild.Build{
+		Id:                    aws.String("project-one:1"),
+		BuildStatus:           aws.String("FAILED"),
+		ProjectName:           aws.String("project-one"),
+		Initiator:             aws.String("GitHub-Hookshot/abc"),
+		Source:                &codebuild.ProjectSource{Type: aws.String("GITHUB"), Location: aws.String("https://github.com/benmatselby/knope.git")},
+		SourceVersion:         aws.String("pr/123"),
+		ResolvedSourceVersion: aws.String("f00ba4"),
+		Environment:           &codebuild.ProjectEnvironment{Image: aws.String("aws/codebuild/standard:4.0"), ComputeType: aws.String("BUILD_GENERAL1_SMALL")},
+		StartTime:             &start,
+		EndTime:               &finish,
+		Phases: []*codebuild.BuildPhase{
+			{PhaseType: aws.String("SUBMITTED"), PhaseStatus: aws.String("SUCCEEDED"), DurationInSeconds: aws.Int64(0)},
+			{PhaseType: aws.String("BUILD"), PhaseStatus: aws.String("FAILED"), DurationInSeconds: aws.Int64(62), Contexts: []*codebuild.PhaseContext{
+				{StatusCode: aws.String("COMMAND_EXECUTION_ERROR"), Message: aws.String("Error while executing command: make test. Reason: exit status 2")},
+			}},
+			{PhaseType: aws.String("COMPLETED")},
+		},
+		Artifacts:          &codebuild.BuildArtifacts{Location: aws.String("arn:aws:s3:::artifacts/project-one")},
+		SecondaryArtifacts: []*codebuild.BuildArtifacts{{Location: aws.String("arn:aws:s3:::artifacts/reports")}},
+		VpcConfig: &codebuild.VpcConfig{
+			VpcId:            aws.String("vpc-1"),
+			Subnets:          aws.StringSlice([]string{"subnet-1", "subnet-2"}),
+			SecurityGroupIds: aws.StringSlice([]string{"sg-1"}),
+		},
+		ExportedEnvironmentVariables: []*codebuild.ExportedEnvironmentVariable{
+			{Name: aws.String("VERSION"), Value: aws.String("1.2.3")},
+		},
+	}
+
+	tt := []struct {
+		name     string
+		build    *codebuild.Build
+		opts     cmd.BuildOptions
+		expected string
+		err      error
+	}{
+		{
+			name:  "can render the details of a failed build",
+			build: failed,
+			expected: `Build:          project-one:1
+Status:         ❌ FAILED
+Project:        project-one
+Initiator:      GitHub-Hookshot/abc
+Source:         GITHUB https://github.com/benmatselby/knope.git
+Source version: pr/123
+Commit:         f00ba4
+Image:          aws/codebuild/standard:4.0
+Compute type:   BUILD_GENERAL1_SMALL
+Started:        19-07-2019 23:00
+Finished:       19-07-2019 23:10
+Duration:       10m0s
+
+Phases
+✅  SUBMITTED SUCCEEDED 0s   
+❌  BUILD     FAILED    1m2s COMMAND_EXECUTION_ERROR: Error while executing command: make test. Reason: exit status 2
+   COMPLETED                
+
+Artifacts
+arn:aws:s3:::artifacts/project-one
+arn:aws:s3:::artifacts/reports
+
+VPC
+VPC:             vpc-1
+Subnets:         subnet-1, subnet-2
+Security groups: sg-1
+
+Exported environment variables
+VERSION=1.2.3
+`,
+		},
+		{
+			name:  "can render a build with very little information",
+			build: &codebuild.Build{Id: aws.String("project-one:1"), BuildStatus: aws.String("SUCCEEDED"), StartTime: &start, EndTime: &finish},
+			expected: `Build:          project-one:1
+Status:         ✅ SUCCEEDED
+Project:        
+Initiator:      
+Source version: 
+Commit:         
+Started:        19-07-2019 23:00
+Finished:       19-07-2019 23:10
+Duration:       10m0s
+
+Phases
+`,
+		},
+		{
+			name:     "can render the build with a template",
+			build:    failed,
+			opts:     cmd.BuildOptions{Template: "{{.ID}} {{.Status}} {{.FailedPhase.Type}}"},
+			expected: "project-one:1 FAILED BUILD\n",
+		},
+		{
+			name:  "can render the build as csv",
+			build: failed,
+			opts:  cmd.BuildOptions{Output: "csv"},
+			expected: `status,source_version,commit,start,finish,duration_seconds,initiator,id
+FAILED,pr/123,f00ba4,2019-07-19T23:00:00Z,2019-07-19T23:10:00Z,600,GitHub-Hookshot/abc,project-one:1
+`,
+		},
+		{
+			name: "returns the error from getting the build",
+			err:  errors.New("there was an error"),
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			client := client.NewMockAPI(ctrl)
+
+			var builds []*codebuild.Build
+			if tc.build != nil {
+				builds = append(builds, tc.build)
+			}
+
+			client.
+				EXPECT().
+				BatchGetBuilds(&codebuild.BatchGetBuildsInput{Ids: []*string{aws.String("project-one:1")}}).
+				Return(&codebuild.BatchGetBuildsOutput{Builds: builds}, tc.err)
+
+			var b bytes.Buffer
+			writer := bufio.NewWriter(&b)
+
+			opts := tc.opts
+			opts.Args = []string{"project-one:1"}
+
+			err := cmd.DisplayBuild(client, opts, writer)
+			writer.Flush()
+
+			if b.String() != tc.expected {
+				t.Fatalf("expected '%s'; got '%s'", tc.expected, b.String())
+			}
+
+			if err != tc.err {
+				t.Fatalf("expected err to be %v; got %v", tc.err, err)
+			}
+		})
+	}
+}
@@ -4,19 +4,31 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"text/tabwriter"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/codebuild"
 	"github.com/benmatselby/knope/client"
-	"github.com/benmatselby/knope/ui"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 // ListBuildForProjectOptions defines what arguments/options the user can provide
 type ListBuildForProjectOptions struct {
-	Args    []string
-	Project string
+	Args      []string
+	Project   string
+	Limit     int
+	Statuses  []string
+	Branch    string
+	Initiator string
+	Commit    string
+	Since     string
+	Until     string
+	Columns   []string
+	Output    string
+	Template  string
+	JSONPath  string
 }
 
 // NewListBuildsForProjectCommand creates a new `builds` command
@@ -28,58 +40,151 @@ func NewListBuildsForProjectCommand(client client.API) *cobra.Command {
 		Short: "List all the builds for a given project",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			opts.Args = args
+			opts.Output = viper.GetString("output")
+			opts.Template = viper.GetString("template")
+			opts.JSONPath = viper.GetString("jsonpath")
 			return DisplayBuildsForProject(client, opts, os.Stdout)
 		},
 	}
 
 	flags := cmd.Flags()
 	flags.StringVar(&opts.Project, "project", "", "Name of the project to list builds for")
+	flags.IntVar(&opts.Limit, "limit", 0, "Maximum number of builds to list (0 means no limit)")
+	flags.StringSliceVar(&opts.Statuses, "status", nil, "Only list builds with these statuses, e.g. FAILED,FAULT")
+	flags.StringVar(&opts.Branch, "branch", "", "Only list builds of the branch")
+	flags.StringVar(&opts.Initiator, "initiator", "", "Only list builds whose initiator contains this")
+	flags.StringVar(&opts.Commit, "commit", "", "Only list builds of the commit, which can be shortened")
+	flags.StringVar(&opts.Since, "since", "", "Only list builds started since, e.g. 7d, 12h or 2020-10-01")
+	flags.StringVar(&opts.Until, "until", "", "Only list builds started before, e.g. 1d, 12h or 2020-10-01, which includes that day")
+	flags.StringSliceVar(&opts.Columns, "columns", nil, "Columns to show, in order, from status, source_version, commit, start, finish, duration_seconds, initiator and id")
 	return cmd
 }
 
 // DisplayBuildsForProject will render the projects you have access to
-func DisplayBuildsForProject(client client.API, opts ListBuildForProjectOptions, w io.Writer) error {
+func DisplayBuildsForProject(api client.API, opts ListBuildForProjectOptions, w io.Writer) error {
 	if opts.Project == "" {
 		return fmt.Errorf("please specify a project name")
 	}
 
-	projectBuilds, err := client.ListBuildsForProject(&codebuild.ListBuildsForProjectInput{
-		ProjectName: &opts.Project,
-	})
+	now := time.Now()
+	since, err := parseSince(opts.Since, now)
 	if err != nil {
 		return err
 	}
 
-	builds, err := client.BatchGetBuilds(&codebuild.BatchGetBuildsInput{Ids: projectBuilds.Ids})
+	until, err := parseUntil(opts.Until, now)
 	if err != nil {
 		return err
 	}
 
-	tr := tabwriter.NewWriter(w, 0, 0, 1, ' ', tabwriter.FilterHTML)
-	fmt.Fprintf(tr, "%s \t%s\t%s\t%s\n", "Status", "Name", "Branch", "Finished")
-	for _, build := range builds.Builds {
-		start := build.StartTime.Format(ui.AppDateTimeFormat)
+	filtered := len(opts.Statuses) > 0 || opts.Branch != "" || opts.Initiator != "" || opts.Commit != "" || !until.IsZero()
+
+	// When filtering, the limit is on the builds that match, not those we look at
+	limit := opts.Limit
+	if filtered {
+		limit = 0
+	}
+
+	builds := []BuildRecord{}
+	iter := client.NewBuildIterator(api, &codebuild.ListBuildsForProjectInput{
+		ProjectName: &opts.Project,
+		SortOrder:   aws.String(codebuild.SortOrderTypeDescending),
+	}, limit)
+	for iter.Next() {
+		build := iter.Build()
 
-		finish := ""
+		// Builds are newest first, so there are no more once we are past --since
+		if build.StartTime != nil && build.StartTime.Before(since) {
+			break
+		}
 
-		if build.EndTime != nil {
-			finish = build.EndTime.Format(ui.AppDateTimeFormat)
+		if !matchesBuild(build, opts, until) {
+			continue
 		}
 
-		result := ""
-		if aws.StringValue(build.BuildStatus) == "FAILED" || aws.StringValue(build.BuildStatus) == "FAULT" {
-			result = ui.AppFailure
-		} else if aws.StringValue(build.BuildStatus) == "IN_PROGRESS" {
-			result = ui.AppProgress
-		} else if aws.StringValue(build.BuildStatus) == "STOPPED" || aws.StringValue(build.BuildStatus) == "TIMED_OUT" {
-			result = ui.AppStale
-		} else {
-			result = ui.AppSuccess
+		builds = append(builds, newBuildRecord(build))
+		if opts.Limit > 0 && len(builds) == opts.Limit {
+			break
 		}
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+
+	return render(w, renderOptions{format: opts.Output, template: opts.Template, jsonpath: opts.JSONPath, columns: opts.Columns}, builds, buildsTable)
+}
+
+// matchesBuild is whether the build is one of those asked for
+func matchesBuild(build *codebuild.Build, opts ListBuildForProjectOptions, until time.Time) bool {
+	if len(opts.Statuses) > 0 {
+		found := false
+		for _, status := range opts.Statuses {
+			if strings.EqualFold(status, aws.StringValue(build.BuildStatus)) {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if opts.Branch != "" && branchName(aws.StringValue(build.SourceVersion)) != branchName(opts.Branch) {
+		return false
+	}
+
+	if opts.Initiator != "" && !strings.Contains(strings.ToLower(aws.StringValue(build.Initiator)), strings.ToLower(opts.Initiator)) {
+		return false
+	}
+
+	if opts.Commit != "" && !strings.HasPrefix(strings.ToLower(aws.StringValue(build.ResolvedSourceVersion)), strings.ToLower(opts.Commit)) {
+		return false
+	}
+
+	if !until.IsZero() && (build.StartTime == nil || !build.StartTime.Before(until)) {
+		return false
+	}
+
+	return true
+}
+
+// branchName picks the branch out of a source version, which may be a full
+// ref such as refs/heads/main, or have the commit it resolved to appended,
+// such as refs/heads/main^{f00ba4}
+func branchName(sourceVersion string) string {
+	if i := strings.Index(sourceVersion, "^{"); i >= 0 {
+		sourceVersion = sourceVersion[:i]
+	}
+
+	return strings.TrimPrefix(sourceVersion, "refs/heads/")
+}
+
+// resolveBuildID turns a build ID, or the project:latest shorthand, into a
+// build ID that can be given to the API.
+func resolveBuildID(api client.API, id string) (*string, error) {
+	project, latest := latestProject(id)
+	if !latest {
+		return aws.String(id), nil
+	}
+
+	ids, err := client.NewBuildIDIterator(api, &codebuild.ListBuildsForProjectInput{
+		ProjectName: aws.String(project),
+	}, 1).All()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("there are no builds for project %s", project)
+	}
+
+	return ids[0], nil
+}
 
-		fmt.Fprintf(tr, "%s \t%s\t%s\t%s\n", result, aws.StringValue(build.ResolvedSourceVersion), start, finish)
+// latestProject picks the project out of the project:latest shorthand
+func latestProject(id string) (string, bool) {
+	if !strings.HasSuffix(id, ":latest") {
+		return "", false
 	}
-	tr.Flush()
 
-	return nil
+	return strings.TrimSuffix(id, ":latest"), true
 }
@@ -4,9 +4,11 @@ import (
 	"bufio"
 	"bytes"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/codebuild"
 	"github.com/benmatselby/knope/client"
 	"github.com/benmatselby/knope/cmd"
@@ -54,8 +56,8 @@ func TestDisplayBuildsForProject(t *testing.T) {
 			Start:  time.Date(2019, time.July, 19, 23, 0, 0, 0, time.UTC),
 			Finish: time.Date(2019, time.July, 19, 23, 10, 0, 0, time.UTC),
 		}},
-			expected: `Status  Name      Branch           Finished
-✅       my-branch 19-07-2019 23:00 19-07-2019 23:10
+			expected: `Status  Branch    Commit  Started          Finished         Duration Initiator
+✅       my-branch f00ba4c 19-07-2019 23:00 19-07-2019 23:10 10m0s    ben
 `, listBuildErr: nil, getBuildErr: nil},
 		{name: "can return a failed build", project: "project-one", builds: []testBuild{testBuild{
 			Status: "FAILED",
@@ -63,8 +65,8 @@ func TestDisplayBuildsForProject(t *testing.T) {
 			Start:  time.Date(2019, time.July, 19, 23, 0, 0, 0, time.UTC),
 			Finish: time.Date(2019, time.July, 19, 23, 10, 0, 0, time.UTC),
 		}},
-			expected: `Status  Name      Branch           Finished
-❌       my-branch 19-07-2019 23:00 19-07-2019 23:10
+			expected: `Status  Branch    Commit  Started          Finished         Duration Initiator
+❌       my-branch f00ba4c 19-07-2019 23:00 19-07-2019 23:10 10m0s    ben
 `, listBuildErr: nil, getBuildErr: nil},
 		{name: "can return a fault build", project: "project-one", builds: []testBuild{testBuild{
 			Status: "FAULT",
@@ -72,8 +74,8 @@ func TestDisplayBuildsForProject(t *testing.T) {
 			Start:  time.Date(2019, time.July, 19, 23, 0, 0, 0, time.UTC),
 			Finish: time.Date(2019, time.July, 19, 23, 10, 0, 0, time.UTC),
 		}},
-			expected: `Status  Name      Branch           Finished
-❌       my-branch 19-07-2019 23:00 19-07-2019 23:10
+			expected: `Status  Branch    Commit  Started          Finished         Duration Initiator
+❌       my-branch f00ba4c 19-07-2019 23:00 19-07-2019 23:10 10m0s    ben
 `, listBuildErr: nil, getBuildErr: nil},
 		{name: "can return an in progress build", project: "project-one", builds: []testBuild{testBuild{
 			Status: "IN_PROGRESS",
@@ -81,8 +83,8 @@ func TestDisplayBuildsForProject(t *testing.T) {
 			Start:  time.Date(2019, time.July, 19, 23, 0, 0, 0, time.UTC),
 			Finish: time.Date(2019, time.July, 19, 23, 10, 0, 0, time.UTC),
 		}},
-			expected: `Status  Name      Branch           Finished
-🏗       my-branch 19-07-2019 23:00 19-07-2019 23:10
+			expected: `Status  Branch    Commit  Started          Finished         Duration Initiator
+🏗       my-branch f00ba4c 19-07-2019 23:00 19-07-2019 23:10 10m0s    ben
 `, listBuildErr: nil, getBuildErr: nil},
 		{name: "can return a stopped build build", project: "project-one", builds: []testBuild{testBuild{
 			Status: "STOPPED",
@@ -90,8 +92,8 @@ func TestDisplayBuildsForProject(t *testing.T) {
 			Start:  time.Date(2019, time.July, 19, 23, 0, 0, 0, time.UTC),
 			Finish: time.Date(2019, time.July, 19, 23, 10, 0, 0, time.UTC),
 		}},
-			expected: `Status  Name      Branch           Finished
-🕳       my-branch 19-07-2019 23:00 19-07-2019 23:10
+			expected: `Status  Branch    Commit  Started          Finished         Duration Initiator
+🕳       my-branch f00ba4c 19-07-2019 23:00 19-07-2019 23:10 10m0s    ben
 `, listBuildErr: nil, getBuildErr: nil},
 		{name: "can return a timed out build build", project: "project-one", builds: []testBuild{testBuild{
 			Status: "STOPPED",
@@ -99,8 +101,8 @@ func TestDisplayBuildsForProject(t *testing.T) {
 			Start:  time.Date(2019, time.July, 19, 23, 0, 0, 0, time.UTC),
 			Finish: time.Date(2019, time.July, 19, 23, 10, 0, 0, time.UTC),
 		}},
-			expected: `Status  Name      Branch           Finished
-🕳       my-branch 19-07-2019 23:00 19-07-2019 23:10
+			expected: `Status  Branch    Commit  Started          Finished         Duration Initiator
+🕳       my-branch f00ba4c 19-07-2019 23:00 19-07-2019 23:10 10m0s    ben
 `, listBuildErr: nil, getBuildErr: nil},
 		{
 			name:         "unable to list builds for project",
@@ -136,7 +138,9 @@ func TestDisplayBuildsForProject(t *testing.T) {
 					StartTime:             &tc.builds[index].Start,
 					EndTime:               &tc.builds[index].Finish,
 					BuildStatus:           &tc.builds[index].Status,
-					ResolvedSourceVersion: &tc.builds[index].Source,
+					SourceVersion:         &tc.builds[index].Source,
+					ResolvedSourceVersion: aws.String("f00ba4c0ffee"),
+					Initiator:             aws.String("ben"),
 				})
 			}
 			buildOutput := codebuild.BatchGetBuildsOutput{
@@ -183,3 +187,136 @@ func TestDisplayBuildsForProject(t *testing.T) {
 		})
 	}
 }
+
+func TestDisplayBuildsForProjectWithFilters(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2019, time.July, d, 9, 0, 0, 0, time.UTC) }
+
+	// The builds are newest first, followed by a long tail of old ones
+	builds := []*codebuild.Build{
+		{BuildStatus: aws.String("FAILED"), SourceVersion: aws.String("refs/heads/main"), ResolvedSourceVersion: aws.String("aaa111"), Initiator: aws.String("GitHub-Hookshot/abc")},
+		{BuildStatus: aws.String("SUCCEEDED"), SourceVersion: aws.String("main"), ResolvedSourceVersion: aws.String("bbb222"), Initiator: aws.String("ben")},
+		{BuildStatus: aws.String("FAULT"), SourceVersion: aws.String("feature"), ResolvedSourceVersion: aws.String("ccc333"), Initiator: aws.String("GitHub-Hookshot/abc")},
+		{BuildStatus: aws.String("FAILED"), SourceVersion: aws.String("main"), ResolvedSourceVersion: aws.String("ddd444"), Initiator: aws.String("codepipeline/deploy")},
+	}
+	for i := range builds {
+		start := day(20 - i)
+		builds[i].StartTime = &start
+	}
+	for i := 0; i < 146; i++ {
+		start := time.Date(2019, time.June, 1, 9, 0, 0, 0, time.UTC).Add(-time.Duration(i) * time.Hour)
+		builds = append(builds, &codebuild.Build{BuildStatus: aws.String("FAILED"), SourceVersion: aws.String("main"), StartTime: &start})
+	}
+
+	byID := map[string]*codebuild.Build{}
+	var ids []*string
+	for i, build := range builds {
+		build.Id = aws.String(fmt.Sprintf("project-one:%d", i))
+		build.EndTime = build.StartTime
+		byID[aws.StringValue(build.Id)] = build
+		ids = append(ids, build.Id)
+	}
+
+	tt := []struct {
+		name     string
+		opts     cmd.ListBuildForProjectOptions
+		expected string
+		chunks   []int
+		err      string
+	}{
+		{
+			name:     "can find failed builds on main",
+			opts:     cmd.ListBuildForProjectOptions{Statuses: []string{"FAILED", "FAULT"}, Branch: "main", Since: "2019-07-01"},
+			expected: "project-one:0\nproject-one:3\n",
+			chunks:   []int{100},
+		},
+		{
+			name:     "can find builds by who started them",
+			opts:     cmd.ListBuildForProjectOptions{Initiator: "hookshot", Since: "2019-07-01"},
+			expected: "project-one:0\nproject-one:2\n",
+			chunks:   []int{100},
+		},
+		{
+			name:     "can find builds of a commit",
+			opts:     cmd.ListBuildForProjectOptions{Commit: "BBB", Since: "2019-07-01"},
+			expected: "project-one:1\n",
+			chunks:   []int{100},
+		},
+		{
+			name:     "can find builds between two dates",
+			opts:     cmd.ListBuildForProjectOptions{Since: "2019-07-18", Until: "2019-07-18"},
+			expected: "project-one:2\n",
+			chunks:   []int{100},
+		},
+		{
+			name:     "applies the limit to the builds that match",
+			opts:     cmd.ListBuildForProjectOptions{Statuses: []string{"failed"}, Limit: 3},
+			expected: "project-one:0\nproject-one:3\nproject-one:4\n",
+			chunks:   []int{100},
+		},
+		{
+			name:     "looks at every build without --since",
+			opts:     cmd.ListBuildForProjectOptions{Branch: "feature"},
+			expected: "project-one:2\n",
+			chunks:   []int{100, 50},
+		},
+		{
+			name: "tells you when until is not understood",
+			opts: cmd.ListBuildForProjectOptions{Until: "tomorrow"},
+			err:  `invalid --until "tomorrow", expected something like 30d, 2w, 12h or 2020-10-01`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			client := client.NewMockAPI(ctrl)
+
+			client.
+				EXPECT().
+				ListBuildsForProject(gomock.Any()).
+				Return(&codebuild.ListBuildsForProjectOutput{Ids: ids}, nil).
+				AnyTimes()
+
+			var chunks []int
+			client.
+				EXPECT().
+				BatchGetBuilds(gomock.Any()).
+				DoAndReturn(func(input *codebuild.BatchGetBuildsInput) (*codebuild.BatchGetBuildsOutput, error) {
+					chunks = append(chunks, len(input.Ids))
+					out := &codebuild.BatchGetBuildsOutput{}
+					for _, id := range input.Ids {
+						out.Builds = append(out.Builds, byID[aws.StringValue(id)])
+					}
+					return out, nil
+				}).
+				AnyTimes()
+
+			var b bytes.Buffer
+			writer := bufio.NewWriter(&b)
+
+			opts := tc.opts
+			opts.Project = "project-one"
+			opts.Template = "{{.ID}}"
+
+			err := cmd.DisplayBuildsForProject(client, opts, writer)
+			writer.Flush()
+
+			if tc.err == "" && err != nil {
+				t.Fatalf("expected no error; got %v", err)
+			}
+
+			if tc.err != "" && (err == nil || err.Error() != tc.err) {
+				t.Fatalf("expected err to be %s; got %v", tc.err, err)
+			}
+
+			if b.String() != tc.expected {
+				t.Fatalf("expected '%s'; got '%s'", tc.expected, b.String())
+			}
+
+			if fmt.Sprint(chunks) != fmt.Sprint(tc.chunks) {
+				t.Fatalf("expected chunks %v; got %v", tc.chunks, chunks)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/codebuild"
+	"github.com/benmatselby/knope/client"
+	"github.com/spf13/cobra"
+)
+
+// The exit codes of the check command
+const (
+	CheckSucceeded  = 0
+	CheckFailed     = 1
+	CheckInProgress = 2
+	CheckUnknown    = 3
+)
+
+// CheckOptions defines what arguments/options the user can provide
+type CheckOptions struct {
+	Args         []string
+	Project      string
+	Branch       string
+	Wait         bool
+	PollInterval time.Duration
+}
+
+// NewCheckCommand creates a new `check` command
+func NewCheckCommand(client client.API) *cobra.Command {
+	var opts CheckOptions
+
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "Check the latest build of a project, for gating scripts and pipelines",
+		Long: "Check the latest build of a project, for gating scripts and pipelines. " +
+			"Exits 0 when the build succeeded, 1 when it failed, timed out or was stopped, " +
+			"2 when it is in progress and 3 when it is unknown, such as when there are no builds or no project was given.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Args = args
+
+			// The exit code is the answer, so only say why when we could not get one
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			return Check(client, opts, os.Stdout)
+		},
+	}
+
+	cmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return &ExitError{Code: CheckUnknown, Err: err}
+	})
+
+	// A bad config file, context or output format must not look like a
+	// failed build either, so wrap whatever the root command complains about
+	cmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		parent := cmd.Parent()
+		if parent == nil || parent.PersistentPreRunE == nil {
+			return nil
+		}
+
+		if err := parent.PersistentPreRunE(cmd, args); err != nil {
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			return &ExitError{Code: CheckUnknown, Err: err}
+		}
+
+		return nil
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&opts.Project, "project", "", "Name of the project to check")
+	flags.StringVar(&opts.Branch, "branch", "", "Only consider builds of this branch or pull request, e.g. main or pr/123")
+	flags.BoolVar(&opts.Wait, "wait", false, "Wait for the build to finish when it is in progress")
+	flags.DurationVar(&opts.PollInterval, "poll-interval", 10*time.Second, "How often to check the build status when waiting")
+
+	return cmd
+}
+
+// Check renders the latest build of a project, and returns an ExitError
+// with a code for its status unless it succeeded
+func Check(api client.API, opts CheckOptions, w io.Writer) error {
+	// A misconfigured check must not look like a failed build
+	if opts.Project == "" {
+		return &ExitError{Code: CheckUnknown, Err: errors.New("please specify a project name")}
+	}
+
+	build, err := latestBuild(api, opts.Project, opts.Branch)
+	if err != nil {
+		return &ExitError{Code: CheckUnknown, Err: err}
+	}
+
+	if build == nil {
+		message := fmt.Sprintf("there are no builds for project %s", opts.Project)
+		if opts.Branch != "" {
+			message += " on " + opts.Branch
+		}
+		return &ExitError{Code: CheckUnknown, Err: errors.New(message)}
+	}
+
+	fmt.Fprintln(w, checkLine(build))
+
+	if opts.Wait && aws.StringValue(build.BuildStatus) == codebuild.StatusTypeInProgress {
+		build, err = waitForBuild(api, build.Id, opts.PollInterval)
+		if err != nil {
+			return &ExitError{Code: CheckUnknown, Err: err}
+		}
+
+		fmt.Fprintln(w, checkLine(build))
+	}
+
+	status := aws.StringValue(build.BuildStatus)
+	switch {
+	case status == codebuild.StatusTypeSucceeded:
+		return nil
+	case status == codebuild.StatusTypeInProgress:
+		return &ExitError{Code: CheckInProgress}
+	case buildFailed(status) || status == codebuild.StatusTypeStopped:
+		return &ExitError{Code: CheckFailed}
+	}
+
+	return &ExitError{Code: CheckUnknown}
+}
+
+// latestBuild finds the most recent build of the project, on the branch if
+// one is given. There is no build when the project has never been built.
+func latestBuild(api client.API, project, branch string) (*codebuild.Build, error) {
+	limit := 1
+	if branch != "" {
+		limit = 0
+	}
+
+	iter := client.NewBuildIterator(api, &codebuild.ListBuildsForProjectInput{
+		ProjectName: aws.String(project),
+		SortOrder:   aws.String(codebuild.SortOrderTypeDescending),
+	}, limit)
+	for iter.Next() {
+		build := iter.Build()
+		if matchesBuild(build, ListBuildForProjectOptions{Branch: branch}, time.Time{}) {
+			return build, nil
+		}
+	}
+
+	return nil, iter.Err()
+}
+
+// checkLine describes the build being checked in a line
+func checkLine(build *codebuild.Build) string {
+	line := fmt.Sprintf("%s %s %s", getBuildIcon(build.BuildStatus), aws.StringValue(build.Id), aws.StringValue(build.BuildStatus))
+	if branch := branchName(aws.StringValue(build.SourceVersion)); branch != "" {
+		line += " " + branch
+	}
+	if commit := aws.StringValue(build.ResolvedSourceVersion); commit != "" {
+		line += " " + shortCommit(commit)
+	}
+
+	return line
+}
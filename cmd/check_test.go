@@ -0,0 +1,229 @@
+package cmd_test
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/codebuild"
+	"github.com/benmatselby/knope/client"
+	"github.com/benmatselby/knope/cmd"
+	"github.com/golang/mock/gomock"
+)
+
+func TestNewCheckCommand(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := client.NewMockAPI(ctrl)
+
+	cmd := cmd.NewCheckCommand(client)
+
+	use := "check"
+	short := "Check the latest build of a project, for gating scripts and pipelines"
+
+	if cmd.Use != use {
+		t.Fatalf("expected use: %s; got %s", use, cmd.Use)
+	}
+
+	if cmd.Short != short {
+		t.Fatalf("expected use: %s; got %s", short, cmd.Short)
+	}
+}
+
+func TestCheckExitsUnknownForUnknownFlags(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := client.NewMockAPI(ctrl)
+
+	check := cmd.NewCheckCommand(client)
+	check.SetArgs([]string{"--projct", "project-one"})
+	check.SetOut(ioutil.Discard)
+	check.SetErr(ioutil.Discard)
+
+	err := check.Execute()
+
+	exitErr, ok := err.(*cmd.ExitError)
+	if !ok || exitErr.Code != cmd.CheckUnknown {
+		t.Fatalf("expected exit code %d; got %v", cmd.CheckUnknown, err)
+	}
+}
+
+func TestCheckExitsUnknownForABadConfig(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	logs := client.NewMockLogsAPI(ctrl)
+	client := client.NewMockAPI(ctrl)
+
+	dir, err := ioutil.TempDir("", "knope")
+	if err != nil {
+		t.Fatalf("expected no error; got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	root := cmd.NewRootCommand(client, logs)
+	root.SetArgs([]string{"check", "--project", "project-one", "--config", filepath.Join(dir, "missing.yaml")})
+	root.SetOut(ioutil.Discard)
+	root.SetErr(ioutil.Discard)
+
+	err = root.Execute()
+
+	exitErr, ok := err.(*cmd.ExitError)
+	if !ok || exitErr.Code != cmd.CheckUnknown {
+		t.Fatalf("expected exit code %d; got %v", cmd.CheckUnknown, err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tt := []struct {
+		name     string
+		opts     cmd.CheckOptions
+		builds   []*codebuild.Build
+		polls    []string
+		listErr  error
+		expected string
+		code     int
+		err      string
+	}{
+		{
+			name: "succeeds when the latest build succeeded",
+			opts: cmd.CheckOptions{Project: "project-one"},
+			builds: []*codebuild.Build{
+				{Id: aws.String("project-one:3"), BuildStatus: aws.String("SUCCEEDED"), SourceVersion: aws.String("refs/heads/main"), ResolvedSourceVersion: aws.String("f00ba4c0ffee")},
+				{Id: aws.String("project-one:2"), BuildStatus: aws.String("FAILED")},
+			},
+			expected: "✅ project-one:3 SUCCEEDED main f00ba4c\n",
+			code:     cmd.CheckSucceeded,
+		},
+		{
+			name:     "exits 1 when the latest build failed",
+			opts:     cmd.CheckOptions{Project: "project-one"},
+			builds:   []*codebuild.Build{{Id: aws.String("project-one:3"), BuildStatus: aws.String("FAILED")}},
+			expected: "❌ project-one:3 FAILED\n",
+			code:     cmd.CheckFailed,
+		},
+		{
+			name:     "exits 1 when the latest build was stopped",
+			opts:     cmd.CheckOptions{Project: "project-one"},
+			builds:   []*codebuild.Build{{Id: aws.String("project-one:3"), BuildStatus: aws.String("STOPPED")}},
+			expected: "🕳 project-one:3 STOPPED\n",
+			code:     cmd.CheckFailed,
+		},
+		{
+			name:     "exits 2 when the latest build is in progress",
+			opts:     cmd.CheckOptions{Project: "project-one"},
+			builds:   []*codebuild.Build{{Id: aws.String("project-one:3"), BuildStatus: aws.String("IN_PROGRESS")}},
+			expected: "🏗 project-one:3 IN_PROGRESS\n",
+			code:     cmd.CheckInProgress,
+		},
+		{
+			name:     "can wait for the latest build to finish",
+			opts:     cmd.CheckOptions{Project: "project-one", Wait: true},
+			builds:   []*codebuild.Build{{Id: aws.String("project-one:3"), BuildStatus: aws.String("IN_PROGRESS")}},
+			polls:    []string{"IN_PROGRESS", "FAILED"},
+			expected: "🏗 project-one:3 IN_PROGRESS\n❌ project-one:3 FAILED\n",
+			code:     cmd.CheckFailed,
+		},
+		{
+			name: "only considers builds of the branch",
+			opts: cmd.CheckOptions{Project: "project-one", Branch: "main"},
+			builds: []*codebuild.Build{
+				{Id: aws.String("project-one:3"), BuildStatus: aws.String("FAILED"), SourceVersion: aws.String("pr/123")},
+				{Id: aws.String("project-one:2"), BuildStatus: aws.String("SUCCEEDED"), SourceVersion: aws.String("main")},
+			},
+			expected: "✅ project-one:2 SUCCEEDED main\n",
+			code:     cmd.CheckSucceeded,
+		},
+		{
+			name:   "exits 3 when there are no builds of the branch",
+			opts:   cmd.CheckOptions{Project: "project-one", Branch: "release"},
+			builds: []*codebuild.Build{{Id: aws.String("project-one:3"), BuildStatus: aws.String("SUCCEEDED"), SourceVersion: aws.String("main")}},
+			code:   cmd.CheckUnknown,
+			err:    "there are no builds for project project-one on release",
+		},
+		{
+			name:    "exits 3 when the builds cannot be listed",
+			opts:    cmd.CheckOptions{Project: "project-one"},
+			listErr: errors.New("there was an error"),
+			code:    cmd.CheckUnknown,
+			err:     "there was an error",
+		},
+		{
+			name: "exits 3 when there is no project, rather than looking like a failed build",
+			opts: cmd.CheckOptions{},
+			code: cmd.CheckUnknown,
+			err:  "please specify a project name",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			client := client.NewMockAPI(ctrl)
+
+			builds := map[string]*codebuild.Build{}
+			var ids []*string
+			for _, build := range tc.builds {
+				builds[aws.StringValue(build.Id)] = build
+				ids = append(ids, build.Id)
+			}
+
+			client.
+				EXPECT().
+				ListBuildsForProject(gomock.Any()).
+				Return(&codebuild.ListBuildsForProjectOutput{Ids: ids}, tc.listErr).
+				AnyTimes()
+
+			polls := tc.polls
+			client.
+				EXPECT().
+				BatchGetBuilds(gomock.Any()).
+				DoAndReturn(func(input *codebuild.BatchGetBuildsInput) (*codebuild.BatchGetBuildsOutput, error) {
+					var found []*codebuild.Build
+					for _, id := range input.Ids {
+						found = append(found, builds[aws.StringValue(id)])
+					}
+
+					// Each call moves the build on to its next status, as if it were running
+					if len(polls) > 0 {
+						builds[aws.StringValue(input.Ids[0])] = &codebuild.Build{Id: input.Ids[0], BuildStatus: aws.String(polls[0])}
+						polls = polls[1:]
+					}
+
+					return &codebuild.BatchGetBuildsOutput{Builds: found}, nil
+				}).
+				AnyTimes()
+
+			var b bytes.Buffer
+			writer := bufio.NewWriter(&b)
+
+			err := cmd.Check(client, tc.opts, writer)
+			writer.Flush()
+
+			if b.String() != tc.expected {
+				t.Fatalf("expected '%s'; got '%s'", tc.expected, b.String())
+			}
+
+			code := 0
+			if err != nil {
+				code = 1
+				if exitErr, ok := err.(*cmd.ExitError); ok {
+					code = exitErr.Code
+				}
+			}
+
+			if code != tc.code {
+				t.Fatalf("expected exit code %d; got %d", tc.code, code)
+			}
+
+			if tc.err != "" && (err == nil || err.Error() != tc.err) {
+				t.Fatalf("expected err to be %s; got %v", tc.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/benmatselby/knope/config"
+	"github.com/benmatselby/knope/ui"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// ConfigOptions defines what arguments/options the user can provide
+type ConfigOptions struct {
+	Args    []string
+	Path    string
+	Force   bool
+	Current config.Config
+}
+
+// NewConfigCommand creates a new `config` command
+func NewConfigCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "View and edit the knope config file",
+		// The config may be broken or missing, which is why we are here,
+		// so it is read but not applied
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return readConfig(true)
+		},
+	}
+
+	cmd.AddCommand(
+		newConfigViewCommand(),
+		newConfigSetCommand(),
+		newConfigInitCommand(),
+	)
+
+	return cmd
+}
+
+func newConfigViewCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "view",
+		Short: "Show the config in use, including defaults",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts, err := newConfigOptions(args)
+			if err != nil {
+				return err
+			}
+
+			if opts.Current, err = config.Load(viper.GetViper()); err != nil {
+				return err
+			}
+
+			return ViewConfig(opts, os.Stdout)
+		},
+	}
+}
+
+func newConfigSetCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "set KEY VALUE",
+		Short: "Set a value in the config file",
+		Long:  "Set a value in the config file. Keys are " + strings.Join(config.Keys, ", ") + ". Favourites are a comma separated list of projects.",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts, err := newConfigOptions(args)
+			if err != nil {
+				return err
+			}
+
+			return SetConfig(opts, os.Stdout)
+		},
+	}
+}
+
+func newConfigInitCommand() *cobra.Command {
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "init",
+		Short: "Create a config file with the default values",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts, err := newConfigOptions(args)
+			if err != nil {
+				return err
+			}
+			opts.Force = force
+
+			return InitConfig(opts, os.Stdout)
+		},
+	}
+
+	cmd.Flags().BoolVar(&force, "force", false, "Replace the config file if it already exists")
+
+	return cmd
+}
+
+// newConfigOptions works out which config file the subcommands act on
+func newConfigOptions(args []string) (ConfigOptions, error) {
+	path, err := configPath()
+	return ConfigOptions{Args: args, Path: path}, err
+}
+
+// ViewConfig renders the config in use as YAML
+func ViewConfig(opts ConfigOptions, w io.Writer) error {
+	out, err := yaml.Marshal(opts.Current)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "# %s\n%s", opts.Path, out)
+	return nil
+}
+
+// SetConfig validates and stores a single value in the config file
+func SetConfig(opts ConfigOptions, w io.Writer) error {
+	key, value := opts.Args[0], opts.Args[1]
+
+	if err := validateSetting(key, value); err != nil {
+		return err
+	}
+
+	if err := config.Set(opts.Path, key, value); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Set %s in %s\n", key, opts.Path)
+	return nil
+}
+
+// InitConfig creates the config file with the defaults
+func InitConfig(opts ConfigOptions, w io.Writer) error {
+	if err := config.Init(opts.Path, opts.Force); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Created %s\n", opts.Path)
+	return nil
+}
+
+// validateSetting makes sure we can use a value before it is saved
+func validateSetting(key, value string) error {
+	if !config.IsKey(key) {
+		return fmt.Errorf("unknown config key %q, expected one of %s", key, strings.Join(config.Keys, ", "))
+	}
+
+	switch key {
+	case config.KeyFilter:
+		_, err := regexp.Compile(value)
+		return err
+	case config.KeyOutput:
+		return validateOutput(value, "", "")
+	case config.KeyIcons:
+		_, err := ui.LookupIcons(value)
+		return err
+	case config.KeySessionDuration:
+		_, err := time.ParseDuration(value)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package cmd_test
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/benmatselby/knope/cmd"
+	"github.com/benmatselby/knope/config"
+)
+
+func TestNewConfigCommand(t *testing.T) {
+	cmd := cmd.NewConfigCommand()
+
+	use := "config"
+	short := "View and edit the knope config file"
+
+	if cmd.Use != use {
+		t.Fatalf("expected use: %s; got %s", use, cmd.Use)
+	}
+
+	if cmd.Short != short {
+		t.Fatalf("expected use: %s; got %s", short, cmd.Short)
+	}
+
+	if len(cmd.Commands()) != 3 {
+		t.Fatalf("expected 3 subcommands; got %d", len(cmd.Commands()))
+	}
+}
+
+func TestViewConfig(t *testing.T) {
+	current := config.Default()
+	current.Region = "eu-west-2"
+	current.Favourites = []string{"api"}
+
+	var b bytes.Buffer
+	writer := bufio.NewWriter(&b)
+
+	err := cmd.ViewConfig(cmd.ConfigOptions{Path: "/home/knope/.benmatselby/knope.yaml", Current: current}, writer)
+	writer.Flush()
+
+	if err != nil {
+		t.Fatalf("expected no error; got %v", err)
+	}
+
+	expected := `# /home/knope/.benmatselby/knope.yaml
+region: eu-west-2
+profile: ""
+role_arn: ""
+filter: .*
+output: table
+date_format: 02-01-2006 15:04
+icons: emoji
+favourites:
+- api
+`
+	if b.String() != expected {
+		t.Fatalf("expected '%s'; got '%s'", expected, b.String())
+	}
+}
+
+func TestSetConfig(t *testing.T) {
+	tt := []struct {
+		name     string
+		args     []string
+		expected string
+		err      string
+	}{
+		{name: "can set a value", args: []string{"output", "wide"}, expected: "output: wide\n"},
+		{name: "can set the icons", args: []string{"icons", "text"}, expected: "icons: text\n"},
+		{name: "rejects unknown keys", args: []string{"colour", "blue"}, err: `unknown config key "colour", expected one of region, profile, role_arn, external_id, mfa_serial, session_duration, filter, output, date_format, icons, favourites`},
+		{name: "rejects unknown output formats", args: []string{"output", "xml"}, err: `unknown output format "xml", expected one of table, json, yaml, csv, wide`},
+		{name: "rejects unknown icon sets", args: []string{"icons", "ascii"}, err: `unknown icon set "ascii", expected one of emoji, text`},
+		{name: "rejects broken filters", args: []string{"filter", "(api"}, err: "error parsing regexp: missing closing ): `(api`"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "knope")
+			if err != nil {
+				t.Fatalf("expected no error; got %v", err)
+			}
+			defer os.RemoveAll(dir)
+			path := filepath.Join(dir, "knope.yaml")
+
+			var b bytes.Buffer
+			err = cmd.SetConfig(cmd.ConfigOptions{Args: tc.args, Path: path}, &b)
+
+			if tc.err == "" && err != nil {
+				t.Fatalf("expected no error; got %v", err)
+			}
+
+			if tc.err != "" && (err == nil || err.Error() != tc.err) {
+				t.Fatalf("expected err to be %s; got %v", tc.err, err)
+			}
+
+			contents, _ := ioutil.ReadFile(path)
+			if string(contents) != tc.expected {
+				t.Fatalf("expected '%s'; got '%s'", tc.expected, string(contents))
+			}
+		})
+	}
+}
+
+func TestInitConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "knope")
+	if err != nil {
+		t.Fatalf("expected no error; got %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "knope.yaml")
+
+	var b bytes.Buffer
+	if err := cmd.InitConfig(cmd.ConfigOptions{Path: path}, &b); err != nil {
+		t.Fatalf("expected no error; got %v", err)
+	}
+
+	expected := "Created " + path + "\n"
+	if b.String() != expected {
+		t.Fatalf("expected '%s'; got '%s'", expected, b.String())
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("expected the config file to exist; got %v", err)
+	}
+}
@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/benmatselby/knope/config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// ContextOptions defines what arguments/options the user can provide
+type ContextOptions struct {
+	Args     []string
+	Path     string
+	Current  config.Config
+	Active   string
+	Output   string
+	Template string
+	JSONPath string
+}
+
+// NewContextCommand creates a new `context` command
+func NewContextCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "context",
+		Short: "List and switch between the contexts in the config file",
+		// Switching is how people get out of a context that no longer
+		// exists, so the config is read but not applied
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := readConfig(true); err != nil {
+				return err
+			}
+			return validateOutput(viper.GetString("output"), viper.GetString("template"), viper.GetString("jsonpath"))
+		},
+	}
+
+	cmd.AddCommand(
+		newContextListCommand(),
+		newContextUseCommand(),
+	)
+
+	return cmd
+}
+
+func newContextListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the contexts, marking the one in use",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts, err := newContextOptions(args)
+			if err != nil {
+				return err
+			}
+
+			return ListContexts(opts, os.Stdout)
+		},
+	}
+}
+
+func newContextUseCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "use NAME",
+		Short: "Set the context used when --context is not given",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts, err := newContextOptions(args)
+			if err != nil {
+				return err
+			}
+
+			return UseContext(opts, os.Stdout)
+		},
+	}
+}
+
+// newContextOptions loads the config the subcommands act on
+func newContextOptions(args []string) (ContextOptions, error) {
+	opts := ContextOptions{
+		Args:     args,
+		Output:   viper.GetString("output"),
+		Template: viper.GetString("template"),
+		JSONPath: viper.GetString("jsonpath"),
+	}
+
+	var err error
+	if opts.Path, err = configPath(); err != nil {
+		return opts, err
+	}
+
+	if opts.Current, err = config.Load(viper.GetViper()); err != nil {
+		return opts, err
+	}
+
+	opts.Active = activeContext(opts.Current)
+	return opts, nil
+}
+
+// ListContexts will render the contexts in the config file
+func ListContexts(opts ContextOptions, w io.Writer) error {
+	records := []ContextRecord{}
+	for _, name := range opts.Current.ContextNames() {
+		context := opts.Current.Contexts[name]
+		records = append(records, ContextRecord{
+			Name:    name,
+			Current: name == opts.Active,
+			Profile: context.Profile,
+			Region:  context.Region,
+			RoleARN: context.RoleARN,
+			Filter:  context.Filter,
+		})
+	}
+
+	return render(w, renderOptions{format: opts.Output, template: opts.Template, jsonpath: opts.JSONPath}, records, contextsTable)
+}
+
+// UseContext stores the context to use in the config file
+func UseContext(opts ContextOptions, w io.Writer) error {
+	name := opts.Args[0]
+	if _, err := opts.Current.Context(name); err != nil {
+		return err
+	}
+
+	if err := config.Set(opts.Path, config.KeyCurrentContext, name); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Switched to context %s\n", name)
+	return nil
+}
+
+// ContextRecord gives us a struct to store the contexts in the config file
+type ContextRecord struct {
+	Name    string `json:"name" yaml:"name"`
+	Current bool   `json:"current" yaml:"current"`
+	Profile string `json:"profile" yaml:"profile"`
+	Region  string `json:"region" yaml:"region"`
+	RoleARN string `json:"role_arn" yaml:"role_arn"`
+	Filter  string `json:"filter" yaml:"filter"`
+}
+
+// contextField adapts a ContextRecord function for use as a column value
+func contextField(f func(r ContextRecord) string) func(record interface{}) string {
+	return func(record interface{}) string {
+		return f(record.(ContextRecord))
+	}
+}
+
+var contextsTable = table{columns: []column{
+	{
+		name:   "current",
+		header: "Current",
+		value: contextField(func(r ContextRecord) string {
+			if r.Current {
+				return "*"
+			}
+			return ""
+		}),
+		raw: contextField(func(r ContextRecord) string { return strconv.FormatBool(r.Current) }),
+	},
+	{name: "name", header: "Name", value: contextField(func(r ContextRecord) string { return r.Name })},
+	{name: "profile", header: "Profile", value: contextField(func(r ContextRecord) string { return r.Profile })},
+	{name: "region", header: "Region", value: contextField(func(r ContextRecord) string { return r.Region })},
+	{name: "role_arn", header: "Role ARN", value: contextField(func(r ContextRecord) string { return r.RoleARN })},
+	{name: "filter", header: "Filter", value: contextField(func(r ContextRecord) string { return r.Filter })},
+}}
@@ -0,0 +1,131 @@
+package cmd_test
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/benmatselby/knope/cmd"
+	"github.com/benmatselby/knope/config"
+)
+
+func TestNewContextCommand(t *testing.T) {
+	cmd := cmd.NewContextCommand()
+
+	use := "context"
+	short := "List and switch between the contexts in the config file"
+
+	if cmd.Use != use {
+		t.Fatalf("expected use: %s; got %s", use, cmd.Use)
+	}
+
+	if cmd.Short != short {
+		t.Fatalf("expected use: %s; got %s", short, cmd.Short)
+	}
+}
+
+func testContextConfig() config.Config {
+	return config.Config{
+		CurrentContext: "dev",
+		Contexts: map[string]config.Context{
+			"prod": {Profile: "prod", Region: "eu-west-1", RoleARN: "arn:aws:iam::123456789012:role/readonly", Filter: "^api"},
+			"dev":  {Profile: "dev", Region: "eu-west-2"},
+		},
+	}
+}
+
+func TestListContexts(t *testing.T) {
+	tt := []struct {
+		name     string
+		opts     cmd.ContextOptions
+		expected string
+	}{
+		{
+			name: "can list the contexts",
+			opts: cmd.ContextOptions{Current: testContextConfig(), Active: "prod"},
+			expected: `Current Name Profile Region    Role ARN                                Filter
+        dev  dev     eu-west-2                                         
+*       prod prod    eu-west-1 arn:aws:iam::123456789012:role/readonly ^api
+`,
+		},
+		{
+			name: "can list the contexts as csv",
+			opts: cmd.ContextOptions{Current: testContextConfig(), Active: "dev", Output: "csv"},
+			expected: `current,name,profile,region,role_arn,filter
+true,dev,dev,eu-west-2,,
+false,prod,prod,eu-west-1,arn:aws:iam::123456789012:role/readonly,^api
+`,
+		},
+		{
+			name:     "can list no contexts",
+			opts:     cmd.ContextOptions{},
+			expected: "Current Name Profile Region Role ARN Filter\n",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var b bytes.Buffer
+			writer := bufio.NewWriter(&b)
+
+			err := cmd.ListContexts(tc.opts, writer)
+			writer.Flush()
+
+			if err != nil {
+				t.Fatalf("expected no error; got %v", err)
+			}
+
+			if b.String() != tc.expected {
+				t.Fatalf("expected '%s'; got '%s'", tc.expected, b.String())
+			}
+		})
+	}
+}
+
+func TestUseContext(t *testing.T) {
+	tt := []struct {
+		name     string
+		context  string
+		expected string
+		file     string
+		err      string
+	}{
+		{name: "can switch context", context: "prod", expected: "Switched to context prod\n", file: "region: eu-west-2\ncurrent_context: prod\n"},
+		{name: "will not switch to an unknown context", context: "staging", file: "region: eu-west-2\n", err: `unknown context "staging", expected one of dev, prod`},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "knope")
+			if err != nil {
+				t.Fatalf("expected no error; got %v", err)
+			}
+			defer os.RemoveAll(dir)
+			path := filepath.Join(dir, "knope.yaml")
+			ioutil.WriteFile(path, []byte("region: eu-west-2\n"), 0600)
+
+			var b bytes.Buffer
+			err = cmd.UseContext(cmd.ContextOptions{Args: []string{tc.context}, Path: path, Current: testContextConfig()}, &b)
+
+			if tc.err == "" && err != nil {
+				t.Fatalf("expected no error; got %v", err)
+			}
+
+			if tc.err != "" && (err == nil || err.Error() != tc.err) {
+				t.Fatalf("expected err to be %s; got %v", tc.err, err)
+			}
+
+			if b.String() != tc.expected {
+				t.Fatalf("expected '%s'; got '%s'", tc.expected, b.String())
+			}
+
+			contents, _ := ioutil.ReadFile(path)
+			if string(contents) != tc.file {
+				t.Fatalf("expected '%s'; got '%s'", tc.file, string(contents))
+			}
+		})
+	}
+}
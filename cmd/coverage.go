@@ -0,0 +1,368 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/codebuild"
+	"github.com/benmatselby/knope/client"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// CoverageOptions defines what arguments/options the user can provide
+type CoverageOptions struct {
+	Args      []string
+	Project   string
+	Limit     int
+	FailUnder float64
+	Output    string
+	Template  string
+	JSONPath  string
+}
+
+// NewCoverageCommand creates a new `coverage` command
+func NewCoverageCommand(client client.API) *cobra.Command {
+	var opts CoverageOptions
+
+	cmd := &cobra.Command{
+		Use:   "coverage [build-id|project:latest]",
+		Short: "Show the code coverage of a project's builds, or of each file in a build",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Args = args
+			opts.Output = viper.GetString("output")
+			opts.Template = viper.GetString("template")
+			opts.JSONPath = viper.GetString("jsonpath")
+
+			// Coverage being too low is not a mistake in how the command was used
+			cmd.SilenceUsage = true
+			return DisplayCoverage(client, opts, os.Stdout)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&opts.Project, "project", "", "Name of the project to show the coverage trend for")
+	flags.IntVar(&opts.Limit, "limit", 10, "Number of recent builds to show the coverage trend over")
+	flags.Float64Var(&opts.FailUnder, "fail-under", 0, "Exit with an error when line coverage of the latest build is under this percentage")
+
+	return cmd
+}
+
+// DisplayCoverage will render the coverage of each file for a build, or the
+// coverage of the latest builds of a project. It fails when the coverage of
+// the build, or the latest build, is under the threshold.
+func DisplayCoverage(api client.API, opts CoverageOptions, w io.Writer) error {
+	var latest *CoverageRecord
+
+	switch {
+	case len(opts.Args) > 0:
+		reports, err := buildReports(api, opts.Args[0], codebuild.ReportTypeCodeCoverage)
+		if err != nil {
+			return err
+		}
+
+		if len(reports) == 0 {
+			return fmt.Errorf("there are no code coverage reports for build %s", opts.Args[0])
+		}
+
+		records := []FileCoverageRecord{}
+		for _, report := range reports {
+			coverages, err := fetchCodeCoverages(api, report.Arn)
+			if err != nil {
+				return err
+			}
+			for _, coverage := range coverages {
+				records = append(records, newFileCoverageRecord(coverage))
+			}
+		}
+
+		if err := render(w, renderOptions{format: opts.Output, template: opts.Template, jsonpath: opts.JSONPath}, records, fileCoverageTable); err != nil {
+			return err
+		}
+
+		if finished := finishedCoverage(reports); len(finished) > 0 {
+			record := newCoverageRecord(nil, finished)
+			latest = &record
+		}
+	case opts.Project != "":
+		records, newest, err := fetchCoverageTrend(api, opts.Project, opts.Limit)
+		if err != nil {
+			return err
+		}
+
+		if err := render(w, renderOptions{format: opts.Output, template: opts.Template, jsonpath: opts.JSONPath}, records, coverageTable); err != nil {
+			return err
+		}
+
+		// The threshold is for the latest build, not the latest with coverage
+		if len(records) > 0 && records[0].Build == newest {
+			latest = &records[0]
+		} else if opts.FailUnder > 0 && newest != "" {
+			return fmt.Errorf("latest build has no coverage")
+		}
+	default:
+		return fmt.Errorf("please specify a build id or a project name")
+	}
+
+	if opts.FailUnder > 0 {
+		if latest == nil {
+			return fmt.Errorf("there is no code coverage to compare with %.1f%%", opts.FailUnder)
+		}
+		if latest.LineCoverage < opts.FailUnder {
+			return fmt.Errorf("line coverage of %.1f%% is under %.1f%%", latest.LineCoverage, opts.FailUnder)
+		}
+	}
+
+	return nil
+}
+
+// fetchCoverageTrend gets the coverage of the latest builds of a project
+// which created finished code coverage reports, newest first. Each record includes
+// the change from the build before it. The ID of the latest build is returned
+// too, whether it has coverage or not.
+func fetchCoverageTrend(api client.API, project string, limit int) ([]CoverageRecord, string, error) {
+	builds := []*codebuild.Build{}
+	iter := client.NewBuildIterator(api, &codebuild.ListBuildsForProjectInput{
+		ProjectName: aws.String(project),
+		SortOrder:   aws.String(codebuild.SortOrderTypeDescending),
+	}, limit)
+	for iter.Next() {
+		builds = append(builds, iter.Build())
+	}
+	if err := iter.Err(); err != nil {
+		return nil, "", err
+	}
+
+	var arns []*string
+	for _, build := range builds {
+		arns = append(arns, build.ReportArns...)
+	}
+
+	reports, err := client.GetReports(api, arns)
+	if err != nil {
+		return nil, "", err
+	}
+
+	byArn := map[string]*codebuild.Report{}
+	for _, report := range finishedCoverage(reports) {
+		byArn[aws.StringValue(report.Arn)] = report
+	}
+
+	records := []CoverageRecord{}
+	for _, build := range builds {
+		var coverage []*codebuild.Report
+		for _, arn := range build.ReportArns {
+			if report, ok := byArn[aws.StringValue(arn)]; ok {
+				coverage = append(coverage, report)
+			}
+		}
+
+		if len(coverage) > 0 {
+			records = append(records, newCoverageRecord(build, coverage))
+		}
+	}
+
+	for i := 0; i < len(records)-1; i++ {
+		change := records[i].LineCoverage - records[i+1].LineCoverage
+		records[i].Change = &change
+	}
+
+	newest := ""
+	if len(builds) > 0 {
+		newest = aws.StringValue(builds[0].Id)
+	}
+
+	return records, newest, nil
+}
+
+// finishedCoverage picks out the code coverage reports with a summary.
+// Reports still being generated, or which failed, have none, and their
+// coverage is unknown rather than 0%.
+func finishedCoverage(reports []*codebuild.Report) []*codebuild.Report {
+	var finished []*codebuild.Report
+	for _, report := range reports {
+		if aws.StringValue(report.Type) == codebuild.ReportTypeCodeCoverage && report.CodeCoverageSummary != nil {
+			finished = append(finished, report)
+		}
+	}
+
+	return finished
+}
+
+// fetchCodeCoverages pages through the coverage of each file in a report
+func fetchCodeCoverages(api client.API, report *string) ([]*codebuild.CodeCoverage, error) {
+	var coverages []*codebuild.CodeCoverage
+
+	input := &codebuild.DescribeCodeCoveragesInput{ReportArn: report}
+	for {
+		out, err := api.DescribeCodeCoverages(input)
+		if err != nil {
+			return nil, err
+		}
+
+		coverages = append(coverages, out.CodeCoverages...)
+
+		if aws.StringValue(out.NextToken) == "" {
+			return coverages, nil
+		}
+		input.NextToken = out.NextToken
+	}
+}
+
+// CoverageRecord gives us a struct to store the code coverage of a build
+type CoverageRecord struct {
+	Build           string     `json:"build,omitempty" yaml:"build,omitempty"`
+	Commit          string     `json:"commit,omitempty" yaml:"commit,omitempty"`
+	Start           *time.Time `json:"start,omitempty" yaml:"start,omitempty"`
+	LineCoverage    float64    `json:"line_coverage" yaml:"line_coverage"`
+	BranchCoverage  float64    `json:"branch_coverage" yaml:"branch_coverage"`
+	LinesCovered    int64      `json:"lines_covered" yaml:"lines_covered"`
+	LinesMissed     int64      `json:"lines_missed" yaml:"lines_missed"`
+	BranchesCovered int64      `json:"branches_covered" yaml:"branches_covered"`
+	BranchesMissed  int64      `json:"branches_missed" yaml:"branches_missed"`
+	Change          *float64   `json:"change,omitempty" yaml:"change,omitempty"`
+}
+
+// FileCoverageRecord gives us a struct to store the code coverage of a file
+type FileCoverageRecord struct {
+	File            string  `json:"file" yaml:"file"`
+	LineCoverage    float64 `json:"line_coverage" yaml:"line_coverage"`
+	BranchCoverage  float64 `json:"branch_coverage" yaml:"branch_coverage"`
+	LinesCovered    int64   `json:"lines_covered" yaml:"lines_covered"`
+	LinesMissed     int64   `json:"lines_missed" yaml:"lines_missed"`
+	BranchesCovered int64   `json:"branches_covered" yaml:"branches_covered"`
+	BranchesMissed  int64   `json:"branches_missed" yaml:"branches_missed"`
+}
+
+// newCoverageRecord adds up the code coverage reports of a build, which
+// may have several report groups
+func newCoverageRecord(build *codebuild.Build, reports []*codebuild.Report) CoverageRecord {
+	record := CoverageRecord{}
+	if build != nil {
+		record.Build = aws.StringValue(build.Id)
+		record.Commit = aws.StringValue(build.ResolvedSourceVersion)
+		record.Start = build.StartTime
+	}
+
+	for _, report := range reports {
+		if summary := report.CodeCoverageSummary; summary != nil {
+			record.LinesCovered += aws.Int64Value(summary.LinesCovered)
+			record.LinesMissed += aws.Int64Value(summary.LinesMissed)
+			record.BranchesCovered += aws.Int64Value(summary.BranchesCovered)
+			record.BranchesMissed += aws.Int64Value(summary.BranchesMissed)
+		}
+	}
+
+	record.LineCoverage = coveragePercent(record.LinesCovered, record.LinesMissed)
+	record.BranchCoverage = coveragePercent(record.BranchesCovered, record.BranchesMissed)
+
+	return record
+}
+
+// newFileCoverageRecord flattens the coverage of a file into a record
+func newFileCoverageRecord(coverage *codebuild.CodeCoverage) FileCoverageRecord {
+	return FileCoverageRecord{
+		File:            aws.StringValue(coverage.FilePath),
+		LineCoverage:    aws.Float64Value(coverage.LineCoveragePercentage),
+		BranchCoverage:  aws.Float64Value(coverage.BranchCoveragePercentage),
+		LinesCovered:    aws.Int64Value(coverage.LinesCovered),
+		LinesMissed:     aws.Int64Value(coverage.LinesMissed),
+		BranchesCovered: aws.Int64Value(coverage.BranchesCovered),
+		BranchesMissed:  aws.Int64Value(coverage.BranchesMissed),
+	}
+}
+
+// coveragePercent is the percentage of lines or branches covered
+func coveragePercent(covered, missed int64) float64 {
+	if covered+missed == 0 {
+		return 0
+	}
+	return float64(covered) * 100 / float64(covered+missed)
+}
+
+// coverageField adapts a CoverageRecord function for use as a column value
+func coverageField(f func(r CoverageRecord) string) func(record interface{}) string {
+	return func(record interface{}) string {
+		return f(record.(CoverageRecord))
+	}
+}
+
+// fileCoverageField adapts a FileCoverageRecord function for use as a column value
+func fileCoverageField(f func(r FileCoverageRecord) string) func(record interface{}) string {
+	return func(record interface{}) string {
+		return f(record.(FileCoverageRecord))
+	}
+}
+
+// formatCoverage renders a coverage percentage for people
+func formatCoverage(percent float64) string {
+	return formatPercent(percent / 100)
+}
+
+var coverageTable = table{columns: []column{
+	{name: "build", header: "Build", value: coverageField(func(r CoverageRecord) string { return r.Build })},
+	{name: "commit", header: "Commit", value: coverageField(func(r CoverageRecord) string { return r.Commit })},
+	{
+		name:   "start",
+		header: "Started",
+		value:  coverageField(func(r CoverageRecord) string { return formatTime(r.Start) }),
+		raw:    coverageField(func(r CoverageRecord) string { return rawTime(r.Start) }),
+	},
+	{
+		name:   "line_coverage",
+		header: "Lines",
+		value:  coverageField(func(r CoverageRecord) string { return formatCoverage(r.LineCoverage) }),
+		raw:    coverageField(func(r CoverageRecord) string { return strconv.FormatFloat(r.LineCoverage, 'f', 2, 64) }),
+	},
+	{
+		name:   "branch_coverage",
+		header: "Branches",
+		value:  coverageField(func(r CoverageRecord) string { return formatCoverage(r.BranchCoverage) }),
+		raw:    coverageField(func(r CoverageRecord) string { return strconv.FormatFloat(r.BranchCoverage, 'f', 2, 64) }),
+	},
+	{
+		name:   "change",
+		header: "Change",
+		value: coverageField(func(r CoverageRecord) string {
+			if r.Change == nil {
+				return "-"
+			}
+			return formatTrend(*r.Change / 100)
+		}),
+		raw: coverageField(func(r CoverageRecord) string {
+			if r.Change == nil {
+				return ""
+			}
+			return strconv.FormatFloat(*r.Change, 'f', 2, 64)
+		}),
+	},
+	{name: "lines_covered", header: "Lines covered", wide: true, value: coverageField(func(r CoverageRecord) string { return strconv.FormatInt(r.LinesCovered, 10) })},
+	{name: "lines_missed", header: "Lines missed", wide: true, value: coverageField(func(r CoverageRecord) string { return strconv.FormatInt(r.LinesMissed, 10) })},
+	{name: "branches_covered", header: "Branches covered", wide: true, value: coverageField(func(r CoverageRecord) string { return strconv.FormatInt(r.BranchesCovered, 10) })},
+	{name: "branches_missed", header: "Branches missed", wide: true, value: coverageField(func(r CoverageRecord) string { return strconv.FormatInt(r.BranchesMissed, 10) })},
+}}
+
+var fileCoverageTable = table{columns: []column{
+	{name: "file", header: "File", value: fileCoverageField(func(r FileCoverageRecord) string { return r.File })},
+	{
+		name:   "line_coverage",
+		header: "Lines",
+		value:  fileCoverageField(func(r FileCoverageRecord) string { return formatCoverage(r.LineCoverage) }),
+		raw:    fileCoverageField(func(r FileCoverageRecord) string { return strconv.FormatFloat(r.LineCoverage, 'f', 2, 64) }),
+	},
+	{
+		name:   "branch_coverage",
+		header: "Branches",
+		value:  fileCoverageField(func(r FileCoverageRecord) string { return formatCoverage(r.BranchCoverage) }),
+		raw:    fileCoverageField(func(r FileCoverageRecord) string { return strconv.FormatFloat(r.BranchCoverage, 'f', 2, 64) }),
+	},
+	{name: "lines_covered", header: "Lines covered", wide: true, value: fileCoverageField(func(r FileCoverageRecord) string { return strconv.FormatInt(r.LinesCovered, 10) })},
+	{name: "lines_missed", header: "Lines missed", wide: true, value: fileCoverageField(func(r FileCoverageRecord) string { return strconv.FormatInt(r.LinesMissed, 10) })},
+	{name: "branches_covered", header: "Branches covered", wide: true, value: fileCoverageField(func(r FileCoverageRecord) string { return strconv.FormatInt(r.BranchesCovered, 10) })},
+	{name: "branches_missed", header: "Branches missed", wide: true, value: fileCoverageField(func(r FileCoverageRecord) string { return strconv.FormatInt(r.BranchesMissed, 10) })},
+}}
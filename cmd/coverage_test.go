@@ -0,0 +1,243 @@
+package cmd_test
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/codebuild"
+	"github.com/benmatselby/knope/client"
+	"github.com/benmatselby/knope/cmd"
+	"github.com/golang/mock/gomock"
+)
+
+func TestNewCoverageCommand(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := client.NewMockAPI(ctrl)
+
+	cmd := cmd.NewCoverageCommand(client)
+
+	use := "coverage [build-id|project:latest]"
+	short := "Show the code coverage of a project's builds, or of each file in a build"
+
+	if cmd.Use != use {
+		t.Fatalf("expected use: %s; got %s", use, cmd.Use)
+	}
+
+	if cmd.Short != short {
+		t.Fatalf("expected use: %s; got %s", short, cmd.Short)
+	}
+}
+
+func TestDisplayCoverage(t *testing.T) {
+	start := func(d int) *time.Time {
+		t := time.Date(2019, time.July, d, 9, 0, 0, 0, time.UTC)
+		return &t
+	}
+	coverage := func(arn string, lines, linesMissed, branches, branchesMissed int64) *codebuild.Report {
+		return &codebuild.Report{
+			Arn:  aws.String(arn),
+			Type: aws.String("CODE_COVERAGE"),
+			CodeCoverageSummary: &codebuild.CodeCoverageReportSummary{
+				LinesCovered:    aws.Int64(lines),
+				LinesMissed:     aws.Int64(linesMissed),
+				BranchesCovered: aws.Int64(branches),
+				BranchesMissed:  aws.Int64(branchesMissed),
+			},
+		}
+	}
+
+	builds := map[string]*codebuild.Build{
+		"project-one:4": {Id: aws.String("project-one:4"), ResolvedSourceVersion: aws.String("c4"), StartTime: start(21), ReportArns: aws.StringSlice([]string{"coverage:4"})},
+		"project-one:3": {Id: aws.String("project-one:3"), ResolvedSourceVersion: aws.String("c3"), StartTime: start(20), ReportArns: aws.StringSlice([]string{"unit:3", "coverage:3"})},
+		"project-one:2": {Id: aws.String("project-one:2"), ResolvedSourceVersion: aws.String("c2"), StartTime: start(19)},
+		"project-one:1": {Id: aws.String("project-one:1"), ResolvedSourceVersion: aws.String("c1"), StartTime: start(18), ReportArns: aws.StringSlice([]string{"coverage:1"})},
+	}
+
+	reports := map[string]*codebuild.Report{
+		"unit:3":     {Arn: aws.String("unit:3"), Type: aws.String("TEST")},
+		"coverage:3": coverage("coverage:3", 80, 20, 30, 10),
+		"coverage:1": coverage("coverage:1", 75, 25, 20, 20),
+		"coverage:4": {Arn: aws.String("coverage:4"), Type: aws.String("CODE_COVERAGE"), Status: aws.String("GENERATING")},
+	}
+
+	files := []*codebuild.CodeCoverage{
+		{FilePath: aws.String("cmd/build.go"), LineCoveragePercentage: aws.Float64(92.5), BranchCoveragePercentage: aws.Float64(80), LinesCovered: aws.Int64(37), LinesMissed: aws.Int64(3)},
+		{FilePath: aws.String("cmd/logs.go"), LineCoveragePercentage: aws.Float64(50), BranchCoveragePercentage: aws.Float64(25), LinesCovered: aws.Int64(10), LinesMissed: aws.Int64(10)},
+	}
+
+	tt := []struct {
+		name     string
+		opts     cmd.CoverageOptions
+		ids      []string
+		expected string
+		err      string
+	}{
+		{
+			name: "can show the coverage trend for a project",
+			opts: cmd.CoverageOptions{Project: "project-one", Limit: 10},
+			expected: `Build         Commit Started          Lines Branches Change
+project-one:3 c3     20-07-2019 09:00 80.0% 75.0%    +5.0%
+project-one:1 c1     18-07-2019 09:00 75.0% 50.0%    -
+`,
+		},
+		{
+			name: "can show the coverage trend as csv",
+			opts: cmd.CoverageOptions{Project: "project-one", Limit: 10, Output: "csv"},
+			expected: `build,commit,start,line_coverage,branch_coverage,change,lines_covered,lines_missed,branches_covered,branches_missed
+project-one:3,c3,2019-07-20T09:00:00Z,80.00,75.00,5.00,80,20,30,10
+project-one:1,c1,2019-07-18T09:00:00Z,75.00,50.00,,75,25,20,20
+`,
+		},
+		{
+			name: "passes when the latest build is at or over the threshold",
+			opts: cmd.CoverageOptions{Project: "project-one", Limit: 10, FailUnder: 80, Template: "{{.Build}}"},
+			expected: `project-one:3
+project-one:1
+`,
+		},
+		{
+			name: "fails when the latest build is under the threshold",
+			opts: cmd.CoverageOptions{Project: "project-one", Limit: 1, FailUnder: 85, Template: "{{.Build}}"},
+			expected: `project-one:3
+`,
+			err: "line coverage of 80.0% is under 85.0%",
+		},
+		{
+			name: "skips coverage which is still being generated",
+			opts: cmd.CoverageOptions{Project: "project-one", Limit: 10},
+			ids:  []string{"project-one:4", "project-one:3", "project-one:2", "project-one:1"},
+			expected: `Build         Commit Started          Lines Branches Change
+project-one:3 c3     20-07-2019 09:00 80.0% 75.0%    +5.0%
+project-one:1 c1     18-07-2019 09:00 75.0% 50.0%    -
+`,
+		},
+		{
+			name:     "fails when the coverage of the latest build is still being generated",
+			opts:     cmd.CoverageOptions{Project: "project-one", Limit: 10, FailUnder: 70, Template: "{{.Build}}"},
+			ids:      []string{"project-one:4", "project-one:3", "project-one:2", "project-one:1"},
+			expected: "project-one:3\nproject-one:1\n",
+			err:      "latest build has no coverage",
+		},
+		{
+			name:     "fails when the latest build has no coverage report",
+			opts:     cmd.CoverageOptions{Project: "project-one", Limit: 10, FailUnder: 70, Template: "{{.Build}}"},
+			ids:      []string{"project-one:2", "project-one:1"},
+			expected: "project-one:1\n",
+			err:      "latest build has no coverage",
+		},
+		{
+			name:     "does not compare a build whose coverage is still being generated with the threshold",
+			opts:     cmd.CoverageOptions{Args: []string{"project-one:4"}, FailUnder: 50, Template: "{{.File}}"},
+			expected: "",
+			err:      "there is no code coverage to compare with 50.0%",
+		},
+		{
+			name: "can show the coverage of each file in a build",
+			opts: cmd.CoverageOptions{Args: []string{"project-one:3"}},
+			expected: `File         Lines Branches
+cmd/build.go 92.5% 80.0%
+cmd/logs.go  50.0% 25.0%
+`,
+		},
+		{
+			name: "fails when the build is under the threshold",
+			opts: cmd.CoverageOptions{Args: []string{"project-one:3"}, FailUnder: 90, Template: "{{.File}}"},
+			expected: `cmd/build.go
+cmd/logs.go
+`,
+			err: "line coverage of 80.0% is under 90.0%",
+		},
+		{
+			name: "tells you when a build has no coverage",
+			opts: cmd.CoverageOptions{Args: []string{"project-one:2"}},
+			err:  "there are no code coverage reports for build project-one:2",
+		},
+		{
+			name: "needs a build or project",
+			opts: cmd.CoverageOptions{},
+			err:  "please specify a build id or a project name",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			client := client.NewMockAPI(ctrl)
+
+			ids := tc.ids
+			if ids == nil {
+				ids = []string{"project-one:3", "project-one:2", "project-one:1"}
+			}
+
+			client.
+				EXPECT().
+				ListBuildsForProject(gomock.Any()).
+				Return(&codebuild.ListBuildsForProjectOutput{Ids: aws.StringSlice(ids)}, nil).
+				AnyTimes()
+
+			client.
+				EXPECT().
+				BatchGetBuilds(gomock.Any()).
+				DoAndReturn(func(input *codebuild.BatchGetBuildsInput) (*codebuild.BatchGetBuildsOutput, error) {
+					var out []*codebuild.Build
+					for _, id := range input.Ids {
+						out = append(out, builds[aws.StringValue(id)])
+					}
+					return &codebuild.BatchGetBuildsOutput{Builds: out}, nil
+				}).
+				AnyTimes()
+
+			client.
+				EXPECT().
+				BatchGetReports(gomock.Any()).
+				DoAndReturn(func(input *codebuild.BatchGetReportsInput) (*codebuild.BatchGetReportsOutput, error) {
+					var out []*codebuild.Report
+					for _, arn := range input.ReportArns {
+						out = append(out, reports[aws.StringValue(arn)])
+					}
+					return &codebuild.BatchGetReportsOutput{Reports: out}, nil
+				}).
+				AnyTimes()
+
+			client.
+				EXPECT().
+				DescribeCodeCoverages(gomock.Any()).
+				DoAndReturn(func(input *codebuild.DescribeCodeCoveragesInput) (*codebuild.DescribeCodeCoveragesOutput, error) {
+					if aws.StringValue(input.ReportArn) == "coverage:4" {
+						return &codebuild.DescribeCodeCoveragesOutput{}, nil
+					}
+					if aws.StringValue(input.ReportArn) != "coverage:3" {
+						t.Fatalf("expected the coverage for coverage:3; got %s", aws.StringValue(input.ReportArn))
+					}
+					if aws.StringValue(input.NextToken) == "" {
+						return &codebuild.DescribeCodeCoveragesOutput{CodeCoverages: files[:1], NextToken: aws.String("page-2")}, nil
+					}
+					return &codebuild.DescribeCodeCoveragesOutput{CodeCoverages: files[1:]}, nil
+				}).
+				AnyTimes()
+
+			var b bytes.Buffer
+			writer := bufio.NewWriter(&b)
+
+			err := cmd.DisplayCoverage(client, tc.opts, writer)
+			writer.Flush()
+
+			if b.String() != tc.expected {
+				t.Fatalf("expected '%s'; got '%s'", tc.expected, b.String())
+			}
+
+			if tc.err == "" && err != nil {
+				t.Fatalf("expected no error; got %v", err)
+			}
+
+			if tc.err != "" && (err == nil || err.Error() != tc.err) {
+				t.Fatalf("expected err to be %s; got %v", tc.err, err)
+			}
+		})
+	}
+}
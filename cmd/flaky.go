@@ -0,0 +1,222 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/codebuild"
+	"github.com/benmatselby/knope/client"
+	"github.com/benmatselby/knope/config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// FlakyOptions defines what arguments/options the user can provide
+type FlakyOptions struct {
+	Args     []string
+	Projects []string
+	Filter   string
+	Since    string
+	Output   string
+	Template string
+	JSONPath string
+}
+
+// NewFlakyCommand creates a new `flaky` command
+func NewFlakyCommand(client client.API) *cobra.Command {
+	var opts FlakyOptions
+
+	cmd := &cobra.Command{
+		Use:   "flaky",
+		Short: "Find commits that both failed and succeeded, ranking projects by flake rate",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Args = args
+			if !cmd.Flags().Changed("filter") {
+				opts.Filter = viper.GetString(config.KeyFilter)
+			}
+			opts.Output = viper.GetString("output")
+			opts.Template = viper.GetString("template")
+			opts.JSONPath = viper.GetString("jsonpath")
+			return DisplayFlaky(client, opts, os.Stdout)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringSliceVar(&opts.Projects, "project", nil, "Projects to report on, defaults to every project matching --filter")
+	flags.StringVar(&opts.Filter, "filter", ".*", "Regex to filter the projects reported on")
+	flags.StringVar(&opts.Since, "since", "30d", "How far back to look, e.g. 30d, 2w, 12h or 2020-10-01")
+
+	return cmd
+}
+
+// DisplayFlaky will render the projects with the most flaky commits first
+func DisplayFlaky(api client.API, opts FlakyOptions, w io.Writer) error {
+	since, err := parseSince(opts.Since, time.Now())
+	if err != nil {
+		return err
+	}
+
+	projects, err := windowProjects(api, opts.Projects, opts.Filter)
+	if err != nil {
+		return err
+	}
+
+	window, err := fetchWindow(api, projects, since)
+	if err != nil {
+		return err
+	}
+
+	records := []FlakyRecord{}
+	for _, project := range projects {
+		records = append(records, newFlakyRecord(project, window[project]))
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].FlakeRate != records[j].FlakeRate {
+			return records[i].FlakeRate > records[j].FlakeRate
+		}
+		return records[i].Project < records[j].Project
+	})
+
+	return render(w, renderOptions{format: opts.Output, template: opts.Template, jsonpath: opts.JSONPath}, records, flakyTable)
+}
+
+// newFlakyRecord groups the builds, which are newest first, by the commit
+// they built. A commit is flaky when it both failed and succeeded, as the
+// code did not change between the builds.
+func newFlakyRecord(project string, builds []*codebuild.Build) FlakyRecord {
+	record := FlakyRecord{Project: project}
+
+	var commits []string
+	byCommit := map[string][]*codebuild.Build{}
+	for i := len(builds) - 1; i >= 0; i-- {
+		commit := aws.StringValue(builds[i].ResolvedSourceVersion)
+		if commit == "" {
+			continue
+		}
+		if _, seen := byCommit[commit]; !seen {
+			commits = append(commits, commit)
+		}
+		byCommit[commit] = append(byCommit[commit], builds[i])
+	}
+
+	for _, commit := range commits {
+		var succeeded bool
+		var failures []*codebuild.Build
+		for _, build := range byCommit[commit] {
+			switch aws.StringValue(build.BuildStatus) {
+			case codebuild.StatusTypeSucceeded:
+				succeeded = true
+			case codebuild.StatusTypeFailed, codebuild.StatusTypeFault, codebuild.StatusTypeTimedOut:
+				failures = append(failures, build)
+			}
+		}
+
+		if !succeeded || len(failures) == 0 {
+			continue
+		}
+
+		record.FlakyCommits++
+		for _, build := range failures {
+			failure := FlakyFailureRecord{Commit: commit, ID: aws.StringValue(build.Id), Status: aws.StringValue(build.BuildStatus)}
+			if phase := newBuildRecord(build).FailedPhase(); phase != nil {
+				failure.Phase = phase.Type
+				failure.Contexts = phase.Contexts
+			}
+			record.Failures = append(record.Failures, failure)
+		}
+	}
+
+	record.Commits = len(commits)
+	if record.Commits > 0 {
+		record.FlakeRate = float64(record.FlakyCommits) / float64(record.Commits)
+	}
+
+	return record
+}
+
+// FlakyRecord gives us a struct to store the flaky commits of a project
+type FlakyRecord struct {
+	Project      string               `json:"project" yaml:"project"`
+	Commits      int                  `json:"commits" yaml:"commits"`
+	FlakyCommits int                  `json:"flaky_commits" yaml:"flaky_commits"`
+	FlakeRate    float64              `json:"flake_rate" yaml:"flake_rate"`
+	Failures     []FlakyFailureRecord `json:"failures,omitempty" yaml:"failures,omitempty"`
+}
+
+// FlakyFailureRecord gives us a struct to store a failed build of a flaky commit
+type FlakyFailureRecord struct {
+	Commit   string   `json:"commit" yaml:"commit"`
+	ID       string   `json:"id" yaml:"id"`
+	Status   string   `json:"status" yaml:"status"`
+	Phase    string   `json:"phase,omitempty" yaml:"phase,omitempty"`
+	Contexts []string `json:"contexts,omitempty" yaml:"contexts,omitempty"`
+}
+
+// FailedPhases counts the failures in each phase, most common first
+func (r FlakyRecord) FailedPhases() string {
+	counts := map[string]int{}
+	var phases []string
+	for _, failure := range r.Failures {
+		phase := failure.Phase
+		if phase == "" {
+			phase = failure.Status
+		}
+		if counts[phase] == 0 {
+			phases = append(phases, phase)
+		}
+		counts[phase]++
+	}
+
+	sort.SliceStable(phases, func(i, j int) bool { return counts[phases[i]] > counts[phases[j]] })
+
+	var summary []string
+	for _, phase := range phases {
+		summary = append(summary, fmt.Sprintf("%s x%d", phase, counts[phase]))
+	}
+
+	return strings.Join(summary, ", ")
+}
+
+// FailedContexts lists the distinct reasons the phases failed
+func (r FlakyRecord) FailedContexts() string {
+	seen := map[string]bool{}
+	var contexts []string
+	for _, failure := range r.Failures {
+		for _, context := range failure.Contexts {
+			if !seen[context] {
+				seen[context] = true
+				contexts = append(contexts, context)
+			}
+		}
+	}
+
+	return strings.Join(contexts, "; ")
+}
+
+// flakyField adapts a FlakyRecord function for use as a column value
+func flakyField(f func(r FlakyRecord) string) func(record interface{}) string {
+	return func(record interface{}) string {
+		return f(record.(FlakyRecord))
+	}
+}
+
+var flakyTable = table{columns: []column{
+	{name: "project", header: "Name", value: flakyField(func(r FlakyRecord) string { return r.Project })},
+	{name: "commits", header: "Commits", value: flakyField(func(r FlakyRecord) string { return strconv.Itoa(r.Commits) })},
+	{name: "flaky_commits", header: "Flaky", value: flakyField(func(r FlakyRecord) string { return strconv.Itoa(r.FlakyCommits) })},
+	{
+		name:   "flake_rate",
+		header: "Flake rate",
+		value:  flakyField(func(r FlakyRecord) string { return formatPercent(r.FlakeRate) }),
+		raw:    flakyField(func(r FlakyRecord) string { return strconv.FormatFloat(r.FlakeRate, 'f', 4, 64) }),
+	},
+	{name: "failed_phases", header: "Failed phases", value: flakyField(func(r FlakyRecord) string { return r.FailedPhases() })},
+	{name: "failed_contexts", header: "Reasons", wide: true, value: flakyField(func(r FlakyRecord) string { return r.FailedContexts() })},
+}}
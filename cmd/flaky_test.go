@@ -0,0 +1,162 @@
+package cmd_test
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/codebuild"
+	"github.com/benmatselby/knope/client"
+	"github.com/benmatselby/knope/cmd"
+	"github.com/golang/mock/gomock"
+)
+
+func TestNewFlakyCommand(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := client.NewMockAPI(ctrl)
+
+	cmd := cmd.NewFlakyCommand(client)
+
+	use := "flaky"
+	short := "Find commits that both failed and succeeded, ranking projects by flake rate"
+
+	if cmd.Use != use {
+		t.Fatalf("expected use: %s; got %s", use, cmd.Use)
+	}
+
+	if cmd.Short != short {
+		t.Fatalf("expected use: %s; got %s", short, cmd.Short)
+	}
+}
+
+func TestDisplayFlaky(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2019, time.July, d, 9, 0, 0, 0, time.UTC) }
+	failed := func(phase, status, context string) []*codebuild.BuildPhase {
+		phases := []*codebuild.BuildPhase{
+			{PhaseType: aws.String("SUBMITTED"), PhaseStatus: aws.String("SUCCEEDED")},
+			{PhaseType: aws.String(phase), PhaseStatus: aws.String(status)},
+		}
+		if context != "" {
+			phases[1].Contexts = []*codebuild.PhaseContext{{StatusCode: aws.String("COMMAND_EXECUTION_ERROR"), Message: aws.String(context)}}
+		}
+		return phases
+	}
+
+	projects := map[string][]testWindowBuild{
+		"project-one": {
+			{Status: "SUCCEEDED", Start: day(20), Version: "c3"},
+			{Status: "SUCCEEDED", Start: day(19), Version: "c2"},
+			{Status: "FAILED", Start: day(18), Version: "c2", Phases: failed("BUILD", "FAILED", "make test exited with 2")},
+			{Status: "SUCCEEDED", Start: day(17), Version: "c1"},
+		},
+		"project-two": {
+			{Status: "FAILED", Start: day(20), Version: "b2", Phases: failed("BUILD", "FAILED", "make test exited with 2")},
+			{Status: "SUCCEEDED", Start: day(19), Version: "b2"},
+			{Status: "TIMED_OUT", Start: day(18), Version: "b2", Phases: failed("PROVISIONING", "TIMED_OUT", "")},
+			{Status: "FAILED", Start: day(17), Version: "b1", Phases: failed("BUILD", "FAILED", "lint failed")},
+		},
+	}
+
+	tt := []struct {
+		name     string
+		opts     cmd.FlakyOptions
+		listErr  error
+		expected string
+		err      string
+	}{
+		{
+			name: "ranks the projects by flake rate",
+			opts: cmd.FlakyOptions{Filter: "project", Since: "2019-07-01"},
+			expected: `Name        Commits Flaky Flake rate Failed phases
+project-two 2       1     50.0%      PROVISIONING x1, BUILD x1
+project-one 3       1     33.3%      BUILD x1
+`,
+		},
+		{
+			name: "shows the reasons the phases failed",
+			opts: cmd.FlakyOptions{Projects: []string{"project-two"}, Since: "2019-07-01", Output: "wide"},
+			expected: `Name        Commits Flaky Flake rate Failed phases             Reasons
+project-two 2       1     50.0%      PROVISIONING x1, BUILD x1 COMMAND_EXECUTION_ERROR: make test exited with 2
+`,
+		},
+		{
+			name: "only looks at the builds since the time",
+			opts: cmd.FlakyOptions{Projects: []string{"project-one"}, Since: "2019-07-19", Output: "csv"},
+			expected: `project,commits,flaky_commits,flake_rate,failed_phases,failed_contexts
+project-one,2,0,0.0000,,
+`,
+		},
+		{
+			name:    "returns the error from listing builds",
+			opts:    cmd.FlakyOptions{Projects: []string{"project-one"}, Since: "30d"},
+			listErr: errors.New("there was an error"),
+			err:     "unable to get the builds for project-one: there was an error",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			client := client.NewMockAPI(ctrl)
+
+			ids := map[string][]*string{}
+			builds := map[string]*codebuild.Build{}
+			for project, projectBuilds := range projects {
+				var out []*codebuild.Build
+				ids[project], out = testWindowBuilds(project, projectBuilds)
+				for _, build := range out {
+					builds[aws.StringValue(build.Id)] = build
+				}
+			}
+
+			client.
+				EXPECT().
+				ListProjects(gomock.Any()).
+				Return(&codebuild.ListProjectsOutput{Projects: aws.StringSlice([]string{"project-one", "project-two"})}, nil).
+				AnyTimes()
+
+			client.
+				EXPECT().
+				ListBuildsForProject(gomock.Any()).
+				DoAndReturn(func(input *codebuild.ListBuildsForProjectInput) (*codebuild.ListBuildsForProjectOutput, error) {
+					return &codebuild.ListBuildsForProjectOutput{Ids: ids[aws.StringValue(input.ProjectName)]}, tc.listErr
+				}).
+				AnyTimes()
+
+			client.
+				EXPECT().
+				BatchGetBuilds(gomock.Any()).
+				DoAndReturn(func(input *codebuild.BatchGetBuildsInput) (*codebuild.BatchGetBuildsOutput, error) {
+					var out []*codebuild.Build
+					for _, id := range input.Ids {
+						out = append(out, builds[aws.StringValue(id)])
+					}
+					return &codebuild.BatchGetBuildsOutput{Builds: out}, nil
+				}).
+				AnyTimes()
+
+			var b bytes.Buffer
+			writer := bufio.NewWriter(&b)
+
+			err := cmd.DisplayFlaky(client, tc.opts, writer)
+			writer.Flush()
+
+			if b.String() != tc.expected {
+				t.Fatalf("expected '%s'; got '%s'", tc.expected, b.String())
+			}
+
+			if tc.err == "" && err != nil {
+				t.Fatalf("expected no error; got %v", err)
+			}
+
+			if tc.err != "" && (err == nil || err.Error() != tc.err) {
+				t.Fatalf("expected err to be %s; got %v", tc.err, err)
+			}
+		})
+	}
+}
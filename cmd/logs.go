@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go/service/codebuild"
+	"github.com/benmatselby/knope/client"
+	"github.com/spf13/cobra"
+)
+
+// LogsOptions defines what arguments/options the user can provide
+type LogsOptions struct {
+	Args         []string
+	Project      string
+	Latest       bool
+	Follow       bool
+	PollInterval time.Duration
+}
+
+// NewLogsCommand creates a new `logs` command
+func NewLogsCommand(client client.API, logs client.LogsAPI) *cobra.Command {
+	var opts LogsOptions
+
+	cmd := &cobra.Command{
+		Use:   "logs [build-id|project:latest]",
+		Short: "Show the logs for a build",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Args = args
+			return DisplayLogs(client, logs, opts, os.Stdout)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&opts.Project, "project", "", "Name of the project, used with --latest")
+	flags.BoolVar(&opts.Latest, "latest", false, "Show the logs for the latest build of the project")
+	flags.BoolVarP(&opts.Follow, "follow", "f", false, "Follow the logs until the build completes")
+	flags.DurationVar(&opts.PollInterval, "poll-interval", 5*time.Second, "How often to check for new log events when following")
+
+	return cmd
+}
+
+// DisplayLogs will render the CloudWatch log stream for a build
+func DisplayLogs(api client.API, logs client.LogsAPI, opts LogsOptions, w io.Writer) error {
+	var id string
+	if opts.Latest {
+		if opts.Project == "" {
+			return fmt.Errorf("please specify a project name")
+		}
+		id = opts.Project + ":latest"
+	} else if len(opts.Args) > 0 {
+		id = opts.Args[0]
+	} else {
+		return fmt.Errorf("please specify a build id")
+	}
+
+	buildID, err := resolveBuildID(api, id)
+	if err != nil {
+		return err
+	}
+
+	build, err := getBuild(api, buildID)
+	if err != nil {
+		return err
+	}
+
+	if build.Logs == nil || build.Logs.GroupName == nil || build.Logs.StreamName == nil {
+		return fmt.Errorf("build %s has no CloudWatch logs", aws.StringValue(buildID))
+	}
+
+	finished := aws.StringValue(build.BuildStatus) != codebuild.StatusTypeInProgress
+	input := &cloudwatchlogs.GetLogEventsInput{
+		LogGroupName:  build.Logs.GroupName,
+		LogStreamName: build.Logs.StreamName,
+		StartFromHead: aws.Bool(true),
+	}
+
+	for {
+		events, err := logs.GetLogEvents(input)
+		if err != nil && !(opts.Follow && isLogStreamMissing(err)) {
+			return err
+		}
+
+		caughtUp := true
+		if err == nil {
+			for _, event := range events.Events {
+				message := aws.StringValue(event.Message)
+				if !strings.HasSuffix(message, "\n") {
+					message += "\n"
+				}
+				fmt.Fprint(w, message)
+			}
+
+			caughtUp = aws.StringValue(events.NextForwardToken) == aws.StringValue(input.NextToken)
+			input.NextToken = events.NextForwardToken
+		}
+
+		if !caughtUp {
+			continue
+		}
+
+		if !opts.Follow || finished {
+			return nil
+		}
+
+		time.Sleep(opts.PollInterval)
+
+		build, err = getBuild(api, buildID)
+		if err != nil {
+			return err
+		}
+		finished = aws.StringValue(build.BuildStatus) != codebuild.StatusTypeInProgress
+	}
+}
+
+// getBuild will return a single build
+func getBuild(api client.API, id *string) (*codebuild.Build, error) {
+	builds, err := api.BatchGetBuilds(&codebuild.BatchGetBuildsInput{Ids: []*string{id}})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(builds.Builds) == 0 {
+		return nil, fmt.Errorf("unable to find build %s", aws.StringValue(id))
+	}
+
+	return builds.Builds[0], nil
+}
+
+// isLogStreamMissing tells us if the log stream has not been created yet
+func isLogStreamMissing(err error) bool {
+	aerr, ok := err.(awserr.Error)
+	return ok && aerr.Code() == cloudwatchlogs.ErrCodeResourceNotFoundException
+}
@@ -0,0 +1,173 @@
+package cmd_test
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go/service/codebuild"
+	"github.com/benmatselby/knope/client"
+	"github.com/benmatselby/knope/cmd"
+	"github.com/golang/mock/gomock"
+)
+
+func TestNewLogsCommand(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	logs := client.NewMockLogsAPI(ctrl)
+	client := client.NewMockAPI(ctrl)
+
+	cmd := cmd.NewLogsCommand(client, logs)
+
+	use := "logs [build-id|project:latest]"
+	short := "Show the logs for a build"
+
+	if cmd.Use != use {
+		t.Fatalf("expected use: %s; got %s", use, cmd.Use)
+	}
+
+	if cmd.Short != short {
+		t.Fatalf("expected use: %s; got %s", short, cmd.Short)
+	}
+}
+
+type testLogPage struct {
+	Messages []string
+	Token    string
+	Err      error
+}
+
+func TestDisplayLogs(t *testing.T) {
+	tt := []struct {
+		name     string
+		opts     cmd.LogsOptions
+		statuses []string
+		pages    []testLogPage
+		noLogs   bool
+		expected string
+		err      string
+	}{
+		{
+			name:     "can print the logs for a build",
+			opts:     cmd.LogsOptions{Args: []string{"project-one:1"}},
+			statuses: []string{"SUCCEEDED"},
+			pages: []testLogPage{
+				{Messages: []string{"[Container] Entering phase BUILD\n", "make test"}, Token: "f/1"},
+				{Token: "f/1"},
+			},
+			expected: "[Container] Entering phase BUILD\nmake test\n",
+		},
+		{
+			name:     "can print the logs for the latest build of a project",
+			opts:     cmd.LogsOptions{Project: "project-one", Latest: true},
+			statuses: []string{"FAILED"},
+			pages: []testLogPage{
+				{Messages: []string{"exit status 1\n"}, Token: "f/1"},
+				{Token: "f/1"},
+			},
+			expected: "exit status 1\n",
+		},
+		{
+			name:     "can follow the logs until the build completes",
+			opts:     cmd.LogsOptions{Args: []string{"project-one:1"}, Follow: true},
+			statuses: []string{"IN_PROGRESS", "IN_PROGRESS", "SUCCEEDED"},
+			pages: []testLogPage{
+				{Err: awserr.New(cloudwatchlogs.ErrCodeResourceNotFoundException, "missing", nil)},
+				{Messages: []string{"one\n"}, Token: "f/1"},
+				{Token: "f/1"},
+				{Messages: []string{"two\n"}, Token: "f/2"},
+				{Token: "f/2"},
+			},
+			expected: "one\ntwo\n",
+		},
+		{
+			name:     "returns an error if the build has no logs",
+			opts:     cmd.LogsOptions{Args: []string{"project-one:1"}},
+			statuses: []string{"SUCCEEDED"},
+			noLogs:   true,
+			err:      "build project-one:1 has no CloudWatch logs",
+		},
+		{
+			name:     "returns the error from getting the log events",
+			opts:     cmd.LogsOptions{Args: []string{"project-one:1"}},
+			statuses: []string{"SUCCEEDED"},
+			pages:    []testLogPage{{Err: errors.New("there was an error")}},
+			err:      "there was an error",
+		},
+		{
+			name: "requires a build id",
+			opts: cmd.LogsOptions{},
+			err:  "please specify a build id",
+		},
+		{
+			name: "requires a project name when asking for the latest build",
+			opts: cmd.LogsOptions{Latest: true},
+			err:  "please specify a project name",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			logs := client.NewMockLogsAPI(ctrl)
+			client := client.NewMockAPI(ctrl)
+
+			client.
+				EXPECT().
+				ListBuildsForProject(gomock.Any()).
+				Return(&codebuild.ListBuildsForProjectOutput{Ids: []*string{aws.String("project-one:1")}}, nil).
+				AnyTimes()
+
+			var calls []*gomock.Call
+			for _, status := range tc.statuses {
+				build := &codebuild.Build{Id: aws.String("project-one:1"), BuildStatus: aws.String(status)}
+				if !tc.noLogs {
+					build.Logs = &codebuild.LogsLocation{GroupName: aws.String("/aws/codebuild/project-one"), StreamName: aws.String("1")}
+				}
+
+				calls = append(calls, client.
+					EXPECT().
+					BatchGetBuilds(&codebuild.BatchGetBuildsInput{Ids: []*string{aws.String("project-one:1")}}).
+					Return(&codebuild.BatchGetBuildsOutput{Builds: []*codebuild.Build{build}}, nil))
+			}
+			gomock.InOrder(calls...)
+
+			calls = nil
+			for _, page := range tc.pages {
+				var events []*cloudwatchlogs.OutputLogEvent
+				for _, message := range page.Messages {
+					events = append(events, &cloudwatchlogs.OutputLogEvent{Message: aws.String(message)})
+				}
+
+				calls = append(calls, logs.
+					EXPECT().
+					GetLogEvents(gomock.Any()).
+					Return(&cloudwatchlogs.GetLogEventsOutput{Events: events, NextForwardToken: aws.String(page.Token)}, page.Err))
+			}
+			gomock.InOrder(calls...)
+
+			var b bytes.Buffer
+			writer := bufio.NewWriter(&b)
+
+			err := cmd.DisplayLogs(client, logs, tc.opts, writer)
+			writer.Flush()
+
+			if b.String() != tc.expected {
+				t.Fatalf("expected '%s'; got '%s'", tc.expected, b.String())
+			}
+
+			if tc.err == "" && err != nil {
+				t.Fatalf("expected no error; got %v", err)
+			}
+
+			if tc.err != "" && (err == nil || err.Error() != tc.err) {
+				t.Fatalf("expected err to be %s; got %v", tc.err, err)
+			}
+		})
+	}
+}
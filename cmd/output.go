@@ -0,0 +1,298 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"github.com/benmatselby/knope/jsonpath"
+	"github.com/benmatselby/knope/ui"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Output formats the user can ask for with --output
+const (
+	// OutputTable is the default, human friendly, table
+	OutputTable string = "table"
+	// OutputWide is the table with some extra columns
+	OutputWide string = "wide"
+	// OutputJSON renders the records as JSON
+	OutputJSON string = "json"
+	// OutputYAML renders the records as YAML
+	OutputYAML string = "yaml"
+	// OutputCSV renders the records as CSV, with raw values rather than icons
+	OutputCSV string = "csv"
+)
+
+// OutputFormats lists every format the --output flag accepts
+var OutputFormats = []string{OutputTable, OutputJSON, OutputYAML, OutputCSV, OutputWide}
+
+// column describes how one field of a record is rendered in a table or CSV
+type column struct {
+	// name identifies the column in CSV headers
+	name string
+	// header is what the table shows above the column
+	header string
+	// icon columns hold emoji, which are wider than tabwriter thinks, so get some extra padding
+	icon bool
+	// wide columns are only shown in the table with --output wide
+	wide bool
+	// value renders the field for people
+	value func(record interface{}) string
+	// raw renders the field for machines, falling back to value
+	raw func(record interface{}) string
+}
+
+// table describes how a slice of records is rendered in tabular formats
+type table struct {
+	columns     []column
+	hideHeaders bool
+	// highlight picks out records which should stand out, such as those that just changed
+	highlight func(record interface{}) bool
+}
+
+// withColumns returns a copy of the table with extra columns after the named one
+func (t table) withColumns(after string, extra ...column) table {
+	var columns []column
+	for _, c := range t.columns {
+		columns = append(columns, c)
+		if c.name == after {
+			columns = append(columns, extra...)
+		}
+	}
+
+	t.columns = columns
+	return t
+}
+
+// selectColumns returns a copy of the table with only the named columns, in
+// the order given. Wide columns are shown when they are asked for by name.
+func (t table) selectColumns(names []string) (table, error) {
+	byName := map[string]column{}
+	var known []string
+	for _, c := range t.columns {
+		byName[c.name] = c
+		known = append(known, c.name)
+	}
+
+	var columns []column
+	for _, name := range names {
+		c, ok := byName[strings.TrimSpace(name)]
+		if !ok {
+			return t, fmt.Errorf("unknown column %q, expected one of %s", name, strings.Join(known, ", "))
+		}
+		c.wide = false
+		columns = append(columns, c)
+	}
+
+	t.columns = columns
+	return t, nil
+}
+
+// renderOptions is how the user asked for records to be rendered
+type renderOptions struct {
+	format   string
+	template string
+	jsonpath string
+	// columns picks and orders the columns of a table or CSV
+	columns []string
+}
+
+// validateOutput makes sure we know how to render the format asked for
+func validateOutput(format, tmpl, path string) error {
+	if tmpl != "" && path != "" {
+		return fmt.Errorf("please specify either a template or a jsonpath, not both")
+	}
+
+	if tmpl != "" {
+		_, err := template.New("record").Parse(tmpl)
+		return err
+	}
+
+	if path != "" {
+		_, err := jsonpath.Parse(path)
+		return err
+	}
+
+	if format == "" {
+		return nil
+	}
+
+	for _, known := range OutputFormats {
+		if format == known {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown output format %q, expected one of %s", format, strings.Join(OutputFormats, ", "))
+}
+
+// render writes the records, which must be a slice, in the format asked for.
+// A template or jsonpath is applied to each record in turn.
+func render(w io.Writer, opts renderOptions, records interface{}, t table) error {
+	if err := validateOutput(opts.format, opts.template, opts.jsonpath); err != nil {
+		return err
+	}
+
+	if opts.template != "" {
+		tmpl, err := template.New("record").Parse(opts.template)
+		if err != nil {
+			return err
+		}
+		return eachRecord(w, records, tmpl.Execute)
+	}
+
+	if opts.jsonpath != "" {
+		path, err := jsonpath.Parse(opts.jsonpath)
+		if err != nil {
+			return err
+		}
+		return eachRecord(w, records, path.Execute)
+	}
+
+	if len(opts.columns) > 0 {
+		var err error
+		if t, err = t.selectColumns(opts.columns); err != nil {
+			return err
+		}
+	}
+
+	switch opts.format {
+	case OutputJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(records)
+	case OutputYAML:
+		out, err := yaml.Marshal(records)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(out)
+		return err
+	case OutputCSV:
+		return t.writeCSV(w, records)
+	default:
+		return t.writeTable(w, records, opts.format == OutputWide)
+	}
+}
+
+// eachRecord runs execute for every record, putting each on its own line
+func eachRecord(w io.Writer, records interface{}, execute func(w io.Writer, data interface{}) error) error {
+	rows := reflect.ValueOf(records)
+	for i := 0; i < rows.Len(); i++ {
+		if err := execute(w, rows.Index(i).Interface()); err != nil {
+			return err
+		}
+		fmt.Fprintln(w)
+	}
+
+	return nil
+}
+
+// writeTable renders the records through a tabwriter
+func (t table) writeTable(w io.Writer, records interface{}, wide bool) error {
+	var columns []column
+	for _, c := range t.columns {
+		if !c.wide || wide {
+			columns = append(columns, c)
+		}
+	}
+
+	tr := tabwriter.NewWriter(w, 0, 0, 1, ' ', tabwriter.FilterHTML)
+
+	if !t.hideHeaders {
+		var headers []string
+		for _, c := range columns {
+			headers = append(headers, c.pad(c.header))
+		}
+		fmt.Fprintf(tr, "%s%s\n", t.lineStart(nil), strings.Join(headers, "\t"))
+	}
+
+	rows := reflect.ValueOf(records)
+	for i := 0; i < rows.Len(); i++ {
+		record := rows.Index(i).Interface()
+
+		var cells []string
+		for _, c := range columns {
+			cells = append(cells, c.pad(c.value(record)))
+		}
+		fmt.Fprintf(tr, "%s%s%s\n", t.lineStart(record), strings.Join(cells, "\t"), t.lineEnd(record))
+	}
+
+	return tr.Flush()
+}
+
+// writeCSV renders every column, using the raw values
+func (t table) writeCSV(w io.Writer, records interface{}) error {
+	writer := csv.NewWriter(w)
+
+	var headers []string
+	for _, c := range t.columns {
+		headers = append(headers, c.name)
+	}
+	if err := writer.Write(headers); err != nil {
+		return err
+	}
+
+	rows := reflect.ValueOf(records)
+	for i := 0; i < rows.Len(); i++ {
+		var cells []string
+		for _, c := range t.columns {
+			cells = append(cells, c.rawValue(rows.Index(i).Interface()))
+		}
+		if err := writer.Write(cells); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// lineStart begins highlighting a line. Every line gets an escape sequence
+// of the same length, so tabwriter still lines the columns up.
+func (t table) lineStart(record interface{}) string {
+	if t.highlight == nil {
+		return ""
+	}
+
+	if record != nil && t.highlight(record) {
+		return ui.AnsiHighlight
+	}
+	return ui.AnsiReset
+}
+
+// lineEnd stops highlighting a line
+func (t table) lineEnd(record interface{}) string {
+	if t.highlight != nil && t.highlight(record) {
+		return ui.AnsiReset
+	}
+	return ""
+}
+
+// pad gives icon columns their extra space
+func (c column) pad(value string) string {
+	if c.icon {
+		return value + ui.AppIconPadding
+	}
+	return value
+}
+
+// rawValue is the machine friendly value of the column
+func (c column) rawValue(record interface{}) string {
+	if c.raw != nil {
+		return c.raw(record)
+	}
+	return c.value(record)
+}
+
+// asWide returns a copy of the column that is only shown with --output wide
+func (c column) asWide() column {
+	c.wide = true
+	return c
+}
@@ -0,0 +1,241 @@
+package cmd_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/codebuild"
+	"github.com/benmatselby/knope/client"
+	"github.com/benmatselby/knope/cmd"
+	"github.com/golang/mock/gomock"
+)
+
+func TestDisplayOverviewOutputFormats(t *testing.T) {
+	tt := []struct {
+		name     string
+		output   string
+		template string
+		jsonpath string
+		columns  []string
+		expected string
+		err      string
+	}{
+		{
+			name:     "can render a go template for each record",
+			template: `{{.Project}} {{.Status}}{{if .Start}} {{.Icon}} {{.Duration}}{{end}}`,
+			expected: "a SUCCEEDED ✅ 10m0s\nb UNKNOWN\n",
+		},
+		{
+			name:     "can render a jsonpath for each record",
+			jsonpath: "{.project},{.commit},{.phases[*].type}",
+			expected: "a,f00ba4,BUILD COMPLETED\nb,,\n",
+		},
+		{
+			name:     "can not use a template and a jsonpath together",
+			template: "{{.Project}}",
+			jsonpath: "{.project}",
+			err:      "please specify either a template or a jsonpath, not both",
+		},
+		{
+			name:     "returns an error for invalid jsonpath",
+			jsonpath: "{.project",
+			err:      `unclosed expression in jsonpath "{.project"`,
+		},
+		{
+			name:   "can render json",
+			output: "json",
+			expected: `[
+  {
+    "project": "a",
+    "id": "a:1",
+    "status": "SUCCEEDED",
+    "source_version": "main",
+    "commit": "f00ba4",
+    "start": "2019-07-19T23:00:00Z",
+    "finish": "2019-07-19T23:10:00Z",
+    "duration_seconds": 600,
+    "phases": [
+      {
+        "type": "BUILD",
+        "status": "SUCCEEDED",
+        "duration_seconds": 540
+      },
+      {
+        "type": "COMPLETED",
+        "duration_seconds": 0
+      }
+    ]
+  },
+  {
+    "project": "b",
+    "status": "UNKNOWN",
+    "duration_seconds": 0,
+    "error": "there was an error"
+  }
+]
+`,
+		},
+		{
+			name:   "can render yaml",
+			output: "yaml",
+			expected: `- project: a
+  id: a:1
+  status: SUCCEEDED
+  source_version: main
+  commit: f00ba4
+  start: 2019-07-19T23:00:00Z
+  finish: 2019-07-19T23:10:00Z
+  duration_seconds: 600
+  phases:
+  - type: BUILD
+    status: SUCCEEDED
+    duration_seconds: 540
+  - type: COMPLETED
+    duration_seconds: 0
+- project: b
+  status: UNKNOWN
+  duration_seconds: 0
+  error: there was an error
+`,
+		},
+		{
+			name:   "can render csv",
+			output: "csv",
+			expected: `status,project,source_version,commit,start,finish,duration_seconds,id,initiator
+SUCCEEDED,a,main,f00ba4,2019-07-19T23:00:00Z,2019-07-19T23:10:00Z,600,a:1,
+UNKNOWN,b,,,,,0,,
+`,
+		},
+		{
+			name:   "can render a wide table",
+			output: "wide",
+			expected: `Status  Name Branch Commit Started          Finished         Duration Build Initiator
+✅       a    main   f00ba4 19-07-2019 23:00 19-07-2019 23:10 10m0s    a:1   
+❓       b                  -                -                               
+`,
+		},
+		{
+			name:     "can pick and order the columns",
+			columns:  []string{"project", "status", "id"},
+			expected: "Name Status  Build\na    ✅       a:1\nb    ❓       \n",
+		},
+		{
+			name:     "can pick the columns for csv",
+			output:   "csv",
+			columns:  []string{"project", "commit"},
+			expected: "project,commit\na,f00ba4\nb,\n",
+		},
+		{
+			name:    "returns an error for unknown columns",
+			columns: []string{"status", "colour"},
+			err:     `unknown column "colour", expected one of status, project, source_version, commit, start, finish, duration_seconds, id, initiator`,
+		},
+		{
+			name:   "returns an error for unknown formats",
+			output: "xml",
+			err:    `unknown output format "xml", expected one of table, json, yaml, csv, wide`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			client := client.NewMockAPI(ctrl)
+
+			start := time.Date(2019, time.July, 19, 23, 0, 0, 0, time.UTC)
+			finish := time.Date(2019, time.July, 19, 23, 10, 0, 0, time.UTC)
+
+			client.
+				EXPECT().
+				ListProjects(gomock.Any()).
+				Return(&codebuild.ListProjectsOutput{Projects: aws.StringSlice([]string{"b", "a"})}, nil)
+
+			client.
+				EXPECT().
+				ListBuildsForProject(&codebuild.ListBuildsForProjectInput{ProjectName: aws.String("a")}).
+				Return(&codebuild.ListBuildsForProjectOutput{Ids: aws.StringSlice([]string{"a:1"})}, nil)
+
+			client.
+				EXPECT().
+				ListBuildsForProject(&codebuild.ListBuildsForProjectInput{ProjectName: aws.String("b")}).
+				Return(nil, errors.New("there was an error"))
+
+			client.
+				EXPECT().
+				BatchGetBuilds(gomock.Any()).
+				Return(&codebuild.BatchGetBuildsOutput{Builds: []*codebuild.Build{{
+					Id:                    aws.String("a:1"),
+					ProjectName:           aws.String("a"),
+					BuildStatus:           aws.String("SUCCEEDED"),
+					SourceVersion:         aws.String("main"),
+					ResolvedSourceVersion: aws.String("f00ba4"),
+					StartTime:             &start,
+					EndTime:               &finish,
+					Phases: []*codebuild.BuildPhase{
+						{PhaseType: aws.String("BUILD"), PhaseStatus: aws.String("SUCCEEDED"), DurationInSeconds: aws.Int64(540)},
+						{PhaseType: aws.String("COMPLETED")},
+					},
+				}}}, nil)
+
+			var b bytes.Buffer
+			opts := cmd.OverviewOptions{Filter: ".*", Output: tc.output, Template: tc.template, JSONPath: tc.jsonpath, Columns: tc.columns}
+			err := cmd.DisplayOverview(client, opts, &b)
+
+			if b.String() != tc.expected {
+				t.Fatalf("expected '%s'; got '%s'", tc.expected, b.String())
+			}
+
+			if tc.err == "" && err != nil {
+				t.Fatalf("expected no error; got %v", err)
+			}
+
+			if tc.err != "" && (err == nil || err.Error() != tc.err) {
+				t.Fatalf("expected err to be %s; got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestDisplayProjectsOutputFormats(t *testing.T) {
+	tt := []struct {
+		name     string
+		output   string
+		expected string
+	}{
+		{name: "can render json", output: "json", expected: "[\n  {\n    \"name\": \"a\"\n  },\n  {\n    \"name\": \"b\"\n  }\n]\n"},
+		{name: "can render yaml", output: "yaml", expected: "- name: a\n- name: b\n"},
+		{name: "can render csv", output: "csv", expected: "name\na\nb\n"},
+		{name: "can render json when there are no projects", output: "json", expected: "[]\n"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			client := client.NewMockAPI(ctrl)
+
+			projects := []string{"b", "a"}
+			if tc.expected == "[]\n" {
+				projects = nil
+			}
+
+			client.
+				EXPECT().
+				ListProjects(gomock.Any()).
+				Return(&codebuild.ListProjectsOutput{Projects: aws.StringSlice(projects)}, nil)
+
+			var b bytes.Buffer
+			if err := cmd.DisplayProjects(client, cmd.ListProjectsOptions{Output: tc.output}, &b); err != nil {
+				t.Fatalf("expected no error; got %v", err)
+			}
+
+			if b.String() != tc.expected {
+				t.Fatalf("expected '%s'; got '%s'", tc.expected, b.String())
+			}
+		})
+	}
+}
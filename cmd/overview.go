@@ -6,31 +6,61 @@ import (
 	"os"
 	"regexp"
 	"sort"
+	"strings"
 	"sync"
-	"text/tabwriter"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/codebuild"
+	"github.com/aws/aws-sdk-go/service/sts"
 	"github.com/benmatselby/knope/client"
+	"github.com/benmatselby/knope/config"
 	"github.com/benmatselby/knope/ui"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 // OverviewOptions defines what arguments/options the user can provide
 type OverviewOptions struct {
-	Args   []string
-	Filter string
+	Args       []string
+	Filter     string
+	Selector   ProjectSelector
+	Favourites []string
+	Columns    []string
+	// FailOnRed returns an error when the last build of any project failed
+	FailOnRed bool
+	Limit     int
+	Output    string
+	Template  string
+	JSONPath  string
+	Watch     time.Duration
+	// Polls limits how many times the overview is refreshed when watching, zero means forever
+	Polls       int
+	AllContexts bool
+	Regions     []string
+	// Targets are the contexts and regions to fan out over, rather than just the one client
+	Targets []OverviewTarget
 }
 
-// BuildRecord gives us a struct to store records
-type BuildRecord struct {
-	Project string
-	Status  string
-	Start   string
-	Finish  string
+// OverviewTarget is one context and region to include in the overview
+type OverviewTarget struct {
+	Context string
+	Region  string
+	Filter  string
+	API     client.API
+	// Account is the ID of the AWS account the context is for, looked up
+	// the first time the target is fetched
+	Account string
+
+	// projects remembers the configuration of the projects in the target
+	projects projectCache
 }
 
+// maxWatchBackoff is the most we will slow down polling when being throttled
+const maxWatchBackoff = 8
+
 // NewOverviewCommand creates a new `overview` command
 func NewOverviewCommand(client client.API) *cobra.Command {
 	var opts OverviewOptions
@@ -40,93 +70,410 @@ func NewOverviewCommand(client client.API) *cobra.Command {
 		Short: "Will provide an overview of the last build per project",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			opts.Args = args
+			if !cmd.Flags().Changed("filter") {
+				opts.Filter = viper.GetString(config.KeyFilter)
+			}
+			opts.Favourites = viper.GetStringSlice(config.KeyFavourites)
+			opts.Output = viper.GetString("output")
+			opts.Template = viper.GetString("template")
+			opts.JSONPath = viper.GetString("jsonpath")
+
+			if opts.AllContexts || len(opts.Regions) > 0 {
+				var err error
+				if opts.Targets, err = overviewTargets(opts, cmd.Flags().Changed("filter")); err != nil {
+					return err
+				}
+			}
+
+			if opts.FailOnRed {
+				// A failed build is not a mistake in how the command was used
+				cmd.SilenceUsage = true
+			}
+
 			return DisplayOverview(client, opts, os.Stdout)
 		},
 	}
 
 	flags := cmd.Flags()
 	flags.StringVar(&opts.Filter, "filter", ".*", "Regex to filter the projects displayed")
+	flags.IntVar(&opts.Limit, "limit", 0, "Maximum number of projects to consider (0 means no limit)")
+	flags.DurationVar(&opts.Watch, "watch", 0, "Keep refreshing the overview, e.g. --watch or --watch=30s")
+	flags.Lookup("watch").NoOptDefVal = "10s"
+	flags.BoolVar(&opts.AllContexts, "all-contexts", false, "Include every context in the config file")
+	flags.StringSliceVar(&opts.Regions, "regions", nil, "Regions to include, e.g. eu-west-1,us-east-1")
+	addSelectorFlags(flags, &opts.Selector)
+	flags.BoolVar(&opts.FailOnRed, "fail-on-red", false, "Exit with an error when the last build of any project failed")
+	flags.StringSliceVar(&opts.Columns, "columns", nil, "Columns to show, in order, from status, project, source_version, commit, start, finish, duration_seconds, id and initiator")
 
 	return cmd
 }
 
 // DisplayOverview will render each project asked for and the last build value
-func DisplayOverview(client client.API, opts OverviewOptions, w io.Writer) error {
-	projects, err := client.ListProjects(&codebuild.ListProjectsInput{SortOrder: aws.String("ASCENDING")})
+func DisplayOverview(api client.API, opts OverviewOptions, w io.Writer) error {
+	if opts.Watch > 0 {
+		if opts.FailOnRed {
+			return fmt.Errorf("--fail-on-red cannot be used with --watch")
+		}
+		return watchOverview(api, opts, w)
+	}
+
+	builds, err := fetchOverview(api, opts, nil)
 	if err != nil {
 		return err
 	}
 
-	records := make(chan BuildRecord)
-	var wg sync.WaitGroup
-	wg.Add(len(projects.Projects))
+	if err := render(w, renderOptions{format: opts.Output, template: opts.Template, jsonpath: opts.JSONPath, columns: opts.Columns}, builds, overviewTableFor(opts, overviewTable)); err != nil {
+		return err
+	}
 
-	go func() {
-		wg.Wait()
-		close(records)
-	}()
+	if opts.FailOnRed {
+		return failedBuildsError(builds)
+	}
 
-	for _, project := range projects.Projects {
-		go func(project *string) {
-			defer wg.Done()
+	return nil
+}
 
-			matched, err := regexp.MatchString(opts.Filter, *project)
-			if !matched {
-				return
+// failedBuildsError names the projects whose last build failed, if any did
+func failedBuildsError(builds []BuildRecord) error {
+	var failed []string
+	for _, build := range builds {
+		if !buildFailed(build.Status) {
+			continue
+		}
+
+		name := build.Project
+		if build.Context != "" {
+			name = fmt.Sprintf("%s (%s %s)", build.Project, build.Context, build.Region)
+		}
+		failed = append(failed, name)
+	}
+
+	if len(failed) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("the last build failed for %s", strings.Join(failed, ", "))
+}
+
+// overviewTableFor adds the context, account and region columns when the
+// overview spans more than one of them
+func overviewTableFor(opts OverviewOptions, t table) table {
+	if len(opts.Targets) == 0 {
+		return t
+	}
+
+	return t.withColumns("project", contextColumn, accountColumn, regionColumn)
+}
+
+// overviewTargets works out the contexts and regions asked for. Every
+// context is a target with --all-contexts, and --regions multiplies them
+// out over each region.
+func overviewTargets(opts OverviewOptions, filterChanged bool) ([]OverviewTarget, error) {
+	var contexts []string
+	var resolved []config.Context
+
+	if opts.AllContexts {
+		contexts = fileConfig.ContextNames()
+		if len(contexts) == 0 {
+			return nil, fmt.Errorf("there are no contexts in the config file")
+		}
+
+		for _, name := range contexts {
+			context, err := fileConfig.Resolve(name)
+			if err != nil {
+				return nil, err
 			}
+			resolved = append(resolved, context)
+		}
+	} else {
+		name := activeContext(fileConfig)
+		if name == "" {
+			name = viper.GetString(config.KeyProfile)
+		}
+		if name == "" {
+			name = "default"
+		}
 
-			projectBuilds, err := client.ListBuildsForProject(&codebuild.ListBuildsForProjectInput{
-				ProjectName: project,
+		contexts = []string{name}
+		resolved = []config.Context{{
+			Profile:    viper.GetString(config.KeyProfile),
+			Region:     viper.GetString(config.KeyRegion),
+			RoleARN:    viper.GetString(config.KeyRoleARN),
+			ExternalID: viper.GetString(config.KeyExternalID),
+			MFASerial:  viper.GetString(config.KeyMFASerial),
+			Filter:     viper.GetString(config.KeyFilter),
+		}}
+	}
+
+	var targets []OverviewTarget
+	for i, context := range resolved {
+		regions := opts.Regions
+		if len(regions) == 0 {
+			regions = []string{context.Region}
+		}
+
+		filter := context.Filter
+		if filterChanged {
+			filter = opts.Filter
+		}
+
+		for _, region := range regions {
+			c := client.NewClientFor(client.Target{
+				Profile:    context.Profile,
+				Region:     region,
+				RoleARN:    context.RoleARN,
+				ExternalID: context.ExternalID,
+				MFASerial:  context.MFASerial,
+				Duration:   viper.GetDuration(config.KeySessionDuration),
 			})
-			if err != nil {
-				records <- BuildRecord{Project: *project, Status: ui.AppUnknown, Start: "-", Finish: "-"}
-				return
+			targets = append(targets, OverviewTarget{Context: contexts[i], Region: region, Filter: filter, API: &c})
+		}
+	}
+
+	return targets, nil
+}
+
+// fetchOverview gets the last build for each project, concurrently, across
+// every target if there are any. Favourite projects come first. The cache
+// keeps the configuration of the projects for when there are no targets.
+func fetchOverview(api client.API, opts OverviewOptions, projects projectCache) ([]BuildRecord, error) {
+	var builds []BuildRecord
+	if len(opts.Targets) > 0 {
+		builds = fetchEstate(opts)
+	} else {
+		var err error
+		if builds, err = fetchLatestBuilds(api, opts.Filter, opts.Selector, opts.Limit, projects); err != nil {
+			return nil, err
+		}
+	}
+
+	favourite := map[string]bool{}
+	for _, project := range opts.Favourites {
+		favourite[project] = true
+	}
+
+	sort.Slice(builds, func(i, j int) bool {
+		if favourite[builds[i].Project] != favourite[builds[j].Project] {
+			return favourite[builds[i].Project]
+		}
+		if builds[i].Project != builds[j].Project {
+			return builds[i].Project < builds[j].Project
+		}
+		if builds[i].Context != builds[j].Context {
+			return builds[i].Context < builds[j].Context
+		}
+		return builds[i].Region < builds[j].Region
+	})
+
+	return builds, nil
+}
+
+// fetchEstate gets the last builds from every target at the same time. A
+// target we cannot talk to, maybe as its credentials expired, becomes a
+// single unknown record rather than failing the whole overview.
+func fetchEstate(opts OverviewOptions) []BuildRecord {
+	builds := []BuildRecord{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	wg.Add(len(opts.Targets))
+
+	for i := range opts.Targets {
+		// Each goroutine has its own target, so it can remember the account
+		// for when the overview is refreshed
+		go func(target *OverviewTarget) {
+			defer wg.Done()
+
+			if target.Account == "" {
+				target.Account = callerAccount(target.API)
+			}
+			if target.projects == nil {
+				target.projects = projectCache{}
 			}
 
-			if len(projectBuilds.Ids) == 0 {
-				records <- BuildRecord{Project: *project, Status: ui.AppEmpty, Start: "", Finish: ""}
-				return
+			filter := opts.Filter
+			if target.Filter != "" {
+				filter = target.Filter
 			}
 
-			builds, err := client.BatchGetBuilds(&codebuild.BatchGetBuildsInput{Ids: projectBuilds.Ids})
+			records, err := fetchLatestBuilds(target.API, filter, opts.Selector, opts.Limit, target.projects)
 			if err != nil {
-				records <- BuildRecord{Project: *project, Status: ui.AppUnknown, Start: "-", Finish: "-"}
-				return
+				records = []BuildRecord{{
+					Project:   "-",
+					Status:    StatusUnknown,
+					Error:     err.Error(),
+					throttled: request.IsErrorThrottle(err),
+				}}
 			}
 
-			build := builds.Builds[0]
-			start := build.StartTime.Format(ui.AppDateTimeFormat)
+			for i := range records {
+				records[i].Context = target.Context
+				records[i].Account = target.Account
+				records[i].Region = target.Region
+			}
 
-			finish := ""
+			mu.Lock()
+			builds = append(builds, records...)
+			mu.Unlock()
+		}(&opts.Targets[i])
+	}
 
-			if build.EndTime != nil {
-				finish = build.EndTime.Format(ui.AppDateTimeFormat)
-			}
+	wg.Wait()
+	return builds
+}
 
-			records <- BuildRecord{
-				Project: *project,
-				Status:  getBuildIcon(build.BuildStatus),
-				Start:   start,
-				Finish:  finish,
-			}
-		}(project)
+// callerAccount finds out which AWS account the client is talking to. An
+// account we cannot ask, maybe as sts is not allowed, is left blank rather
+// than hiding the builds, and asked for again next time.
+func callerAccount(api client.API) string {
+	identity, err := api.GetCallerIdentity(&sts.GetCallerIdentityInput{})
+	if err != nil {
+		return ""
 	}
 
-	var builds []BuildRecord
-	for r := range records {
-		builds = append(builds, r)
+	return aws.StringValue(identity.Account)
+}
+
+// fetchLatestBuilds gets the last build for each project matching the filter
+// and selector, a few projects at a time. CodeBuild throttles each account
+// and region on its own, so each target in the overview gets its own few.
+func fetchLatestBuilds(api client.API, filterText string, selector ProjectSelector, limit int, cache projectCache) ([]BuildRecord, error) {
+	filter, err := regexp.Compile(filterText)
+	if err != nil {
+		return nil, err
 	}
 
-	sort.Slice(builds, func(i, j int) bool { return builds[i].Project < builds[j].Project })
+	listed, err := client.NewProjectIterator(api, &codebuild.ListProjectsInput{SortOrder: aws.String("ASCENDING")}, limit).All()
+	if err != nil {
+		return nil, err
+	}
 
-	tr := tabwriter.NewWriter(w, 0, 0, 1, ' ', tabwriter.FilterHTML)
-	fmt.Fprintf(tr, "%s \t%s\t%s\t%s\n", "Status", "Name", "Branch", "Finished")
+	var matched []string
+	for _, project := range listed {
+		if filter.MatchString(aws.StringValue(project)) {
+			matched = append(matched, aws.StringValue(project))
+		}
+	}
 
-	for _, build := range builds {
-		fmt.Fprintf(tr, "%s \t%s\t%s\t%s\n", build.Status, build.Project, build.Start, build.Finish)
+	projects, err := selectProjects(api, matched, selector, cache)
+	if err != nil {
+		return nil, err
+	}
+
+	builds := []BuildRecord{}
+	var mu sync.Mutex
+
+	eachProject(projects, func(project string) {
+		record := latestBuildRecord(api, project)
+
+		mu.Lock()
+		builds = append(builds, record)
+		mu.Unlock()
+	})
+
+	return builds, nil
+}
+
+// latestBuildRecord gets the last build of a project, or the batch it ran
+// in. A project we cannot get the build for is unknown.
+func latestBuildRecord(api client.API, project string) BuildRecord {
+	latest := client.NewBuildIterator(api, &codebuild.ListBuildsForProjectInput{
+		ProjectName: aws.String(project),
+	}, 1)
+	if !latest.Next() {
+		if latest.Err() != nil {
+			return BuildRecord{
+				Project:   project,
+				Status:    StatusUnknown,
+				Error:     latest.Err().Error(),
+				throttled: request.IsErrorThrottle(latest.Err()),
+			}
+		}
+
+		return BuildRecord{Project: project, Status: StatusEmpty}
 	}
 
-	tr.Flush()
+	record := newBuildRecord(latest.Build())
+	if arn := latest.Build().BuildBatchArn; arn != nil {
+		record = latestBatchRecord(api, arn)
+	}
+	record.Project = project
+
+	return record
+}
+
+// latestBatchRecord is the record for the batch the last build ran in, as
+// the status of the batch covers every build in it
+func latestBatchRecord(api client.API, arn *string) BuildRecord {
+	batch, err := getBatch(api, aws.String(arnResource(aws.StringValue(arn))))
+	if err != nil {
+		return BuildRecord{
+			Status:    StatusUnknown,
+			Error:     err.Error(),
+			throttled: request.IsErrorThrottle(err),
+		}
+	}
+
+	return newBuildRecordForBatch(batch)
+}
+
+// watchOverview keeps redrawing the overview, highlighting the projects
+// whose status changed since the last poll. When AWS throttles us we keep
+// the last known record and slow down.
+func watchOverview(api client.API, opts OverviewOptions, w io.Writer) error {
+	previous := map[string]BuildRecord{}
+	projects := projectCache{}
+	backoff := 1
+
+	for poll := 1; opts.Polls == 0 || poll <= opts.Polls; poll++ {
+		builds, err := fetchOverview(api, opts, projects)
+		if err != nil && !request.IsErrorThrottle(err) {
+			return err
+		}
+
+		throttled := err != nil
+		changed := map[string]bool{}
+		for i, build := range builds {
+			last, seen := previous[build.key()]
+			if build.throttled {
+				throttled = true
+				if seen {
+					builds[i] = last
+				}
+				continue
+			}
+
+			changed[build.key()] = seen && last.Status != build.Status
+			previous[build.key()] = build
+		}
+
+		if throttled && backoff < maxWatchBackoff {
+			backoff *= 2
+		} else if !throttled {
+			backoff = 1
+		}
+
+		interval := opts.Watch * time.Duration(backoff)
+
+		fmt.Fprint(w, ui.ClearScreen)
+		fmt.Fprintf(w, "Every %s: knope overview\t%s\n", interval, time.Now().Format(ui.AppDateTimeFormat))
+		if throttled {
+			fmt.Fprintf(w, "%s AWS is throttling requests, slowing down\n", ui.AppStale)
+		}
+		fmt.Fprintln(w)
+
+		if err == nil {
+			t := overviewTableFor(opts, overviewTable)
+			t.highlight = func(record interface{}) bool { return changed[record.(BuildRecord).key()] }
+
+			if err := render(w, renderOptions{format: opts.Output, template: opts.Template, jsonpath: opts.JSONPath, columns: opts.Columns}, builds, t); err != nil {
+				return err
+			}
+		}
+
+		if opts.Polls == 0 || poll < opts.Polls {
+			time.Sleep(interval)
+		}
+	}
 
 	return nil
 }
@@ -139,6 +486,10 @@ func getBuildIcon(status *string) string {
 		result = ui.AppProgress
 	} else if aws.StringValue(status) == "STOPPED" || aws.StringValue(status) == "TIMED_OUT" {
 		result = ui.AppStale
+	} else if aws.StringValue(status) == StatusUnknown {
+		result = ui.AppUnknown
+	} else if aws.StringValue(status) == StatusEmpty {
+		result = ui.AppEmpty
 	} else {
 		result = ui.AppSuccess
 	}
@@ -4,10 +4,16 @@ import (
 	"bufio"
 	"bytes"
 	"errors"
+	"fmt"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/codebuild"
+	"github.com/aws/aws-sdk-go/service/sts"
 	"github.com/benmatselby/knope/client"
 	"github.com/benmatselby/knope/cmd"
 	"github.com/golang/mock/gomock"
@@ -44,6 +50,8 @@ func TestDisplayOverview(t *testing.T) {
 		projects       []string
 		builds         []testOverviewBuild
 		filter         string
+		selector       cmd.ProjectSelector
+		favourites     []string
 		expected       string
 		listProjectErr error
 		listBuildErr   error
@@ -55,8 +63,8 @@ func TestDisplayOverview(t *testing.T) {
 			Finish: time.Date(2019, time.July, 19, 23, 10, 0, 0, time.UTC),
 		}},
 			filter: ".*",
-			expected: `Status  Name Branch           Finished
-✅       a    19-07-2019 23:00 19-07-2019 23:10
+			expected: `Status  Name Branch Commit  Started          Finished         Duration
+✅       a    main   f00ba4c 19-07-2019 23:00 19-07-2019 23:10 10m0s
 `, listProjectErr: nil, listBuildErr: nil, getBuildErr: nil},
 		{name: "can order the projects", projects: []string{"a", "d", "c"}, builds: []testOverviewBuild{testOverviewBuild{
 			Status: "SUCCEEDED",
@@ -64,10 +72,22 @@ func TestDisplayOverview(t *testing.T) {
 			Finish: time.Date(2019, time.July, 19, 23, 10, 0, 0, time.UTC),
 		}},
 			filter: ".*",
-			expected: `Status  Name Branch           Finished
-✅       a    19-07-2019 23:00 19-07-2019 23:10
-✅       c    19-07-2019 23:00 19-07-2019 23:10
-✅       d    19-07-2019 23:00 19-07-2019 23:10
+			expected: `Status  Name Branch Commit  Started          Finished         Duration
+✅       a    main   f00ba4c 19-07-2019 23:00 19-07-2019 23:10 10m0s
+✅       c    main   f00ba4c 19-07-2019 23:00 19-07-2019 23:10 10m0s
+✅       d    main   f00ba4c 19-07-2019 23:00 19-07-2019 23:10 10m0s
+`, listProjectErr: nil, listBuildErr: nil, getBuildErr: nil},
+		{name: "can list favourite projects first", projects: []string{"a", "d", "c"}, builds: []testOverviewBuild{testOverviewBuild{
+			Status: "SUCCEEDED",
+			Start:  time.Date(2019, time.July, 19, 23, 0, 0, 0, time.UTC),
+			Finish: time.Date(2019, time.July, 19, 23, 10, 0, 0, time.UTC),
+		}},
+			filter:     ".*",
+			favourites: []string{"d"},
+			expected: `Status  Name Branch Commit  Started          Finished         Duration
+✅       d    main   f00ba4c 19-07-2019 23:00 19-07-2019 23:10 10m0s
+✅       a    main   f00ba4c 19-07-2019 23:00 19-07-2019 23:10 10m0s
+✅       c    main   f00ba4c 19-07-2019 23:00 19-07-2019 23:10 10m0s
 `, listProjectErr: nil, listBuildErr: nil, getBuildErr: nil},
 		{name: "can ignore projects if filter is defined", projects: []string{"a", "d", "c"}, builds: []testOverviewBuild{testOverviewBuild{
 			Status: "SUCCEEDED",
@@ -75,8 +95,18 @@ func TestDisplayOverview(t *testing.T) {
 			Finish: time.Date(2019, time.July, 19, 23, 10, 0, 0, time.UTC),
 		}},
 			filter: "d",
-			expected: `Status  Name Branch           Finished
-✅       d    19-07-2019 23:00 19-07-2019 23:10
+			expected: `Status  Name Branch Commit  Started          Finished         Duration
+✅       d    main   f00ba4c 19-07-2019 23:00 19-07-2019 23:10 10m0s
+`, listProjectErr: nil, listBuildErr: nil, getBuildErr: nil},
+		{name: "can select projects by their configuration", projects: []string{"a", "d", "c"}, builds: []testOverviewBuild{testOverviewBuild{
+			Status: "SUCCEEDED",
+			Start:  time.Date(2019, time.July, 19, 23, 0, 0, 0, time.UTC),
+			Finish: time.Date(2019, time.July, 19, 23, 10, 0, 0, time.UTC),
+		}},
+			filter:   ".*",
+			selector: cmd.ProjectSelector{ComputeType: "BUILD_GENERAL1_LARGE"},
+			expected: `Status  Name Branch Commit  Started          Finished         Duration
+✅       d    main   f00ba4c 19-07-2019 23:00 19-07-2019 23:10 10m0s
 `, listProjectErr: nil, listBuildErr: nil, getBuildErr: nil},
 		{name: "can return a failed build per project", projects: []string{"a"}, builds: []testOverviewBuild{testOverviewBuild{
 			Status: "FAILED",
@@ -84,8 +114,8 @@ func TestDisplayOverview(t *testing.T) {
 			Finish: time.Date(2019, time.July, 19, 23, 10, 0, 0, time.UTC),
 		}},
 			filter: ".*",
-			expected: `Status  Name Branch           Finished
-❌       a    19-07-2019 23:00 19-07-2019 23:10
+			expected: `Status  Name Branch Commit  Started          Finished         Duration
+❌       a    main   f00ba4c 19-07-2019 23:00 19-07-2019 23:10 10m0s
 `, listProjectErr: nil, listBuildErr: nil, getBuildErr: nil},
 		{name: "can return a faulted build per project", projects: []string{"a"}, builds: []testOverviewBuild{testOverviewBuild{
 			Status: "FAILED",
@@ -93,8 +123,8 @@ func TestDisplayOverview(t *testing.T) {
 			Finish: time.Date(2019, time.July, 19, 23, 10, 0, 0, time.UTC),
 		}},
 			filter: ".*",
-			expected: `Status  Name Branch           Finished
-❌       a    19-07-2019 23:00 19-07-2019 23:10
+			expected: `Status  Name Branch Commit  Started          Finished         Duration
+❌       a    main   f00ba4c 19-07-2019 23:00 19-07-2019 23:10 10m0s
 `, listProjectErr: nil, listBuildErr: nil, getBuildErr: nil},
 		{name: "can return an in progress build per project", projects: []string{"a"}, builds: []testOverviewBuild{testOverviewBuild{
 			Status: "IN_PROGRESS",
@@ -102,8 +132,8 @@ func TestDisplayOverview(t *testing.T) {
 			Finish: time.Date(2019, time.July, 19, 23, 10, 0, 0, time.UTC),
 		}},
 			filter: ".*",
-			expected: `Status  Name Branch           Finished
-🏗       a    19-07-2019 23:00 19-07-2019 23:10
+			expected: `Status  Name Branch Commit  Started          Finished         Duration
+🏗       a    main   f00ba4c 19-07-2019 23:00 19-07-2019 23:10 10m0s
 `, listProjectErr: nil, listBuildErr: nil, getBuildErr: nil},
 		{name: "can return a stopped build per project", projects: []string{"a"}, builds: []testOverviewBuild{testOverviewBuild{
 			Status: "STOPPED",
@@ -111,8 +141,8 @@ func TestDisplayOverview(t *testing.T) {
 			Finish: time.Date(2019, time.July, 19, 23, 10, 0, 0, time.UTC),
 		}},
 			filter: ".*",
-			expected: `Status  Name Branch           Finished
-🕳       a    19-07-2019 23:00 19-07-2019 23:10
+			expected: `Status  Name Branch Commit  Started          Finished         Duration
+🕳       a    main   f00ba4c 19-07-2019 23:00 19-07-2019 23:10 10m0s
 `, listProjectErr: nil, listBuildErr: nil, getBuildErr: nil},
 		{name: "can return a timed out build per project", projects: []string{"a"}, builds: []testOverviewBuild{testOverviewBuild{
 			Status: "TIMED_OUT",
@@ -120,8 +150,8 @@ func TestDisplayOverview(t *testing.T) {
 			Finish: time.Date(2019, time.July, 19, 23, 10, 0, 0, time.UTC),
 		}},
 			filter: ".*",
-			expected: `Status  Name Branch           Finished
-🕳       a    19-07-2019 23:00 19-07-2019 23:10
+			expected: `Status  Name Branch Commit  Started          Finished         Duration
+🕳       a    main   f00ba4c 19-07-2019 23:00 19-07-2019 23:10 10m0s
 `, listProjectErr: nil, listBuildErr: nil, getBuildErr: nil},
 		{
 			name:           "unable to list projects",
@@ -138,9 +168,8 @@ func TestDisplayOverview(t *testing.T) {
 			projects: []string{"a"},
 			builds:   nil,
 			filter:   ".*",
-			expected: `Status  Name Branch Finished
-❓       a    -      -
-`,
+			expected: "Status  Name Branch Commit Started Finished Duration\n" +
+				"❓       a                  -       -        \n",
 			listProjectErr: nil,
 			listBuildErr:   errors.New("unable to list builds for project"),
 			getBuildErr:    nil,
@@ -150,9 +179,8 @@ func TestDisplayOverview(t *testing.T) {
 			projects: []string{"a"},
 			builds:   nil,
 			filter:   ".*",
-			expected: `Status  Name Branch Finished
-❓       a    -      -
-`,
+			expected: "Status  Name Branch Commit Started Finished Duration\n" +
+				"❓       a                  -       -        \n",
 			listProjectErr: nil,
 			listBuildErr:   nil,
 			getBuildErr:    errors.New("unable to get batch builds"),
@@ -180,9 +208,11 @@ func TestDisplayOverview(t *testing.T) {
 			var builds []*codebuild.Build
 			for index, _ := range tc.builds {
 				builds = append(builds, &codebuild.Build{
-					StartTime:   &tc.builds[index].Start,
-					EndTime:     &tc.builds[index].Finish,
-					BuildStatus: &tc.builds[index].Status,
+					StartTime:             &tc.builds[index].Start,
+					EndTime:               &tc.builds[index].Finish,
+					BuildStatus:           &tc.builds[index].Status,
+					SourceVersion:         aws.String("main"),
+					ResolvedSourceVersion: aws.String("f00ba4c0ffee"),
 				})
 			}
 			buildOutput := codebuild.BatchGetBuildsOutput{
@@ -207,11 +237,32 @@ func TestDisplayOverview(t *testing.T) {
 				Return(&buildOutput, tc.getBuildErr).
 				AnyTimes()
 
+			client.
+				EXPECT().
+				BatchGetProjects(gomock.Any()).
+				DoAndReturn(func(input *codebuild.BatchGetProjectsInput) (*codebuild.BatchGetProjectsOutput, error) {
+					out := &codebuild.BatchGetProjectsOutput{}
+					for _, name := range input.Names {
+						computeType := "BUILD_GENERAL1_SMALL"
+						if aws.StringValue(name) == "d" {
+							computeType = "BUILD_GENERAL1_LARGE"
+						}
+						out.Projects = append(out.Projects, &codebuild.Project{
+							Name:        name,
+							Environment: &codebuild.ProjectEnvironment{ComputeType: aws.String(computeType)},
+						})
+					}
+					return out, nil
+				}).
+				AnyTimes()
+
 			var b bytes.Buffer
 			writer := bufio.NewWriter(&b)
 
 			opts := cmd.OverviewOptions{
-				Filter: tc.filter,
+				Filter:     tc.filter,
+				Selector:   tc.selector,
+				Favourites: tc.favourites,
 			}
 
 			err := cmd.DisplayOverview(client, opts, writer)
@@ -229,3 +280,441 @@ func TestDisplayOverview(t *testing.T) {
 		})
 	}
 }
+
+func TestDisplayOverviewWatch(t *testing.T) {
+	tt := []struct {
+		name       string
+		statuses   []string
+		listErrs   []error
+		expected   []string
+		unexpected []string
+	}{
+		{
+			name:     "highlights projects whose status changed",
+			statuses: []string{"SUCCEEDED", "FAILED"},
+			listErrs: []error{nil, nil},
+			expected: []string{
+				"\033[H\033[2JEvery 1ms: knope overview",
+				"\033[0m✅       a    pr/123 f00ba4c 19-07-2019 23:00 19-07-2019 23:10 10m0s\n",
+				"\033[7m❌       a    pr/123 f00ba4c 19-07-2019 23:00 19-07-2019 23:10 10m0s\033[0m\n",
+			},
+		},
+		{
+			name:     "shows how long in progress builds have been running",
+			statuses: []string{"IN_PROGRESS"},
+			listErrs: []error{nil},
+			expected: []string{"Status  Name Branch Commit  Started          Finished         Duration", "🏗       a    pr/123 f00ba4c 19-07-2019 23:00"},
+		},
+		{
+			name:     "keeps the last known build and slows down when throttled",
+			statuses: []string{"SUCCEEDED"},
+			listErrs: []error{nil, awserr.New("ThrottlingException", "Rate exceeded", nil)},
+			expected: []string{
+				"Every 2ms: knope overview",
+				"🕳 AWS is throttling requests, slowing down\n",
+				"\033[0m✅       a    pr/123 f00ba4c 19-07-2019 23:00 19-07-2019 23:10 10m0s\n\033[H",
+			},
+			unexpected: []string{"❓"},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			client := client.NewMockAPI(ctrl)
+
+			client.
+				EXPECT().
+				ListProjects(gomock.Any()).
+				Return(&codebuild.ListProjectsOutput{Projects: aws.StringSlice([]string{"a"})}, nil).
+				Times(len(tc.listErrs))
+
+			var calls []*gomock.Call
+			for _, err := range tc.listErrs {
+				var output *codebuild.ListBuildsForProjectOutput
+				if err == nil {
+					output = &codebuild.ListBuildsForProjectOutput{Ids: aws.StringSlice([]string{"a:1"})}
+				}
+				calls = append(calls, client.
+					EXPECT().
+					ListBuildsForProject(gomock.Any()).
+					Return(output, err))
+			}
+			gomock.InOrder(calls...)
+
+			start := time.Date(2019, time.July, 19, 23, 0, 0, 0, time.UTC)
+			finish := time.Date(2019, time.July, 19, 23, 10, 0, 0, time.UTC)
+
+			calls = nil
+			for index := range tc.statuses {
+				calls = append(calls, client.
+					EXPECT().
+					BatchGetBuilds(gomock.Any()).
+					Return(&codebuild.BatchGetBuildsOutput{Builds: []*codebuild.Build{{
+						BuildStatus:           &tc.statuses[index],
+						SourceVersion:         aws.String("pr/123"),
+						ResolvedSourceVersion: aws.String("f00ba4c0ffee"),
+						StartTime:             &start,
+						EndTime:               &finish,
+					}}}, nil))
+			}
+			gomock.InOrder(calls...)
+
+			var b bytes.Buffer
+			opts := cmd.OverviewOptions{Filter: ".*", Watch: time.Millisecond, Polls: len(tc.listErrs)}
+
+			if err := cmd.DisplayOverview(client, opts, &b); err != nil {
+				t.Fatalf("expected no error; got %v", err)
+			}
+
+			for _, expected := range tc.expected {
+				if !strings.Contains(b.String(), expected) {
+					t.Fatalf("expected '%s' to contain '%s'", b.String(), expected)
+				}
+			}
+
+			for _, unexpected := range tc.unexpected {
+				if strings.Contains(b.String(), unexpected) {
+					t.Fatalf("expected '%s' not to contain '%s'", b.String(), unexpected)
+				}
+			}
+		})
+	}
+}
+
+func TestDisplayOverviewWatchGetsProjectsOnce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := client.NewMockAPI(ctrl)
+
+	client.
+		EXPECT().
+		ListProjects(gomock.Any()).
+		Return(&codebuild.ListProjectsOutput{Projects: aws.StringSlice([]string{"a", "b"})}, nil).
+		Times(3)
+
+	client.
+		EXPECT().
+		BatchGetProjects(gomock.Any()).
+		Return(&codebuild.BatchGetProjectsOutput{Projects: []*codebuild.Project{
+			{Name: aws.String("a"), Environment: &codebuild.ProjectEnvironment{ComputeType: aws.String("BUILD_GENERAL1_LARGE")}},
+			{Name: aws.String("b"), Environment: &codebuild.ProjectEnvironment{ComputeType: aws.String("BUILD_GENERAL1_SMALL")}},
+		}}, nil).
+		Times(1)
+
+	client.
+		EXPECT().
+		ListBuildsForProject(&codebuild.ListBuildsForProjectInput{ProjectName: aws.String("a")}).
+		Return(&codebuild.ListBuildsForProjectOutput{Ids: aws.StringSlice([]string{"a:1"})}, nil).
+		Times(3)
+
+	client.
+		EXPECT().
+		BatchGetBuilds(gomock.Any()).
+		Return(&codebuild.BatchGetBuildsOutput{Builds: []*codebuild.Build{{BuildStatus: aws.String("SUCCEEDED")}}}, nil).
+		Times(3)
+
+	var b bytes.Buffer
+	opts := cmd.OverviewOptions{
+		Filter:   ".*",
+		Selector: cmd.ProjectSelector{ComputeType: "BUILD_GENERAL1_LARGE"},
+		Watch:    time.Millisecond,
+		Polls:    3,
+	}
+
+	if err := cmd.DisplayOverview(client, opts, &b); err != nil {
+		t.Fatalf("expected no error; got %v", err)
+	}
+}
+
+func TestDisplayOverviewAcrossTargets(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	start := time.Date(2019, time.July, 19, 23, 0, 0, 0, time.UTC)
+	finish := time.Date(2019, time.July, 19, 23, 10, 0, 0, time.UTC)
+
+	newTarget := func(account, status string, projects ...string) *client.MockAPI {
+		api := client.NewMockAPI(ctrl)
+		api.
+			EXPECT().
+			GetCallerIdentity(gomock.Any()).
+			Return(&sts.GetCallerIdentityOutput{Account: aws.String(account)}, nil)
+		api.
+			EXPECT().
+			ListProjects(gomock.Any()).
+			Return(&codebuild.ListProjectsOutput{Projects: aws.StringSlice(projects)}, nil).
+			AnyTimes()
+		api.
+			EXPECT().
+			ListBuildsForProject(gomock.Any()).
+			Return(&codebuild.ListBuildsForProjectOutput{Ids: aws.StringSlice([]string{"build:1"})}, nil).
+			AnyTimes()
+		api.
+			EXPECT().
+			BatchGetBuilds(gomock.Any()).
+			Return(&codebuild.BatchGetBuildsOutput{Builds: []*codebuild.Build{{
+				BuildStatus:   aws.String(status),
+				SourceVersion: aws.String("refs/heads/main"),
+				StartTime:     &start,
+				EndTime:       &finish,
+			}}}, nil).
+			AnyTimes()
+		return api
+	}
+
+	expired := client.NewMockAPI(ctrl)
+	expired.
+		EXPECT().
+		GetCallerIdentity(gomock.Any()).
+		Return(nil, awserr.New("ExpiredToken", "The security token included in the request is expired", nil)).
+		AnyTimes()
+	expired.
+		EXPECT().
+		ListProjects(gomock.Any()).
+		Return(nil, awserr.New("ExpiredToken", "The security token included in the request is expired", nil)).
+		AnyTimes()
+
+	opts := cmd.OverviewOptions{
+		Filter: ".*",
+		Targets: []cmd.OverviewTarget{
+			{Context: "prod", Region: "us-east-1", API: newTarget("111111111111", "FAILED", "api")},
+			{Context: "dev", Region: "eu-west-1", API: newTarget("222222222222", "SUCCEEDED", "api", "web")},
+			{Context: "dev", Region: "us-east-1", Filter: "^api$", API: newTarget("222222222222", "IN_PROGRESS", "api", "web")},
+			{Context: "staging", Region: "eu-west-1", API: expired},
+		},
+	}
+
+	// The accounts are only looked up once, however often the overview is shown
+	var b bytes.Buffer
+	for i := 0; i < 2; i++ {
+		b.Reset()
+		if err := cmd.DisplayOverview(nil, opts, &b); err != nil {
+			t.Fatalf("expected no error; got %v", err)
+		}
+	}
+
+	expected := `Status  Name Context Account      Region    Branch Commit Started          Finished         Duration
+❓       -    staging              eu-west-1               -                -                
+✅       api  dev     222222222222 eu-west-1 main          19-07-2019 23:00 19-07-2019 23:10 10m0s
+🏗       api  dev     222222222222 us-east-1 main          19-07-2019 23:00 19-07-2019 23:10 10m0s
+❌       api  prod    111111111111 us-east-1 main          19-07-2019 23:00 19-07-2019 23:10 10m0s
+✅       web  dev     222222222222 eu-west-1 main          19-07-2019 23:00 19-07-2019 23:10 10m0s
+`
+	if b.String() != expected {
+		t.Fatalf("expected '%s'; got '%s'", expected, b.String())
+	}
+}
+
+func TestDisplayOverviewLimitsConcurrentProjects(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := client.NewMockAPI(ctrl)
+
+	var projects []string
+	for i := 0; i < 50; i++ {
+		projects = append(projects, fmt.Sprintf("project-%d", i))
+	}
+
+	client.
+		EXPECT().
+		ListProjects(gomock.Any()).
+		Return(&codebuild.ListProjectsOutput{Projects: aws.StringSlice(projects)}, nil)
+
+	var mu sync.Mutex
+	inFlight, most := 0, 0
+	client.
+		EXPECT().
+		ListBuildsForProject(gomock.Any()).
+		DoAndReturn(func(input *codebuild.ListBuildsForProjectInput) (*codebuild.ListBuildsForProjectOutput, error) {
+			mu.Lock()
+			inFlight++
+			if inFlight > most {
+				most = inFlight
+			}
+			mu.Unlock()
+
+			time.Sleep(time.Millisecond)
+
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+			return &codebuild.ListBuildsForProjectOutput{}, nil
+		}).
+		Times(len(projects))
+
+	var b bytes.Buffer
+	if err := cmd.DisplayOverview(client, cmd.OverviewOptions{Filter: ".*"}, &b); err != nil {
+		t.Fatalf("expected no error; got %v", err)
+	}
+
+	if most > 8 {
+		t.Fatalf("expected at most 8 projects to be fetched at once; got %d", most)
+	}
+}
+
+func TestDisplayOverviewBatches(t *testing.T) {
+	start := time.Date(2019, time.July, 19, 23, 0, 0, 0, time.UTC)
+	finish := time.Date(2019, time.July, 19, 23, 10, 0, 0, time.UTC)
+	batchFinish := time.Date(2019, time.July, 19, 23, 30, 0, 0, time.UTC)
+
+	tt := []struct {
+		name     string
+		batchErr error
+		expected string
+	}{
+		{
+			name: "shows the status of the batch the last build ran in",
+			expected: `Status  Name Branch Commit Started          Finished         Duration Build     Initiator
+❌       a    main          19-07-2019 23:00 19-07-2019 23:30 30m0s    a:batch-1 GitHub-Hookshot/abc
+✅       b    pr/7          19-07-2019 23:00 19-07-2019 23:10 10m0s    b:build-1 ben
+`,
+		},
+		{
+			name:     "shows projects whose batch we cannot get as unknown",
+			batchErr: errors.New("unable to get batch builds"),
+			expected: `Status  Name Branch Commit Started          Finished         Duration Build     Initiator
+❓       a                  -                -                                   
+✅       b    pr/7          19-07-2019 23:00 19-07-2019 23:10 10m0s    b:build-1 ben
+`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			client := client.NewMockAPI(ctrl)
+
+			builds := map[string]*codebuild.Build{
+				"a": {
+					Id:            aws.String("a:build-2"),
+					BuildStatus:   aws.String("SUCCEEDED"),
+					SourceVersion: aws.String("main"),
+					StartTime:     &start,
+					EndTime:       &finish,
+					BuildBatchArn: aws.String("arn:aws:codebuild:eu-west-2:123456789012:build-batch/a:batch-1"),
+				},
+				"b": {Id: aws.String("b:build-1"), BuildStatus: aws.String("SUCCEEDED"), SourceVersion: aws.String("pr/7"), Initiator: aws.String("ben"), StartTime: &start, EndTime: &finish},
+			}
+
+			client.
+				EXPECT().
+				ListProjects(gomock.Any()).
+				Return(&codebuild.ListProjectsOutput{Projects: aws.StringSlice([]string{"a", "b"})}, nil).
+				AnyTimes()
+
+			client.
+				EXPECT().
+				ListBuildsForProject(gomock.Any()).
+				DoAndReturn(func(input *codebuild.ListBuildsForProjectInput) (*codebuild.ListBuildsForProjectOutput, error) {
+					return &codebuild.ListBuildsForProjectOutput{Ids: []*string{builds[aws.StringValue(input.ProjectName)].Id}}, nil
+				}).
+				AnyTimes()
+
+			client.
+				EXPECT().
+				BatchGetBuilds(gomock.Any()).
+				DoAndReturn(func(input *codebuild.BatchGetBuildsInput) (*codebuild.BatchGetBuildsOutput, error) {
+					project := strings.Split(aws.StringValue(input.Ids[0]), ":")[0]
+					return &codebuild.BatchGetBuildsOutput{Builds: []*codebuild.Build{builds[project]}}, nil
+				}).
+				AnyTimes()
+
+			client.
+				EXPECT().
+				BatchGetBuildBatches(gomock.Any()).
+				DoAndReturn(func(input *codebuild.BatchGetBuildBatchesInput) (*codebuild.BatchGetBuildBatchesOutput, error) {
+					if aws.StringValue(input.Ids[0]) != "a:batch-1" {
+						t.Fatalf("expected to get batch a:batch-1; got %s", aws.StringValue(input.Ids[0]))
+					}
+					return &codebuild.BatchGetBuildBatchesOutput{BuildBatches: []*codebuild.BuildBatch{{
+						Id:               aws.String("a:batch-1"),
+						ProjectName:      aws.String("a"),
+						BuildBatchStatus: aws.String("FAILED"),
+						SourceVersion:    aws.String("main"),
+						Initiator:        aws.String("GitHub-Hookshot/abc"),
+						StartTime:        &start,
+						EndTime:          &batchFinish,
+					}}}, tc.batchErr
+				}).
+				Times(1)
+
+			var b bytes.Buffer
+			writer := bufio.NewWriter(&b)
+
+			err := cmd.DisplayOverview(client, cmd.OverviewOptions{Filter: ".*", Output: "wide"}, writer)
+			writer.Flush()
+
+			if err != nil {
+				t.Fatalf("expected no error; got %v", err)
+			}
+
+			if b.String() != tc.expected {
+				t.Fatalf("expected '%s'; got '%s'", tc.expected, b.String())
+			}
+		})
+	}
+}
+
+func TestDisplayOverviewFailOnRed(t *testing.T) {
+	tt := []struct {
+		name   string
+		filter string
+		watch  time.Duration
+		err    string
+	}{
+		{name: "returns an error naming the projects whose last build failed", filter: ".*", err: "the last build failed for b, c"},
+		{name: "ignores projects that are filtered out", filter: "a"},
+		{name: "cannot be used when watching", filter: ".*", watch: time.Second, err: "--fail-on-red cannot be used with --watch"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			client := client.NewMockAPI(ctrl)
+
+			statuses := map[string]string{"a": "SUCCEEDED", "b": "FAILED", "c": "TIMED_OUT"}
+
+			client.
+				EXPECT().
+				ListProjects(gomock.Any()).
+				Return(&codebuild.ListProjectsOutput{Projects: aws.StringSlice([]string{"a", "b", "c"})}, nil).
+				AnyTimes()
+
+			client.
+				EXPECT().
+				ListBuildsForProject(gomock.Any()).
+				DoAndReturn(func(input *codebuild.ListBuildsForProjectInput) (*codebuild.ListBuildsForProjectOutput, error) {
+					return &codebuild.ListBuildsForProjectOutput{Ids: []*string{aws.String(aws.StringValue(input.ProjectName) + ":1")}}, nil
+				}).
+				AnyTimes()
+
+			client.
+				EXPECT().
+				BatchGetBuilds(gomock.Any()).
+				DoAndReturn(func(input *codebuild.BatchGetBuildsInput) (*codebuild.BatchGetBuildsOutput, error) {
+					project := strings.Split(aws.StringValue(input.Ids[0]), ":")[0]
+					return &codebuild.BatchGetBuildsOutput{Builds: []*codebuild.Build{{
+						Id:          input.Ids[0],
+						ProjectName: aws.String(project),
+						BuildStatus: aws.String(statuses[project]),
+					}}}, nil
+				}).
+				AnyTimes()
+
+			var b bytes.Buffer
+			err := cmd.DisplayOverview(client, cmd.OverviewOptions{Filter: tc.filter, Watch: tc.watch, FailOnRed: true}, &b)
+
+			if tc.err == "" && err != nil {
+				t.Fatalf("expected no error; got %v", err)
+			}
+
+			if tc.err != "" && (err == nil || err.Error() != tc.err) {
+				t.Fatalf("expected err to be %s; got %v", tc.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/codebuild"
+	"github.com/benmatselby/knope/client"
+	"github.com/benmatselby/knope/config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// buildPhases are the phases we report on, in the order CodeBuild runs them
+var buildPhases = []string{
+	codebuild.BuildPhaseTypeProvisioning,
+	codebuild.BuildPhaseTypeDownloadSource,
+	codebuild.BuildPhaseTypeInstall,
+	codebuild.BuildPhaseTypePreBuild,
+	codebuild.BuildPhaseTypeBuild,
+	codebuild.BuildPhaseTypePostBuild,
+	codebuild.BuildPhaseTypeUploadArtifacts,
+}
+
+// PhasesOptions defines what arguments/options the user can provide
+type PhasesOptions struct {
+	Args      []string
+	Projects  []string
+	Filter    string
+	Since     string
+	Threshold float64
+	Output    string
+	Template  string
+	JSONPath  string
+}
+
+// NewPhasesCommand creates a new `phases` command
+func NewPhasesCommand(client client.API) *cobra.Command {
+	var opts PhasesOptions
+
+	cmd := &cobra.Command{
+		Use:   "phases",
+		Short: "Show how long each build phase takes and whether it is getting slower",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Args = args
+			if !cmd.Flags().Changed("filter") {
+				opts.Filter = viper.GetString(config.KeyFilter)
+			}
+			opts.Output = viper.GetString("output")
+			opts.Template = viper.GetString("template")
+			opts.JSONPath = viper.GetString("jsonpath")
+			return DisplayPhases(client, opts, os.Stdout)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringSliceVar(&opts.Projects, "project", nil, "Projects to report on, defaults to every project matching --filter")
+	flags.StringVar(&opts.Filter, "filter", ".*", "Regex to filter the projects reported on")
+	flags.StringVar(&opts.Since, "since", "14d", "How far back to look, e.g. 14d, 2w, 12h or 2020-10-01")
+	flags.Float64Var(&opts.Threshold, "threshold", 20, "Flag phases whose mean duration rose by more than this percentage")
+
+	return cmd
+}
+
+// DisplayPhases will render the duration of each phase of the builds for
+// each project since a point in time
+func DisplayPhases(api client.API, opts PhasesOptions, w io.Writer) error {
+	since, err := parseSince(opts.Since, time.Now())
+	if err != nil {
+		return err
+	}
+
+	projects, err := windowProjects(api, opts.Projects, opts.Filter)
+	if err != nil {
+		return err
+	}
+
+	window, err := fetchWindow(api, projects, since)
+	if err != nil {
+		return err
+	}
+
+	sort.Strings(projects)
+
+	records := []PhaseStatsRecord{}
+	for _, project := range projects {
+		records = append(records, newPhaseStatsRecords(project, window[project], opts.Threshold/100)...)
+	}
+
+	return render(w, renderOptions{format: opts.Output, template: opts.Template, jsonpath: opts.JSONPath}, records, phasesTable)
+}
+
+// newPhaseStatsRecords works out the statistics for each phase of the builds,
+// which are newest first. The trend compares the mean of the older half of
+// the builds with the mean of the newer half.
+func newPhaseStatsRecords(project string, builds []*codebuild.Build, threshold float64) []PhaseStatsRecord {
+	durations := map[string][]float64{}
+	for i := len(builds) - 1; i >= 0; i-- {
+		for _, phase := range builds[i].Phases {
+			if phase.DurationInSeconds == nil {
+				continue
+			}
+			phaseType := aws.StringValue(phase.PhaseType)
+			durations[phaseType] = append(durations[phaseType], float64(aws.Int64Value(phase.DurationInSeconds)))
+		}
+	}
+
+	var records []PhaseStatsRecord
+	for _, phase := range buildPhases {
+		values := durations[phase]
+		if len(values) == 0 {
+			continue
+		}
+
+		record := PhaseStatsRecord{
+			Project:             project,
+			Phase:               phase,
+			Builds:              len(values),
+			MeanDurationSeconds: mean(values),
+			P95DurationSeconds:  percentile(values, 95),
+		}
+
+		if len(values) > 1 {
+			record.EarlierMeanSeconds = mean(values[:len(values)/2])
+			record.RecentMeanSeconds = mean(values[len(values)/2:])
+			if record.EarlierMeanSeconds > 0 {
+				record.Trend = (record.RecentMeanSeconds - record.EarlierMeanSeconds) / record.EarlierMeanSeconds
+			}
+			record.Slower = record.Trend > threshold
+		}
+
+		records = append(records, record)
+	}
+
+	return records
+}
+
+// PhaseStatsRecord gives us a struct to store the statistics for a phase of a project
+type PhaseStatsRecord struct {
+	Project             string  `json:"project" yaml:"project"`
+	Phase               string  `json:"phase" yaml:"phase"`
+	Builds              int     `json:"builds" yaml:"builds"`
+	MeanDurationSeconds float64 `json:"mean_duration_seconds" yaml:"mean_duration_seconds"`
+	P95DurationSeconds  float64 `json:"p95_duration_seconds" yaml:"p95_duration_seconds"`
+	EarlierMeanSeconds  float64 `json:"earlier_mean_seconds" yaml:"earlier_mean_seconds"`
+	RecentMeanSeconds   float64 `json:"recent_mean_seconds" yaml:"recent_mean_seconds"`
+	Trend               float64 `json:"trend" yaml:"trend"`
+	Slower              bool    `json:"slower" yaml:"slower"`
+}
+
+// phaseStatsField adapts a PhaseStatsRecord function for use as a column value
+func phaseStatsField(f func(r PhaseStatsRecord) string) func(record interface{}) string {
+	return func(record interface{}) string {
+		return f(record.(PhaseStatsRecord))
+	}
+}
+
+var phasesTable = table{columns: []column{
+	{name: "project", header: "Name", value: phaseStatsField(func(r PhaseStatsRecord) string { return r.Project })},
+	{name: "phase", header: "Phase", value: phaseStatsField(func(r PhaseStatsRecord) string { return r.Phase })},
+	{name: "builds", header: "Builds", value: phaseStatsField(func(r PhaseStatsRecord) string { return strconv.Itoa(r.Builds) })},
+	phaseSecondsColumn("mean_duration_seconds", "Mean", func(r PhaseStatsRecord) float64 { return r.MeanDurationSeconds }),
+	phaseSecondsColumn("p95_duration_seconds", "p95", func(r PhaseStatsRecord) float64 { return r.P95DurationSeconds }),
+	phaseSecondsColumn("earlier_mean_seconds", "Earlier", func(r PhaseStatsRecord) float64 { return r.EarlierMeanSeconds }),
+	phaseSecondsColumn("recent_mean_seconds", "Recent", func(r PhaseStatsRecord) float64 { return r.RecentMeanSeconds }),
+	{
+		name:   "trend",
+		header: "Trend",
+		value:  phaseStatsField(func(r PhaseStatsRecord) string { return formatTrend(r.Trend) }),
+		raw:    phaseStatsField(func(r PhaseStatsRecord) string { return strconv.FormatFloat(r.Trend, 'f', 4, 64) }),
+	},
+	{
+		name:   "slower",
+		header: "Slower",
+		value: phaseStatsField(func(r PhaseStatsRecord) string {
+			if r.Slower {
+				return "yes"
+			}
+			return "-"
+		}),
+		raw: phaseStatsField(func(r PhaseStatsRecord) string { return strconv.FormatBool(r.Slower) }),
+	},
+}}
+
+// phaseSecondsColumn shows a number of seconds as a duration, or as is for machines
+func phaseSecondsColumn(name, header string, f func(r PhaseStatsRecord) float64) column {
+	return column{
+		name:   name,
+		header: header,
+		value:  phaseStatsField(func(r PhaseStatsRecord) string { return formatSeconds(f(r)) }),
+		raw:    phaseStatsField(func(r PhaseStatsRecord) string { return strconv.FormatFloat(f(r), 'f', 1, 64) }),
+	}
+}
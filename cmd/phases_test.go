@@ -0,0 +1,145 @@
+package cmd_test
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/codebuild"
+	"github.com/benmatselby/knope/client"
+	"github.com/benmatselby/knope/cmd"
+	"github.com/golang/mock/gomock"
+)
+
+func TestNewPhasesCommand(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := client.NewMockAPI(ctrl)
+
+	cmd := cmd.NewPhasesCommand(client)
+
+	use := "phases"
+	short := "Show how long each build phase takes and whether it is getting slower"
+
+	if cmd.Use != use {
+		t.Fatalf("expected use: %s; got %s", use, cmd.Use)
+	}
+
+	if cmd.Short != short {
+		t.Fatalf("expected use: %s; got %s", short, cmd.Short)
+	}
+}
+
+func TestDisplayPhases(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2019, time.July, d, 9, 0, 0, 0, time.UTC) }
+	phases := func(provisioning, build int64) []*codebuild.BuildPhase {
+		return []*codebuild.BuildPhase{
+			{PhaseType: aws.String("SUBMITTED"), DurationInSeconds: aws.Int64(1)},
+			{PhaseType: aws.String("PROVISIONING"), DurationInSeconds: aws.Int64(provisioning)},
+			{PhaseType: aws.String("BUILD"), DurationInSeconds: aws.Int64(build)},
+			{PhaseType: aws.String("COMPLETED")},
+		}
+	}
+
+	builds := []testWindowBuild{
+		{Status: "IN_PROGRESS", Start: day(21), Phases: []*codebuild.BuildPhase{{PhaseType: aws.String("PROVISIONING"), DurationInSeconds: aws.Int64(30)}, {PhaseType: aws.String("BUILD")}}},
+		{Status: "SUCCEEDED", Start: day(20), Phases: phases(30, 300)},
+		{Status: "FAILED", Start: day(19), Phases: phases(30, 280)},
+		{Status: "SUCCEEDED", Start: day(18), Phases: phases(30, 200)},
+		{Status: "SUCCEEDED", Start: day(17), Phases: phases(30, 200)},
+		{Status: "SUCCEEDED", Start: time.Date(2019, time.June, 1, 9, 0, 0, 0, time.UTC), Phases: phases(600, 600)},
+	}
+
+	tt := []struct {
+		name     string
+		opts     cmd.PhasesOptions
+		listErr  error
+		expected string
+		err      string
+	}{
+		{
+			name: "can report the duration of each phase",
+			opts: cmd.PhasesOptions{Projects: []string{"project-one"}, Since: "2019-07-01", Threshold: 20},
+			expected: `Name        Phase        Builds Mean p95  Earlier Recent Trend  Slower
+project-one PROVISIONING 5      30s  30s  30s     30s    +0.0%  -
+project-one BUILD        4      4m5s 5m0s 3m20s   4m50s  +45.0% yes
+`,
+		},
+		{
+			name: "only flags phases which got slower than the threshold",
+			opts: cmd.PhasesOptions{Projects: []string{"project-one"}, Since: "2019-07-01", Threshold: 50},
+			expected: `Name        Phase        Builds Mean p95  Earlier Recent Trend  Slower
+project-one PROVISIONING 5      30s  30s  30s     30s    +0.0%  -
+project-one BUILD        4      4m5s 5m0s 3m20s   4m50s  +45.0% -
+`,
+		},
+		{
+			name: "can report the phases as csv",
+			opts: cmd.PhasesOptions{Filter: "one", Since: "2019-07-19", Threshold: 20, Output: "csv"},
+			expected: `project,phase,builds,mean_duration_seconds,p95_duration_seconds,earlier_mean_seconds,recent_mean_seconds,trend,slower
+project-one,PROVISIONING,3,30.0,30.0,30.0,30.0,0.0000,false
+project-one,BUILD,2,290.0,300.0,280.0,300.0,0.0714,false
+`,
+		},
+		{
+			name:    "returns the error from listing builds",
+			opts:    cmd.PhasesOptions{Projects: []string{"project-one"}, Since: "14d"},
+			listErr: errors.New("there was an error"),
+			err:     "unable to get the builds for project-one: there was an error",
+		},
+		{
+			name: "tells you when since is not understood",
+			opts: cmd.PhasesOptions{Projects: []string{"project-one"}, Since: "last week"},
+			err:  `invalid --since "last week", expected something like 30d, 2w, 12h or 2020-10-01`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			client := client.NewMockAPI(ctrl)
+
+			ids, out := testWindowBuilds("project-one", builds)
+
+			client.
+				EXPECT().
+				ListProjects(gomock.Any()).
+				Return(&codebuild.ListProjectsOutput{Projects: aws.StringSlice([]string{"project-one", "project-two"})}, nil).
+				AnyTimes()
+
+			client.
+				EXPECT().
+				ListBuildsForProject(gomock.Any()).
+				Return(&codebuild.ListBuildsForProjectOutput{Ids: ids}, tc.listErr).
+				AnyTimes()
+
+			client.
+				EXPECT().
+				BatchGetBuilds(gomock.Any()).
+				Return(&codebuild.BatchGetBuildsOutput{Builds: out}, nil).
+				AnyTimes()
+
+			var b bytes.Buffer
+			writer := bufio.NewWriter(&b)
+
+			err := cmd.DisplayPhases(client, tc.opts, writer)
+			writer.Flush()
+
+			if b.String() != tc.expected {
+				t.Fatalf("expected '%s'; got '%s'", tc.expected, b.String())
+			}
+
+			if tc.err == "" && err != nil {
+				t.Fatalf("expected no error; got %v", err)
+			}
+
+			if tc.err != "" && (err == nil || err.Error() != tc.err) {
+				t.Fatalf("expected err to be %s; got %v", tc.err, err)
+			}
+		})
+	}
+}
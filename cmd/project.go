@@ -0,0 +1,920 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/codebuild"
+	"github.com/benmatselby/knope/client"
+	"github.com/benmatselby/knope/ui"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// ProjectOptions defines what arguments/options the user can provide
+type ProjectOptions struct {
+	Args     []string
+	All      bool
+	File     string
+	Yes      bool
+	Output   string
+	Template string
+	JSONPath string
+}
+
+// projectIdentitySettings always differ between two projects, so are left
+// out of a diff unless every setting is asked for
+var projectIdentitySettings = map[string]bool{
+	"Name":          true,
+	"ARN":           true,
+	"Created":       true,
+	"Last modified": true,
+}
+
+// clearableProjectSettings are the settings of a definition which can be
+// removed by sending them empty, as UpdateProject keeps anything left out
+var clearableProjectSettings = map[string]bool{
+	"badgeEnabled":            true,
+	"description":             true,
+	"fileSystemLocations":     true,
+	"secondaryArtifacts":      true,
+	"secondarySources":        true,
+	"secondarySourceVersions": true,
+	"tags":                    true,
+}
+
+// NewProjectCommand creates a new `project` command
+func NewProjectCommand(client client.API) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "project",
+		Short: "Describe, compare, export and apply the configuration of projects",
+	}
+
+	cmd.AddCommand(
+		newProjectDescribeCommand(client),
+		newProjectDiffCommand(client),
+		newProjectExportCommand(client),
+		newProjectApplyCommand(client),
+	)
+
+	return cmd
+}
+
+func newProjectDescribeCommand(client client.API) *cobra.Command {
+	var opts ProjectOptions
+
+	return &cobra.Command{
+		Use:   "describe PROJECT",
+		Short: "Show the configuration of a project",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Args = args
+			opts.Output = viper.GetString("output")
+			opts.Template = viper.GetString("template")
+			opts.JSONPath = viper.GetString("jsonpath")
+			return DescribeProject(client, opts, os.Stdout)
+		},
+	}
+}
+
+func newProjectDiffCommand(client client.API) *cobra.Command {
+	var opts ProjectOptions
+
+	cmd := &cobra.Command{
+		Use:   "diff PROJECT PROJECT",
+		Short: "Compare the configuration of two projects, setting by setting",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Args = args
+			opts.Output = viper.GetString("output")
+			opts.Template = viper.GetString("template")
+			opts.JSONPath = viper.GetString("jsonpath")
+			return DiffProjects(client, opts, os.Stdout)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.BoolVar(&opts.All, "all", false, "Show every setting, not just those that differ")
+
+	return cmd
+}
+
+func newProjectExportCommand(client client.API) *cobra.Command {
+	var opts ProjectOptions
+
+	return &cobra.Command{
+		Use:   "export PROJECT",
+		Short: "Export the definition of a project as YAML, or JSON with --output json",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Args = args
+			opts.Output = viper.GetString("output")
+			if opts.Output == OutputTable && !cmd.Flags().Changed("output") {
+				// A definition is YAML unless asked otherwise, not a table
+				opts.Output = OutputYAML
+			}
+			return ExportProject(client, opts, os.Stdout)
+		},
+	}
+}
+
+func newProjectApplyCommand(client client.API) *cobra.Command {
+	var opts ProjectOptions
+
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Create or update a project from an exported definition",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Args = args
+			return ApplyProject(client, opts, os.Stdin, os.Stdout)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVarP(&opts.File, "file", "f", "", "Path to the YAML or JSON project definition")
+	flags.BoolVarP(&opts.Yes, "yes", "y", false, "Do not ask for confirmation")
+
+	return cmd
+}
+
+// DescribeProject will render the configuration of a project
+func DescribeProject(api client.API, opts ProjectOptions, w io.Writer) error {
+	if len(opts.Args) == 0 {
+		return fmt.Errorf("please specify a project")
+	}
+
+	projects, err := getProjects(api, opts.Args[:1])
+	if err != nil {
+		return err
+	}
+
+	return render(w, renderOptions{format: opts.Output, template: opts.Template, jsonpath: opts.JSONPath}, newProjectSettingRecords(projects[0]), projectSettingsTable)
+}
+
+// DiffProjects will render the settings that differ between two projects
+func DiffProjects(api client.API, opts ProjectOptions, w io.Writer) error {
+	if len(opts.Args) != 2 {
+		return fmt.Errorf("please specify two projects to compare")
+	}
+
+	projects, err := getProjects(api, opts.Args)
+	if err != nil {
+		return err
+	}
+
+	diffs := diffProjectSettings(newProjectSettingRecords(projects[0]), newProjectSettingRecords(projects[1]), opts.All)
+
+	tabular := opts.Output == "" || opts.Output == OutputTable || opts.Output == OutputWide
+	if len(diffs) == 0 && tabular && opts.Template == "" && opts.JSONPath == "" {
+		fmt.Fprintf(w, "%s and %s have the same configuration\n", opts.Args[0], opts.Args[1])
+		return nil
+	}
+
+	return render(w, renderOptions{format: opts.Output, template: opts.Template, jsonpath: opts.JSONPath}, diffs, projectDiffTable(opts.Args[0], opts.Args[1]))
+}
+
+// diffProjectSettings pairs up the settings of two projects. Settings only
+// one project has, such as an environment variable, come after the left
+// project's settings.
+func diffProjectSettings(left, right []ProjectSettingRecord, all bool) []ProjectDiffRecord {
+	leftValues := map[string]string{}
+	rightValues := map[string]string{}
+	var order []string
+	for _, setting := range left {
+		leftValues[setting.Setting] = setting.Value
+		order = append(order, setting.Setting)
+	}
+	for _, setting := range right {
+		if _, ok := leftValues[setting.Setting]; !ok {
+			order = append(order, setting.Setting)
+		}
+		rightValues[setting.Setting] = setting.Value
+	}
+
+	diffs := []ProjectDiffRecord{}
+	for _, setting := range order {
+		if !all && (projectIdentitySettings[setting] || leftValues[setting] == rightValues[setting]) {
+			continue
+		}
+		diffs = append(diffs, ProjectDiffRecord{Setting: setting, Left: leftValues[setting], Right: rightValues[setting]})
+	}
+
+	return diffs
+}
+
+// getProjects gets the named projects, in the order asked for
+func getProjects(api client.API, names []string) ([]*codebuild.Project, error) {
+	projects, err := client.GetProjects(api, aws.StringSlice(names))
+	if err != nil {
+		return nil, err
+	}
+
+	found := map[string]*codebuild.Project{}
+	for _, project := range projects {
+		found[aws.StringValue(project.Name)] = project
+	}
+
+	var ordered []*codebuild.Project
+	for _, name := range names {
+		project, ok := found[name]
+		if !ok {
+			return nil, fmt.Errorf("unable to find project %s", name)
+		}
+		ordered = append(ordered, project)
+	}
+
+	return ordered, nil
+}
+
+// ExportProject will render the definition of a project, in the shape
+// CreateProject and UpdateProject take, so it can be kept in version control
+// and applied later. Webhooks are managed separately, so are not included.
+func ExportProject(api client.API, opts ProjectOptions, w io.Writer) error {
+	if len(opts.Args) == 0 {
+		return fmt.Errorf("please specify a project")
+	}
+
+	switch opts.Output {
+	case "", OutputYAML, OutputJSON:
+	default:
+		return fmt.Errorf("unable to export a project as %s, expected %s or %s", opts.Output, OutputYAML, OutputJSON)
+	}
+
+	projects, err := getProjects(api, opts.Args[:1])
+	if err != nil {
+		return err
+	}
+
+	out, err := marshalProjectDocument(newProjectDocument(projects[0]), opts.Output)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(out)
+	return err
+}
+
+// ApplyProject will create or update a project from its definition. It
+// shows what will change and asks before changing anything.
+func ApplyProject(api client.API, opts ProjectOptions, r io.Reader, w io.Writer) error {
+	if opts.File == "" {
+		return fmt.Errorf("please specify a project definition with --file")
+	}
+
+	data, err := ioutil.ReadFile(opts.File)
+	if err != nil {
+		return err
+	}
+
+	desired, err := unmarshalProjectDocument(data)
+	if err != nil {
+		return fmt.Errorf("unable to read %s: %v", opts.File, err)
+	}
+	name := aws.StringValue(desired.Name)
+
+	existing, err := client.GetProjects(api, []*string{desired.Name})
+	if err != nil {
+		return err
+	}
+
+	if len(existing) == 0 {
+		fmt.Fprintf(w, "Project %s does not exist and will be created\n\n", name)
+		var settings []ProjectSettingRecord
+		for _, setting := range newProjectSettingRecords(newProjectFromDocument(desired)) {
+			if setting.Value != "" {
+				settings = append(settings, setting)
+			}
+		}
+
+		if err := render(w, renderOptions{format: OutputTable}, settings, projectSettingsTable); err != nil {
+			return err
+		}
+		fmt.Fprintln(w)
+
+		if !opts.Yes && !confirm(r, w, fmt.Sprintf("Create project %s?", name)) {
+			return nil
+		}
+
+		if _, err := api.CreateProject(desired); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(w, "%s Created project %s\n", ui.AppSuccess, name)
+		return nil
+	}
+
+	current := newProjectDocument(existing[0])
+	same, err := sameProjectDocuments(current, desired)
+	if err != nil {
+		return err
+	}
+	if same {
+		fmt.Fprintf(w, "Project %s is up to date\n", name)
+		return nil
+	}
+
+	update, err := newProjectUpdate(current, desired)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Project %s will be updated\n\n", name)
+	diffs := diffProjectSettings(newProjectSettingRecords(newProjectFromDocument(current)), newProjectSettingRecords(newProjectFromDocument(desired)), false)
+	if len(diffs) == 0 {
+		fmt.Fprintln(w, "Only settings knope does not show will change, see the definition for details")
+	} else if err := render(w, renderOptions{format: OutputTable}, diffs, projectDiffTable("Current", "Desired")); err != nil {
+		return err
+	}
+	fmt.Fprintln(w)
+
+	if !opts.Yes && !confirm(r, w, fmt.Sprintf("Update project %s?", name)) {
+		return nil
+	}
+
+	if _, err := api.UpdateProject(update); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "%s Updated project %s\n", ui.AppSuccess, name)
+	return nil
+}
+
+// newProjectDocument keeps the parts of a project that can be set with
+// CreateProject and UpdateProject, leaving out what AWS works out itself
+func newProjectDocument(project *codebuild.Project) *codebuild.CreateProjectInput {
+	doc := &codebuild.CreateProjectInput{
+		Name:                    project.Name,
+		Description:             project.Description,
+		Source:                  project.Source,
+		SecondarySources:        project.SecondarySources,
+		SourceVersion:           project.SourceVersion,
+		SecondarySourceVersions: project.SecondarySourceVersions,
+		Artifacts:               project.Artifacts,
+		SecondaryArtifacts:      project.SecondaryArtifacts,
+		Cache:                   project.Cache,
+		Environment:             project.Environment,
+		ServiceRole:             project.ServiceRole,
+		TimeoutInMinutes:        project.TimeoutInMinutes,
+		QueuedTimeoutInMinutes:  project.QueuedTimeoutInMinutes,
+		EncryptionKey:           project.EncryptionKey,
+		Tags:                    project.Tags,
+		VpcConfig:               project.VpcConfig,
+		LogsConfig:              project.LogsConfig,
+		FileSystemLocations:     project.FileSystemLocations,
+		BuildBatchConfig:        project.BuildBatchConfig,
+	}
+
+	if project.Badge != nil {
+		doc.BadgeEnabled = project.Badge.BadgeEnabled
+	}
+
+	return doc
+}
+
+// newProjectFromDocument turns a definition back into a project, so it can
+// be shown and compared like one
+func newProjectFromDocument(doc *codebuild.CreateProjectInput) *codebuild.Project {
+	project := &codebuild.Project{
+		Name:                    doc.Name,
+		Description:             doc.Description,
+		Source:                  doc.Source,
+		SecondarySources:        doc.SecondarySources,
+		SourceVersion:           doc.SourceVersion,
+		SecondarySourceVersions: doc.SecondarySourceVersions,
+		Artifacts:               doc.Artifacts,
+		SecondaryArtifacts:      doc.SecondaryArtifacts,
+		Cache:                   doc.Cache,
+		Environment:             doc.Environment,
+		ServiceRole:             doc.ServiceRole,
+		TimeoutInMinutes:        doc.TimeoutInMinutes,
+		QueuedTimeoutInMinutes:  doc.QueuedTimeoutInMinutes,
+		EncryptionKey:           doc.EncryptionKey,
+		Tags:                    doc.Tags,
+		VpcConfig:               doc.VpcConfig,
+		LogsConfig:              doc.LogsConfig,
+		FileSystemLocations:     doc.FileSystemLocations,
+		BuildBatchConfig:        doc.BuildBatchConfig,
+	}
+
+	if doc.BadgeEnabled != nil {
+		project.Badge = &codebuild.ProjectBadge{BadgeEnabled: doc.BadgeEnabled}
+	}
+
+	return project
+}
+
+// newProjectUpdate works out the update which turns the current definition
+// into the desired one. UpdateProject keeps any setting it is not given, so
+// settings removed from the definition are sent empty, and removing those
+// which cannot be sent empty is refused.
+func newProjectUpdate(current, desired *codebuild.CreateProjectInput) (*codebuild.UpdateProjectInput, error) {
+	left, err := apiFields(current)
+	if err != nil {
+		return nil, err
+	}
+
+	right, err := apiFields(desired)
+	if err != nil {
+		return nil, err
+	}
+
+	currentSettings, _ := withoutEmpty(left).(map[string]interface{})
+	desiredSettings, _ := withoutEmpty(right).(map[string]interface{})
+
+	var kept []string
+	for setting := range currentSettings {
+		if _, ok := desiredSettings[setting]; !ok && !clearableProjectSettings[setting] {
+			kept = append(kept, setting)
+		}
+	}
+	if len(kept) > 0 {
+		sort.Strings(kept)
+		return nil, fmt.Errorf("unable to remove %s from project %s, as CodeBuild keeps settings an update leaves out, so please give them a value instead", strings.Join(kept, ", "), aws.StringValue(desired.Name))
+	}
+
+	update := &codebuild.UpdateProjectInput{}
+	if err := copyProjectDocument(desired, update); err != nil {
+		return nil, err
+	}
+
+	if update.Description == nil && current.Description != nil {
+		update.Description = aws.String("")
+	}
+	if update.BadgeEnabled == nil && current.BadgeEnabled != nil {
+		update.BadgeEnabled = aws.Bool(false)
+	}
+	if len(update.SecondarySources) == 0 && len(current.SecondarySources) > 0 {
+		update.SecondarySources = []*codebuild.ProjectSource{}
+	}
+	if len(update.SecondarySourceVersions) == 0 && len(current.SecondarySourceVersions) > 0 {
+		update.SecondarySourceVersions = []*codebuild.ProjectSourceVersion{}
+	}
+	if len(update.SecondaryArtifacts) == 0 && len(current.SecondaryArtifacts) > 0 {
+		update.SecondaryArtifacts = []*codebuild.ProjectArtifacts{}
+	}
+	if len(update.FileSystemLocations) == 0 && len(current.FileSystemLocations) > 0 {
+		update.FileSystemLocations = []*codebuild.ProjectFileSystemLocation{}
+	}
+	if len(update.Tags) == 0 && len(current.Tags) > 0 {
+		update.Tags = []*codebuild.Tag{}
+	}
+
+	// The environment is replaced as a whole, apart from its variables
+	if update.Environment != nil && current.Environment != nil &&
+		len(update.Environment.EnvironmentVariables) == 0 && len(current.Environment.EnvironmentVariables) > 0 {
+		update.Environment.EnvironmentVariables = []*codebuild.EnvironmentVariable{}
+	}
+
+	return update, nil
+}
+
+// copyProjectDocument copies a definition into another CodeBuild type with
+// the same fields, such as UpdateProjectInput
+func copyProjectDocument(doc *codebuild.CreateProjectInput, v interface{}) error {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// sameProjectDocuments is whether applying one definition over the other
+// would change nothing. Empty settings are the same as those left out, as
+// that is how removed settings come back once they are applied.
+func sameProjectDocuments(a, b *codebuild.CreateProjectInput) (bool, error) {
+	left, err := apiFields(a)
+	if err != nil {
+		return false, err
+	}
+
+	right, err := apiFields(b)
+	if err != nil {
+		return false, err
+	}
+
+	return reflect.DeepEqual(withoutEmpty(left), withoutEmpty(right)), nil
+}
+
+// withoutEmpty drops empty strings, lists and maps, and false, from the maps
+// apiFields gives us
+func withoutEmpty(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		converted := map[string]interface{}{}
+		for key, item := range v {
+			if item = withoutEmpty(item); item != nil {
+				converted[key] = item
+			}
+		}
+		if len(converted) == 0 {
+			return nil
+		}
+		return converted
+	case []interface{}:
+		if len(v) == 0 {
+			return nil
+		}
+		converted := make([]interface{}, len(v))
+		for i, item := range v {
+			converted[i] = withoutEmpty(item)
+		}
+		return converted
+	case string:
+		if v == "" {
+			return nil
+		}
+		return v
+	case bool:
+		if !v {
+			return nil
+		}
+		return v
+	default:
+		return value
+	}
+}
+
+// marshalProjectDocument renders a definition using the same field names as
+// the CodeBuild API, as JSON or YAML. The keys are sorted, so the same
+// project always exports the same way.
+func marshalProjectDocument(doc *codebuild.CreateProjectInput, format string) ([]byte, error) {
+	fields, err := apiFields(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	if format == OutputJSON {
+		var out bytes.Buffer
+		encoder := json.NewEncoder(&out)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(fields); err != nil {
+			return nil, err
+		}
+		return out.Bytes(), nil
+	}
+
+	return yaml.Marshal(fields)
+}
+
+// apiFields turns a CodeBuild type into maps keyed by the field names of the
+// CodeBuild API. The Go field names are the API names with the first letter
+// upper cased, and fields which are not set are left out, as the API does.
+func apiFields(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, err
+	}
+
+	return apiKeys(generic), nil
+}
+
+// apiKeys lower cases the first letter of each key, and drops null values
+func apiKeys(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		converted := map[string]interface{}{}
+		for key, item := range v {
+			if item == nil || key == "" {
+				continue
+			}
+			converted[strings.ToLower(key[:1])+key[1:]] = apiKeys(item)
+		}
+		return converted
+	case []interface{}:
+		for i, item := range v {
+			v[i] = apiKeys(item)
+		}
+		return v
+	default:
+		return value
+	}
+}
+
+// unmarshalProjectDocument reads a definition written by export. JSON is
+// valid YAML, so either can be given.
+func unmarshalProjectDocument(data []byte) (*codebuild.CreateProjectInput, error) {
+	var generic interface{}
+	if err := yaml.Unmarshal(data, &generic); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(stringKeys(generic))
+	if err != nil {
+		return nil, err
+	}
+
+	// Field names are matched without regard to case, so the API names work
+	doc := &codebuild.CreateProjectInput{}
+	if err := json.Unmarshal(data, doc); err != nil {
+		return nil, err
+	}
+
+	if aws.StringValue(doc.Name) == "" {
+		return nil, fmt.Errorf("the project definition has no name")
+	}
+
+	return doc, nil
+}
+
+// stringKeys converts the maps YAML gives us, which can have keys of any
+// type, into maps JSON can encode
+func stringKeys(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		converted := map[string]interface{}{}
+		for key, item := range v {
+			converted[fmt.Sprint(key)] = stringKeys(item)
+		}
+		return converted
+	case []interface{}:
+		for i, item := range v {
+			v[i] = stringKeys(item)
+		}
+		return v
+	default:
+		return value
+	}
+}
+
+// ProjectSettingRecord gives us a struct to store one setting of a project
+type ProjectSettingRecord struct {
+	Setting string `json:"setting" yaml:"setting"`
+	Value   string `json:"value" yaml:"value"`
+}
+
+// ProjectDiffRecord gives us a struct to store a setting that differs between two projects
+type ProjectDiffRecord struct {
+	Setting string `json:"setting" yaml:"setting"`
+	Left    string `json:"left" yaml:"left"`
+	Right   string `json:"right" yaml:"right"`
+}
+
+// newProjectSettingRecords flattens a project into one record per setting,
+// so two projects can be compared setting by setting. Environment variables
+// and tags get a setting each.
+func newProjectSettingRecords(project *codebuild.Project) []ProjectSettingRecord {
+	var records []ProjectSettingRecord
+	add := func(setting, value string) {
+		records = append(records, ProjectSettingRecord{Setting: setting, Value: value})
+	}
+
+	add("Name", aws.StringValue(project.Name))
+	add("ARN", aws.StringValue(project.Arn))
+	add("Description", aws.StringValue(project.Description))
+	add("Created", formatTime(project.Created))
+	add("Last modified", formatTime(project.LastModified))
+
+	source := project.Source
+	if source == nil {
+		source = &codebuild.ProjectSource{}
+	}
+	add("Source", strings.TrimSpace(aws.StringValue(source.Type)+" "+aws.StringValue(source.Location)))
+	add("Source version", aws.StringValue(project.SourceVersion))
+	add("Buildspec", formatBuildspec(aws.StringValue(source.Buildspec)))
+	add("Git clone depth", formatInt64(source.GitCloneDepth))
+	add("Report build status", formatBool(source.ReportBuildStatus))
+
+	var secondary []string
+	for _, s := range project.SecondarySources {
+		secondary = append(secondary, fmt.Sprintf("%s: %s %s", aws.StringValue(s.SourceIdentifier), aws.StringValue(s.Type), aws.StringValue(s.Location)))
+	}
+	add("Secondary sources", strings.Join(secondary, ", "))
+
+	environment := project.Environment
+	if environment == nil {
+		environment = &codebuild.ProjectEnvironment{}
+	}
+	add("Image", aws.StringValue(environment.Image))
+	add("Compute type", aws.StringValue(environment.ComputeType))
+	add("Environment type", aws.StringValue(environment.Type))
+	add("Privileged mode", formatBool(environment.PrivilegedMode))
+	add("Image pull credentials", aws.StringValue(environment.ImagePullCredentialsType))
+	for _, variable := range environment.EnvironmentVariables {
+		value := aws.StringValue(variable.Value)
+		if kind := aws.StringValue(variable.Type); kind != "" && kind != codebuild.EnvironmentVariableTypePlaintext {
+			value = kind + ":" + value
+		}
+		add("Environment variable "+aws.StringValue(variable.Name), value)
+	}
+
+	add("Service role", aws.StringValue(project.ServiceRole))
+
+	vpc := project.VpcConfig
+	if vpc == nil {
+		vpc = &codebuild.VpcConfig{}
+	}
+	add("VPC", aws.StringValue(vpc.VpcId))
+	add("Subnets", strings.Join(aws.StringValueSlice(vpc.Subnets), ", "))
+	add("Security groups", strings.Join(aws.StringValueSlice(vpc.SecurityGroupIds), ", "))
+
+	cache := ""
+	if project.Cache != nil {
+		cache = strings.TrimSpace(aws.StringValue(project.Cache.Type) + " " + aws.StringValue(project.Cache.Location))
+		if len(project.Cache.Modes) > 0 {
+			cache += " (" + strings.Join(aws.StringValueSlice(project.Cache.Modes), ", ") + ")"
+		}
+	}
+	add("Cache", cache)
+
+	artifacts := ""
+	if project.Artifacts != nil {
+		artifacts = formatArtifacts(project.Artifacts)
+	}
+	add("Artifacts", artifacts)
+
+	var secondaryArtifacts []string
+	for _, a := range project.SecondaryArtifacts {
+		secondaryArtifacts = append(secondaryArtifacts, aws.StringValue(a.ArtifactIdentifier)+": "+formatArtifacts(a))
+	}
+	add("Secondary artifacts", strings.Join(secondaryArtifacts, ", "))
+
+	add("Timeout", formatMinutes(project.TimeoutInMinutes))
+	add("Queued timeout", formatMinutes(project.QueuedTimeoutInMinutes))
+	add("Encryption key", aws.StringValue(project.EncryptionKey))
+
+	badge := ""
+	if project.Badge != nil {
+		badge = formatBool(project.Badge.BadgeEnabled)
+	}
+	add("Badge", badge)
+	add("Logs", formatLogs(project.LogsConfig))
+
+	batch := ""
+	if config := project.BuildBatchConfig; config != nil {
+		batch = strings.TrimSpace(aws.StringValue(config.ServiceRole) + " " + formatMinutes(config.TimeoutInMins))
+	}
+	add("Batch builds", batch)
+
+	var fileSystems []string
+	for _, f := range project.FileSystemLocations {
+		fileSystems = append(fileSystems, fmt.Sprintf("%s: %s %s at %s", aws.StringValue(f.Identifier), aws.StringValue(f.Type), aws.StringValue(f.Location), aws.StringValue(f.MountPoint)))
+	}
+	add("File systems", strings.Join(fileSystems, ", "))
+
+	webhook := project.Webhook
+	if webhook == nil {
+		webhook = &codebuild.Webhook{}
+	}
+	add("Webhook", aws.StringValue(webhook.Url))
+	add("Webhook build type", aws.StringValue(webhook.BuildType))
+	add("Webhook filters", formatWebhookFilters(webhook.FilterGroups))
+
+	for _, tag := range project.Tags {
+		add("Tag "+aws.StringValue(tag.Key), aws.StringValue(tag.Value))
+	}
+
+	return records
+}
+
+// formatBuildspec shows the buildspec file, or a fingerprint of an inline
+// buildspec so that two of them can be told apart
+func formatBuildspec(buildspec string) string {
+	if !strings.Contains(buildspec, "\n") {
+		return buildspec
+	}
+
+	return fmt.Sprintf("inline, %d lines, sha256 %.6x", strings.Count(strings.TrimSpace(buildspec), "\n")+1, sha256.Sum256([]byte(buildspec)))
+}
+
+// formatArtifacts shows where the artifacts of a build go
+func formatArtifacts(artifacts *codebuild.ProjectArtifacts) string {
+	location := aws.StringValue(artifacts.Location)
+	if path := aws.StringValue(artifacts.Path); path != "" {
+		location += "/" + path
+	}
+	if name := aws.StringValue(artifacts.Name); name != "" {
+		location += "/" + name
+	}
+
+	return strings.TrimSpace(aws.StringValue(artifacts.Type) + " " + location)
+}
+
+// formatLogs shows where the logs of a build go
+func formatLogs(logs *codebuild.LogsConfig) string {
+	if logs == nil {
+		return ""
+	}
+
+	var formatted []string
+	if cw := logs.CloudWatchLogs; cw != nil {
+		group := strings.Trim(aws.StringValue(cw.GroupName)+"/"+aws.StringValue(cw.StreamName), "/")
+		formatted = append(formatted, strings.TrimSpace("CloudWatch "+aws.StringValue(cw.Status)+" "+group))
+	}
+	if s3 := logs.S3Logs; s3 != nil {
+		formatted = append(formatted, strings.TrimSpace("S3 "+aws.StringValue(s3.Status)+" "+aws.StringValue(s3.Location)))
+	}
+
+	return strings.Join(formatted, ", ")
+}
+
+// formatWebhookFilters shows each filter group in brackets. A build starts
+// when every filter in any one group matches.
+func formatWebhookFilters(groups [][]*codebuild.WebhookFilter) string {
+	var formatted []string
+	for _, group := range groups {
+		var filters []string
+		for _, filter := range group {
+			op := "="
+			if aws.BoolValue(filter.ExcludeMatchedPattern) {
+				op = "!="
+			}
+			filters = append(filters, aws.StringValue(filter.Type)+op+aws.StringValue(filter.Pattern))
+		}
+		formatted = append(formatted, "["+strings.Join(filters, ", ")+"]")
+	}
+
+	return strings.Join(formatted, " ")
+}
+
+// formatMinutes shows a number of minutes as a duration, if there is one
+func formatMinutes(minutes *int64) string {
+	if minutes == nil {
+		return ""
+	}
+	return (time.Duration(*minutes) * time.Minute).String()
+}
+
+// formatInt64 shows a number, if there is one
+func formatInt64(value *int64) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatInt(*value, 10)
+}
+
+// formatBool shows a flag, if there is one
+func formatBool(value *bool) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatBool(*value)
+}
+
+// projectSettingField adapts a ProjectSettingRecord function for use as a column value
+func projectSettingField(f func(r ProjectSettingRecord) string) func(record interface{}) string {
+	return func(record interface{}) string {
+		return f(record.(ProjectSettingRecord))
+	}
+}
+
+// projectDiffField adapts a ProjectDiffRecord function for use as a column value
+func projectDiffField(f func(r ProjectDiffRecord) string) func(record interface{}) string {
+	return func(record interface{}) string {
+		return f(record.(ProjectDiffRecord))
+	}
+}
+
+var projectSettingsTable = table{hideHeaders: true, columns: []column{
+	{
+		name:   "setting",
+		header: "Setting",
+		value:  projectSettingField(func(r ProjectSettingRecord) string { return r.Setting + ":" }),
+		raw:    projectSettingField(func(r ProjectSettingRecord) string { return r.Setting }),
+	},
+	{
+		name:   "value",
+		header: "Value",
+		value:  projectSettingField(func(r ProjectSettingRecord) string { return orDash(r.Value) }),
+		raw:    projectSettingField(func(r ProjectSettingRecord) string { return r.Value }),
+	},
+}}
+
+// projectDiffTable shows the settings of two projects side by side, headed
+// by the project names
+func projectDiffTable(left, right string) table {
+	return table{columns: []column{
+		{name: "setting", header: "Setting", value: projectDiffField(func(r ProjectDiffRecord) string { return r.Setting })},
+		{
+			name:   "left",
+			header: left,
+			value:  projectDiffField(func(r ProjectDiffRecord) string { return orDash(r.Left) }),
+			raw:    projectDiffField(func(r ProjectDiffRecord) string { return r.Left }),
+		},
+		{
+			name:   "right",
+			header: right,
+			value:  projectDiffField(func(r ProjectDiffRecord) string { return orDash(r.Right) }),
+			raw:    projectDiffField(func(r ProjectDiffRecord) string { return r.Right }),
+		},
+	}}
+}
@@ -0,0 +1,777 @@
+package cmd_test
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/codebuild"
+	"github.com/benmatselby/knope/client"
+	"github.com/benmatselby/knope/cmd"
+	"github.com/golang/mock/gomock"
+)
+
+func testProject(name string) *codebuild.Project {
+	created := time.Date(2019, time.July, 19, 23, 0, 0, 0, time.UTC)
+
+	return &codebuild.Project{
+		Name:         aws.String(name),
+		Arn:          aws.String("arn:aws:codebuild:eu-west-2:123456789012:project/" + name),
+		Created:      &created,
+		LastModified: &created,
+		Source: &codebuild.ProjectSource{
+			Type:      aws.String("GITHUB"),
+			Location:  aws.String("https://github.com/benmatselby/knope.git"),
+			Buildspec: aws.String("version: 0.2\nphases:\n  build:\n    commands:\n      - make\n"),
+		},
+		SourceVersion: aws.String("main"),
+		Environment: &codebuild.ProjectEnvironment{
+			Image:          aws.String("aws/codebuild/standard:4.0"),
+			ComputeType:    aws.String("BUILD_GENERAL1_SMALL"),
+			Type:           aws.String("LINUX_CONTAINER"),
+			PrivilegedMode: aws.Bool(false),
+			EnvironmentVariables: []*codebuild.EnvironmentVariable{
+				{Name: aws.String("STAGE"), Value: aws.String("staging"), Type: aws.String("PLAINTEXT")},
+				{Name: aws.String("TOKEN"), Value: aws.String("/knope/token"), Type: aws.String("PARAMETER_STORE")},
+			},
+		},
+		ServiceRole: aws.String("arn:aws:iam::123456789012:role/codebuild"),
+		VpcConfig: &codebuild.VpcConfig{
+			VpcId:            aws.String("vpc-1"),
+			Subnets:          aws.StringSlice([]string{"subnet-1", "subnet-2"}),
+			SecurityGroupIds: aws.StringSlice([]string{"sg-1"}),
+		},
+		Cache: &codebuild.ProjectCache{
+			Type:  aws.String("LOCAL"),
+			Modes: aws.StringSlice([]string{"LOCAL_DOCKER_LAYER_CACHE"}),
+		},
+		Artifacts:              &codebuild.ProjectArtifacts{Type: aws.String("NO_ARTIFACTS")},
+		TimeoutInMinutes:       aws.Int64(60),
+		QueuedTimeoutInMinutes: aws.Int64(480),
+		Webhook: &codebuild.Webhook{
+			Url: aws.String("https://api.github.com/repos/benmatselby/knope/hooks/1"),
+			FilterGroups: [][]*codebuild.WebhookFilter{{
+				{Type: aws.String("EVENT"), Pattern: aws.String("PUSH")},
+				{Type: aws.String("HEAD_REF"), Pattern: aws.String("^refs/heads/main$")},
+			}},
+		},
+		LogsConfig: &codebuild.LogsConfig{
+			CloudWatchLogs: &codebuild.CloudWatchLogsConfig{Status: aws.String("ENABLED"), GroupName: aws.String("codebuild")},
+		},
+		Tags: []*codebuild.Tag{{Key: aws.String("team"), Value: aws.String("platform")}},
+	}
+}
+
+func TestNewProjectCommand(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := client.NewMockAPI(ctrl)
+
+	cmd := cmd.NewProjectCommand(client)
+
+	use := "project"
+	short := "Describe, compare, export and apply the configuration of projects"
+
+	if cmd.Use != use {
+		t.Fatalf("expected use: %s; got %s", use, cmd.Use)
+	}
+
+	if cmd.Short != short {
+		t.Fatalf("expected use: %s; got %s", short, cmd.Short)
+	}
+
+	if len(cmd.Commands()) != 4 {
+		t.Fatalf("expected 4 subcommands; got %d", len(cmd.Commands()))
+	}
+}
+
+func TestDescribeProject(t *testing.T) {
+	tt := []struct {
+		name     string
+		args     []string
+		getErr   error
+		expected string
+		err      string
+	}{
+		{
+			name: "can describe a project",
+			args: []string{"staging"},
+			expected: `Name:                       staging
+ARN:                        arn:aws:codebuild:eu-west-2:123456789012:project/staging
+Description:                -
+Created:                    19-07-2019 23:00
+Last modified:              19-07-2019 23:00
+Source:                     GITHUB https://github.com/benmatselby/knope.git
+Source version:             main
+Buildspec:                  inline, 5 lines, sha256 25fc2c893adf
+Git clone depth:            -
+Report build status:        -
+Secondary sources:          -
+Image:                      aws/codebuild/standard:4.0
+Compute type:               BUILD_GENERAL1_SMALL
+Environment type:           LINUX_CONTAINER
+Privileged mode:            false
+Image pull credentials:     -
+Environment variable STAGE: staging
+Environment variable TOKEN: PARAMETER_STORE:/knope/token
+Service role:               arn:aws:iam::123456789012:role/codebuild
+VPC:                        vpc-1
+Subnets:                    subnet-1, subnet-2
+Security groups:            sg-1
+Cache:                      LOCAL (LOCAL_DOCKER_LAYER_CACHE)
+Artifacts:                  NO_ARTIFACTS
+Secondary artifacts:        -
+Timeout:                    1h0m0s
+Queued timeout:             8h0m0s
+Encryption key:             -
+Badge:                      -
+Logs:                       CloudWatch ENABLED codebuild
+Batch builds:               -
+File systems:               -
+Webhook:                    https://api.github.com/repos/benmatselby/knope/hooks/1
+Webhook build type:         -
+Webhook filters:            [EVENT=PUSH, HEAD_REF=^refs/heads/main$]
+Tag team:                   platform
+`,
+		},
+		{
+			name: "returns an error if the project does not exist",
+			args: []string{"missing"},
+			err:  "unable to find project missing",
+		},
+		{
+			name:   "returns an error if we cannot get the project",
+			args:   []string{"staging"},
+			getErr: errors.New("access denied"),
+			err:    "access denied",
+		},
+		{
+			name: "returns an error if no project is given",
+			err:  "please specify a project",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			api := client.NewMockAPI(ctrl)
+
+			api.
+				EXPECT().
+				BatchGetProjects(gomock.Any()).
+				DoAndReturn(func(input *codebuild.BatchGetProjectsInput) (*codebuild.BatchGetProjectsOutput, error) {
+					if tc.getErr != nil {
+						return nil, tc.getErr
+					}
+					out := &codebuild.BatchGetProjectsOutput{}
+					for _, name := range input.Names {
+						if aws.StringValue(name) == "missing" {
+							out.ProjectsNotFound = append(out.ProjectsNotFound, name)
+							continue
+						}
+						out.Projects = append(out.Projects, testProject(aws.StringValue(name)))
+					}
+					return out, nil
+				}).
+				AnyTimes()
+
+			var b bytes.Buffer
+			writer := bufio.NewWriter(&b)
+
+			err := cmd.DescribeProject(api, cmd.ProjectOptions{Args: tc.args}, writer)
+			writer.Flush()
+
+			if tc.err == "" && err != nil {
+				t.Fatalf("expected no error; got %v", err)
+			}
+
+			if tc.err != "" && (err == nil || err.Error() != tc.err) {
+				t.Fatalf("expected err to be %s; got %v", tc.err, err)
+			}
+
+			if b.String() != tc.expected {
+				t.Fatalf("expected '%s'; got '%s'", tc.expected, b.String())
+			}
+		})
+	}
+}
+
+func TestDiffProjects(t *testing.T) {
+	tt := []struct {
+		name     string
+		args     []string
+		all      bool
+		change   func(p *codebuild.Project)
+		expected string
+		err      string
+	}{
+		{
+			name: "can show the settings that differ",
+			args: []string{"staging", "production"},
+			change: func(p *codebuild.Project) {
+				p.Environment.ComputeType = aws.String("BUILD_GENERAL1_LARGE")
+				p.Environment.EnvironmentVariables = []*codebuild.EnvironmentVariable{
+					{Name: aws.String("STAGE"), Value: aws.String("production"), Type: aws.String("PLAINTEXT")},
+					{Name: aws.String("ALERTS"), Value: aws.String("on"), Type: aws.String("PLAINTEXT")},
+				}
+				p.VpcConfig = nil
+			},
+			expected: `Setting                     staging                      production
+Compute type                BUILD_GENERAL1_SMALL         BUILD_GENERAL1_LARGE
+Environment variable STAGE  staging                      production
+Environment variable TOKEN  PARAMETER_STORE:/knope/token -
+VPC                         vpc-1                        -
+Subnets                     subnet-1, subnet-2           -
+Security groups             sg-1                         -
+Environment variable ALERTS -                            on
+`,
+		},
+		{
+			name:     "says when the projects have the same configuration",
+			args:     []string{"staging", "production"},
+			change:   func(p *codebuild.Project) {},
+			expected: "staging and production have the same configuration\n",
+		},
+		{
+			name: "returns an error if a project does not exist",
+			args: []string{"staging", "missing"},
+			err:  "unable to find project missing",
+		},
+		{
+			name: "returns an error without two projects",
+			args: []string{"staging"},
+			err:  "please specify two projects to compare",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			api := client.NewMockAPI(ctrl)
+
+			api.
+				EXPECT().
+				BatchGetProjects(gomock.Any()).
+				DoAndReturn(func(input *codebuild.BatchGetProjectsInput) (*codebuild.BatchGetProjectsOutput, error) {
+					out := &codebuild.BatchGetProjectsOutput{}
+					for _, name := range input.Names {
+						switch aws.StringValue(name) {
+						case "missing":
+							out.ProjectsNotFound = append(out.ProjectsNotFound, name)
+						case "production":
+							project := testProject("production")
+							tc.change(project)
+							out.Projects = append([]*codebuild.Project{project}, out.Projects...)
+						default:
+							out.Projects = append(out.Projects, testProject(aws.StringValue(name)))
+						}
+					}
+					return out, nil
+				}).
+				AnyTimes()
+
+			var b bytes.Buffer
+			writer := bufio.NewWriter(&b)
+
+			err := cmd.DiffProjects(api, cmd.ProjectOptions{Args: tc.args, All: tc.all}, writer)
+			writer.Flush()
+
+			if tc.err == "" && err != nil {
+				t.Fatalf("expected no error; got %v", err)
+			}
+
+			if tc.err != "" && (err == nil || err.Error() != tc.err) {
+				t.Fatalf("expected err to be %s; got %v", tc.err, err)
+			}
+
+			if b.String() != tc.expected {
+				t.Fatalf("expected '%s'; got '%s'", tc.expected, b.String())
+			}
+		})
+	}
+}
+
+// mockProjects serves the projects from BatchGetProjects, as if no others exist
+func mockProjects(api *client.MockAPI, projects ...*codebuild.Project) {
+	api.
+		EXPECT().
+		BatchGetProjects(gomock.Any()).
+		DoAndReturn(func(input *codebuild.BatchGetProjectsInput) (*codebuild.BatchGetProjectsOutput, error) {
+			out := &codebuild.BatchGetProjectsOutput{}
+			for _, name := range input.Names {
+				found := false
+				for _, project := range projects {
+					if aws.StringValue(project.Name) == aws.StringValue(name) {
+						out.Projects = append(out.Projects, project)
+						found = true
+					}
+				}
+				if !found {
+					out.ProjectsNotFound = append(out.ProjectsNotFound, name)
+				}
+			}
+			return out, nil
+		}).
+		AnyTimes()
+}
+
+func TestExportProject(t *testing.T) {
+	tt := []struct {
+		name     string
+		args     []string
+		output   string
+		expected string
+		err      string
+	}{
+		{
+			name: "can export a project as yaml",
+			args: []string{"staging"},
+			expected: `artifacts:
+  type: NO_ARTIFACTS
+cache:
+  modes:
+  - LOCAL_DOCKER_LAYER_CACHE
+  type: LOCAL
+environment:
+  computeType: BUILD_GENERAL1_SMALL
+  environmentVariables:
+  - name: STAGE
+    type: PLAINTEXT
+    value: staging
+  - name: TOKEN
+    type: PARAMETER_STORE
+    value: /knope/token
+  image: aws/codebuild/standard:4.0
+  privilegedMode: false
+  type: LINUX_CONTAINER
+logsConfig:
+  cloudWatchLogs:
+    groupName: codebuild
+    status: ENABLED
+name: staging
+queuedTimeoutInMinutes: 480
+serviceRole: arn:aws:iam::123456789012:role/codebuild
+source:
+  buildspec: |
+    version: 0.2
+    phases:
+      build:
+        commands:
+          - make
+  location: https://github.com/benmatselby/knope.git
+  type: GITHUB
+sourceVersion: main
+tags:
+- key: team
+  value: platform
+timeoutInMinutes: 60
+vpcConfig:
+  securityGroupIds:
+  - sg-1
+  subnets:
+  - subnet-1
+  - subnet-2
+  vpcId: vpc-1
+`,
+		},
+		{
+			name:   "can export a project as json",
+			args:   []string{"minimal"},
+			output: "json",
+			expected: `{
+  "artifacts": {
+    "type": "NO_ARTIFACTS"
+  },
+  "environment": {
+    "computeType": "BUILD_GENERAL1_SMALL",
+    "image": "aws/codebuild/standard:4.0",
+    "type": "LINUX_CONTAINER"
+  },
+  "name": "minimal",
+  "serviceRole": "arn:aws:iam::123456789012:role/codebuild",
+  "source": {
+    "type": "NO_SOURCE"
+  }
+}
+`,
+		},
+		{
+			name: "returns an error if the project does not exist",
+			args: []string{"missing"},
+			err:  "unable to find project missing",
+		},
+		{
+			name:   "returns an error for formats other than yaml and json",
+			args:   []string{"staging"},
+			output: "csv",
+			err:    "unable to export a project as csv, expected yaml or json",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			api := client.NewMockAPI(ctrl)
+			mockProjects(api, testProject("staging"), &codebuild.Project{
+				Name:        aws.String("minimal"),
+				Arn:         aws.String("arn:aws:codebuild:eu-west-2:123456789012:project/minimal"),
+				Source:      &codebuild.ProjectSource{Type: aws.String("NO_SOURCE")},
+				Artifacts:   &codebuild.ProjectArtifacts{Type: aws.String("NO_ARTIFACTS")},
+				ServiceRole: aws.String("arn:aws:iam::123456789012:role/codebuild"),
+				Environment: &codebuild.ProjectEnvironment{
+					Image:       aws.String("aws/codebuild/standard:4.0"),
+					ComputeType: aws.String("BUILD_GENERAL1_SMALL"),
+					Type:        aws.String("LINUX_CONTAINER"),
+				},
+			})
+
+			var b bytes.Buffer
+			writer := bufio.NewWriter(&b)
+
+			err := cmd.ExportProject(api, cmd.ProjectOptions{Args: tc.args, Output: tc.output}, writer)
+			writer.Flush()
+
+			if tc.err == "" && err != nil {
+				t.Fatalf("expected no error; got %v", err)
+			}
+
+			if tc.err != "" && (err == nil || err.Error() != tc.err) {
+				t.Fatalf("expected err to be %s; got %v", tc.err, err)
+			}
+
+			if b.String() != tc.expected {
+				t.Fatalf("expected '%s'; got '%s'", tc.expected, b.String())
+			}
+		})
+	}
+}
+
+// exportDefinition exports the project, as the definition to apply
+func exportDefinition(t *testing.T, project *codebuild.Project) string {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	api := client.NewMockAPI(ctrl)
+	mockProjects(api, project)
+
+	var b bytes.Buffer
+	if err := cmd.ExportProject(api, cmd.ProjectOptions{Args: []string{aws.StringValue(project.Name)}}, &b); err != nil {
+		t.Fatalf("expected no error; got %v", err)
+	}
+
+	return b.String()
+}
+
+func TestApplyProject(t *testing.T) {
+	larger := testProject("staging")
+	larger.Environment.ComputeType = aws.String("BUILD_GENERAL1_LARGE")
+	larger.TimeoutInMinutes = aws.Int64(90)
+
+	tt := []struct {
+		name       string
+		existing   []*codebuild.Project
+		definition string
+		noFile     bool
+		input      string
+		yes        bool
+		created    bool
+		updated    bool
+		expected   string
+		err        string
+	}{
+		{
+			name: "can create a project after confirmation",
+			definition: `name: api
+source:
+  type: NO_SOURCE
+  buildspec: buildspec.yml
+environment:
+  type: LINUX_CONTAINER
+  image: aws/codebuild/standard:4.0
+  computeType: BUILD_GENERAL1_SMALL
+serviceRole: arn:aws:iam::123456789012:role/codebuild
+artifacts:
+  type: NO_ARTIFACTS
+`,
+			input:   "y\n",
+			created: true,
+			expected: `Project api does not exist and will be created
+
+Name:             api
+Source:           NO_SOURCE
+Buildspec:        buildspec.yml
+Image:            aws/codebuild/standard:4.0
+Compute type:     BUILD_GENERAL1_SMALL
+Environment type: LINUX_CONTAINER
+Service role:     arn:aws:iam::123456789012:role/codebuild
+Artifacts:        NO_ARTIFACTS
+
+Create project api? [y/N] ✅ Created project api
+`,
+		},
+		{
+			name:       "can update a project after showing what will change",
+			existing:   []*codebuild.Project{testProject("staging")},
+			definition: exportDefinition(t, larger),
+			input:      "y\n",
+			updated:    true,
+			expected: `Project staging will be updated
+
+Setting      Current              Desired
+Compute type BUILD_GENERAL1_SMALL BUILD_GENERAL1_LARGE
+Timeout      1h0m0s               1h30m0s
+
+Update project staging? [y/N] ✅ Updated project staging
+`,
+		},
+		{
+			name:       "can skip the confirmation",
+			existing:   []*codebuild.Project{testProject("staging")},
+			definition: exportDefinition(t, larger),
+			yes:        true,
+			updated:    true,
+			expected: `Project staging will be updated
+
+Setting      Current              Desired
+Compute type BUILD_GENERAL1_SMALL BUILD_GENERAL1_LARGE
+Timeout      1h0m0s               1h30m0s
+
+✅ Updated project staging
+`,
+		},
+		{
+			name:       "does not change anything without confirmation",
+			existing:   []*codebuild.Project{testProject("staging")},
+			definition: exportDefinition(t, larger),
+			input:      "n\n",
+			expected: `Project staging will be updated
+
+Setting      Current              Desired
+Compute type BUILD_GENERAL1_SMALL BUILD_GENERAL1_LARGE
+Timeout      1h0m0s               1h30m0s
+
+Update project staging? [y/N] `,
+		},
+		{
+			name:       "says when the project is up to date",
+			existing:   []*codebuild.Project{testProject("staging")},
+			definition: exportDefinition(t, testProject("staging")),
+			expected:   "Project staging is up to date\n",
+		},
+		{
+			name:       "returns an error if the definition has no name",
+			definition: "description: nameless\n",
+			err:        "the project definition has no name",
+		},
+		{
+			name:   "returns an error without a definition",
+			noFile: true,
+			err:    "please specify a project definition with --file",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			api := client.NewMockAPI(ctrl)
+			mockProjects(api, tc.existing...)
+
+			created := false
+			api.
+				EXPECT().
+				CreateProject(gomock.Any()).
+				DoAndReturn(func(input *codebuild.CreateProjectInput) (*codebuild.CreateProjectOutput, error) {
+					created = true
+					return &codebuild.CreateProjectOutput{}, nil
+				}).
+				AnyTimes()
+
+			var updated *codebuild.UpdateProjectInput
+			api.
+				EXPECT().
+				UpdateProject(gomock.Any()).
+				DoAndReturn(func(input *codebuild.UpdateProjectInput) (*codebuild.UpdateProjectOutput, error) {
+					updated = input
+					return &codebuild.UpdateProjectOutput{}, nil
+				}).
+				AnyTimes()
+
+			opts := cmd.ProjectOptions{Yes: tc.yes}
+			if !tc.noFile {
+				dir, err := ioutil.TempDir("", "knope")
+				if err != nil {
+					t.Fatalf("expected no error; got %v", err)
+				}
+				defer os.RemoveAll(dir)
+
+				opts.File = filepath.Join(dir, "project.yaml")
+				if err := ioutil.WriteFile(opts.File, []byte(tc.definition), 0600); err != nil {
+					t.Fatalf("expected no error; got %v", err)
+				}
+			}
+
+			var b bytes.Buffer
+			err := cmd.ApplyProject(api, opts, strings.NewReader(tc.input), &b)
+
+			if tc.err == "" && err != nil {
+				t.Fatalf("expected no error; got %v", err)
+			}
+
+			if tc.err != "" && (err == nil || !strings.HasSuffix(err.Error(), tc.err)) {
+				t.Fatalf("expected err to end with %s; got %v", tc.err, err)
+			}
+
+			if created != tc.created {
+				t.Fatalf("expected created to be %v; got %v", tc.created, created)
+			}
+
+			if (updated != nil) != tc.updated {
+				t.Fatalf("expected updated to be %v; got %v", tc.updated, updated != nil)
+			}
+
+			if updated != nil && aws.StringValue(updated.Environment.ComputeType) != "BUILD_GENERAL1_LARGE" {
+				t.Fatalf("expected the compute type to be updated; got %s", aws.StringValue(updated.Environment.ComputeType))
+			}
+
+			if b.String() != tc.expected {
+				t.Fatalf("expected '%s'; got '%s'", tc.expected, b.String())
+			}
+		})
+	}
+}
+
+func TestApplyProjectRemovesSettings(t *testing.T) {
+	oneVariable := testProject("staging")
+	oneVariable.Environment.EnvironmentVariables = oneVariable.Environment.EnvironmentVariables[1:]
+
+	noVariablesOrTags := testProject("staging")
+	noVariablesOrTags.Environment.EnvironmentVariables = nil
+	noVariablesOrTags.Tags = nil
+
+	noVpc := testProject("staging")
+	noVpc.VpcConfig = nil
+
+	cleared := testProject("staging")
+	cleared.Environment.EnvironmentVariables = []*codebuild.EnvironmentVariable{}
+	cleared.Tags = []*codebuild.Tag{}
+
+	tt := []struct {
+		name      string
+		existing  *codebuild.Project
+		desired   *codebuild.Project
+		updated   bool
+		variables []string
+		tags      []string
+		err       string
+	}{
+		{
+			name:      "sends the environment variables which are left",
+			existing:  testProject("staging"),
+			desired:   oneVariable,
+			updated:   true,
+			variables: []string{"TOKEN"},
+			tags:      []string{"team"},
+		},
+		{
+			name:      "sends empty environment variables and tags once they are all removed",
+			existing:  testProject("staging"),
+			desired:   noVariablesOrTags,
+			updated:   true,
+			variables: []string{},
+			tags:      []string{},
+		},
+		{
+			name:     "says a project with settings sent empty is up to date",
+			existing: cleared,
+			desired:  noVariablesOrTags,
+		},
+		{
+			name:     "refuses to remove settings which cannot be sent empty",
+			existing: testProject("staging"),
+			desired:  noVpc,
+			err:      "unable to remove vpcConfig from project staging, as CodeBuild keeps settings an update leaves out, so please give them a value instead",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			api := client.NewMockAPI(ctrl)
+			mockProjects(api, tc.existing)
+
+			var updated *codebuild.UpdateProjectInput
+			api.
+				EXPECT().
+				UpdateProject(gomock.Any()).
+				DoAndReturn(func(input *codebuild.UpdateProjectInput) (*codebuild.UpdateProjectOutput, error) {
+					updated = input
+					return &codebuild.UpdateProjectOutput{}, nil
+				}).
+				AnyTimes()
+
+			dir, err := ioutil.TempDir("", "knope")
+			if err != nil {
+				t.Fatalf("expected no error; got %v", err)
+			}
+			defer os.RemoveAll(dir)
+
+			opts := cmd.ProjectOptions{File: filepath.Join(dir, "project.yaml"), Yes: true}
+			if err := ioutil.WriteFile(opts.File, []byte(exportDefinition(t, tc.desired)), 0600); err != nil {
+				t.Fatalf("expected no error; got %v", err)
+			}
+
+			var b bytes.Buffer
+			err = cmd.ApplyProject(api, opts, strings.NewReader(""), &b)
+
+			if tc.err == "" && err != nil {
+				t.Fatalf("expected no error; got %v", err)
+			}
+
+			if tc.err != "" && (err == nil || err.Error() != tc.err) {
+				t.Fatalf("expected err to be %s; got %v", tc.err, err)
+			}
+
+			if (updated != nil) != tc.updated {
+				t.Fatalf("expected updated to be %v; got %v", tc.updated, updated != nil)
+			}
+
+			if updated == nil {
+				return
+			}
+
+			variables := []string{}
+			if updated.Environment.EnvironmentVariables == nil {
+				t.Fatalf("expected the environment variables to be sent")
+			}
+			for _, variable := range updated.Environment.EnvironmentVariables {
+				variables = append(variables, aws.StringValue(variable.Name))
+			}
+			if !reflect.DeepEqual(variables, tc.variables) {
+				t.Fatalf("expected the environment variables %v; got %v", tc.variables, variables)
+			}
+
+			tags := []string{}
+			if updated.Tags == nil {
+				t.Fatalf("expected the tags to be sent")
+			}
+			for _, tag := range updated.Tags {
+				tags = append(tags, aws.StringValue(tag.Key))
+			}
+			if !reflect.DeepEqual(tags, tc.tags) {
+				t.Fatalf("expected the tags %v; got %v", tc.tags, tags)
+			}
+		})
+	}
+}
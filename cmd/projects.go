@@ -1,7 +1,6 @@
 package cmd
 
 import (
-	"fmt"
 	"io"
 	"os"
 	"sort"
@@ -11,37 +10,69 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/codebuild"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
+// ListProjectsOptions defines what arguments/options the user can provide
+type ListProjectsOptions struct {
+	Args     []string
+	Limit    int
+	Selector ProjectSelector
+	Output   string
+	Template string
+	JSONPath string
+}
+
 // NewListProjectsCommand creates a new `projects` command
 func NewListProjectsCommand(client client.API) *cobra.Command {
+	var opts ListProjectsOptions
+
 	cmd := &cobra.Command{
 		Use:   "projects",
 		Short: "List all the projects",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return DisplayProjects(client, os.Stdout)
+			opts.Args = args
+			opts.Output = viper.GetString("output")
+			opts.Template = viper.GetString("template")
+			opts.JSONPath = viper.GetString("jsonpath")
+			return DisplayProjects(client, opts, os.Stdout)
 		},
 	}
+
+	flags := cmd.Flags()
+	flags.IntVar(&opts.Limit, "limit", 0, "Maximum number of projects to list (0 means no limit)")
+	addSelectorFlags(flags, &opts.Selector)
+
 	return cmd
 }
 
 // DisplayProjects will render the projects you have access to
-func DisplayProjects(client client.API, w io.Writer) error {
-	projects, err := client.ListProjects(&codebuild.ListProjectsInput{SortOrder: aws.String("ASCENDING")})
+func DisplayProjects(api client.API, opts ListProjectsOptions, w io.Writer) error {
+	projects, err := client.NewProjectIterator(api, &codebuild.ListProjectsInput{SortOrder: aws.String("ASCENDING")}, opts.Limit).All()
+	if err != nil {
+		return err
+	}
+
+	names, err := selectProjects(api, aws.StringValueSlice(projects), opts.Selector, nil)
 	if err != nil {
 		return err
 	}
 
-	var sorted []string
-	for _, name := range projects.Projects {
-		sorted = append(sorted, *name)
+	sorted := []ProjectRecord{}
+	for _, name := range names {
+		sorted = append(sorted, ProjectRecord{Name: name})
 	}
 
-	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
 
-	for _, name := range sorted {
-		fmt.Fprintf(w, "%s\n", name)
-	}
+	return render(w, renderOptions{format: opts.Output, template: opts.Template, jsonpath: opts.JSONPath}, sorted, projectsTable)
+}
 
-	return nil
+// ProjectRecord gives us a struct to store projects
+type ProjectRecord struct {
+	Name string `json:"name" yaml:"name"`
 }
+
+var projectsTable = table{hideHeaders: true, columns: []column{
+	{name: "name", header: "Name", value: func(record interface{}) string { return record.(ProjectRecord).Name }},
+}}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/codebuild"
 	"github.com/benmatselby/knope/client"
 	"github.com/benmatselby/knope/cmd"
@@ -68,7 +69,7 @@ func TestDisplayProjects(t *testing.T) {
 			var b bytes.Buffer
 			writer := bufio.NewWriter(&b)
 
-			cmd.DisplayProjects(client, writer)
+			cmd.DisplayProjects(client, cmd.ListProjectsOptions{}, writer)
 			writer.Flush()
 
 			if b.String() != tc.expected {
@@ -77,3 +78,94 @@ func TestDisplayProjects(t *testing.T) {
 		})
 	}
 }
+
+func TestDisplayProjectsWithSelector(t *testing.T) {
+	projects := []*codebuild.Project{
+		{
+			Name:        aws.String("payments-api"),
+			Source:      &codebuild.ProjectSource{Type: aws.String("GITHUB")},
+			Environment: &codebuild.ProjectEnvironment{Image: aws.String("aws/codebuild/standard:4.0"), ComputeType: aws.String("BUILD_GENERAL1_SMALL")},
+			Tags:        []*codebuild.Tag{{Key: aws.String("team"), Value: aws.String("payments")}, {Key: aws.String("service"), Value: aws.String("api")}},
+		},
+		{
+			Name:        aws.String("payments-web"),
+			Source:      &codebuild.ProjectSource{Type: aws.String("CODECOMMIT")},
+			Environment: &codebuild.ProjectEnvironment{Image: aws.String("aws/codebuild/standard:5.0"), ComputeType: aws.String("BUILD_GENERAL1_LARGE")},
+			Tags:        []*codebuild.Tag{{Key: aws.String("team"), Value: aws.String("payments")}},
+		},
+		{
+			Name:        aws.String("search"),
+			Source:      &codebuild.ProjectSource{Type: aws.String("GITHUB")},
+			Environment: &codebuild.ProjectEnvironment{Image: aws.String("123456789012.dkr.ecr.eu-west-2.amazonaws.com/builder:latest"), ComputeType: aws.String("BUILD_GENERAL1_SMALL")},
+		},
+	}
+
+	tt := []struct {
+		name     string
+		selector cmd.ProjectSelector
+		expected string
+		calls    int
+		err      string
+	}{
+		{name: "does not look up the projects without a selector", expected: "payments-api\npayments-web\nsearch\n"},
+		{name: "can select by tag", selector: cmd.ProjectSelector{Tags: []string{"team=payments"}}, expected: "payments-api\npayments-web\n", calls: 1},
+		{name: "can select by tag key", selector: cmd.ProjectSelector{Tags: []string{"service"}}, expected: "payments-api\n", calls: 1},
+		{name: "needs every tag to match", selector: cmd.ProjectSelector{Tags: []string{"team=payments", "service=web"}}, expected: "", calls: 1},
+		{name: "can select by source type", selector: cmd.ProjectSelector{SourceType: "github"}, expected: "payments-api\nsearch\n", calls: 1},
+		{name: "can select by image without its tag", selector: cmd.ProjectSelector{Image: "aws/codebuild/standard"}, expected: "payments-api\npayments-web\n", calls: 1},
+		{name: "can select by image with its tag", selector: cmd.ProjectSelector{Image: "aws/codebuild/standard:5.0"}, expected: "payments-web\n", calls: 1},
+		{name: "can select by compute type", selector: cmd.ProjectSelector{ComputeType: "BUILD_GENERAL1_SMALL", Tags: []string{"team"}}, expected: "payments-api\n", calls: 1},
+		{name: "rejects a tag without a key", selector: cmd.ProjectSelector{Tags: []string{"=payments"}}, err: `invalid --tag "=payments", expected KEY=VALUE or KEY`},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			api := client.NewMockAPI(ctrl)
+
+			var names []*string
+			for _, project := range projects {
+				names = append(names, project.Name)
+			}
+
+			api.
+				EXPECT().
+				ListProjects(gomock.Any()).
+				Return(&codebuild.ListProjectsOutput{Projects: names}, nil).
+				AnyTimes()
+
+			calls := 0
+			api.
+				EXPECT().
+				BatchGetProjects(gomock.Any()).
+				DoAndReturn(func(input *codebuild.BatchGetProjectsInput) (*codebuild.BatchGetProjectsOutput, error) {
+					calls++
+					return &codebuild.BatchGetProjectsOutput{Projects: projects}, nil
+				}).
+				AnyTimes()
+
+			var b bytes.Buffer
+			writer := bufio.NewWriter(&b)
+
+			err := cmd.DisplayProjects(api, cmd.ListProjectsOptions{Selector: tc.selector}, writer)
+			writer.Flush()
+
+			if tc.err == "" && err != nil {
+				t.Fatalf("expected no error; got %v", err)
+			}
+
+			if tc.err != "" && (err == nil || err.Error() != tc.err) {
+				t.Fatalf("expected err to be %s; got %v", tc.err, err)
+			}
+
+			if b.String() != tc.expected {
+				t.Fatalf("expected '%s'; got '%s'", tc.expected, b.String())
+			}
+
+			if calls != tc.calls {
+				t.Fatalf("expected %d calls to BatchGetProjects; got %d", tc.calls, calls)
+			}
+		})
+	}
+}
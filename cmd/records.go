@@ -0,0 +1,281 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/codebuild"
+)
+
+const (
+	// StatusUnknown is the status of a record when we could not find out about the build
+	StatusUnknown string = "UNKNOWN"
+	// StatusEmpty is the status of a record when the project has no builds
+	StatusEmpty string = "NO_BUILDS"
+)
+
+// BuildRecord gives us a struct to store records
+type BuildRecord struct {
+	Project         string        `json:"project" yaml:"project"`
+	Context         string        `json:"context,omitempty" yaml:"context,omitempty"`
+	Account         string        `json:"account,omitempty" yaml:"account,omitempty"`
+	Region          string        `json:"region,omitempty" yaml:"region,omitempty"`
+	ID              string        `json:"id,omitempty" yaml:"id,omitempty"`
+	Status          string        `json:"status" yaml:"status"`
+	SourceVersion   string        `json:"source_version,omitempty" yaml:"source_version,omitempty"`
+	Commit          string        `json:"commit,omitempty" yaml:"commit,omitempty"`
+	Initiator       string        `json:"initiator,omitempty" yaml:"initiator,omitempty"`
+	Number          int64         `json:"number,omitempty" yaml:"number,omitempty"`
+	CurrentPhase    string        `json:"current_phase,omitempty" yaml:"current_phase,omitempty"`
+	Image           string        `json:"image,omitempty" yaml:"image,omitempty"`
+	ComputeType     string        `json:"compute_type,omitempty" yaml:"compute_type,omitempty"`
+	Start           *time.Time    `json:"start,omitempty" yaml:"start,omitempty"`
+	Finish          *time.Time    `json:"finish,omitempty" yaml:"finish,omitempty"`
+	DurationSeconds int64         `json:"duration_seconds" yaml:"duration_seconds"`
+	Phases          []PhaseRecord `json:"phases,omitempty" yaml:"phases,omitempty"`
+	Error           string        `json:"error,omitempty" yaml:"error,omitempty"`
+	Batch           bool          `json:"batch,omitempty" yaml:"batch,omitempty"`
+
+	// throttled is set when AWS refused to tell us about the build because we asked too often
+	throttled bool
+}
+
+// PhaseRecord gives us a struct to store the phases of a build
+type PhaseRecord struct {
+	Type            string     `json:"type" yaml:"type"`
+	Status          string     `json:"status,omitempty" yaml:"status,omitempty"`
+	Start           *time.Time `json:"start,omitempty" yaml:"start,omitempty"`
+	Finish          *time.Time `json:"finish,omitempty" yaml:"finish,omitempty"`
+	DurationSeconds int64      `json:"duration_seconds" yaml:"duration_seconds"`
+	Contexts        []string   `json:"contexts,omitempty" yaml:"contexts,omitempty"`
+}
+
+// newBuildRecordForBatch flattens a batch build into a build record, so a
+// batch can stand in for the last build of a project
+func newBuildRecordForBatch(batch *codebuild.BuildBatch) BuildRecord {
+	return BuildRecord{
+		Project:         aws.StringValue(batch.ProjectName),
+		ID:              aws.StringValue(batch.Id),
+		Status:          aws.StringValue(batch.BuildBatchStatus),
+		SourceVersion:   aws.StringValue(batch.SourceVersion),
+		Commit:          aws.StringValue(batch.ResolvedSourceVersion),
+		Initiator:       aws.StringValue(batch.Initiator),
+		Number:          aws.Int64Value(batch.BuildBatchNumber),
+		CurrentPhase:    aws.StringValue(batch.CurrentPhase),
+		Start:           batch.StartTime,
+		Finish:          batch.EndTime,
+		DurationSeconds: durationSeconds(batch.StartTime, batch.EndTime),
+		Batch:           true,
+	}
+}
+
+// durationSeconds is how long something took, or has taken so far
+func durationSeconds(start, end *time.Time) int64 {
+	if start == nil {
+		return 0
+	}
+
+	finish := time.Now()
+	if end != nil {
+		finish = *end
+	}
+
+	return int64(finish.Sub(*start).Seconds())
+}
+
+// newBuildRecord flattens a build into a record
+func newBuildRecord(build *codebuild.Build) BuildRecord {
+	record := BuildRecord{
+		Project:       aws.StringValue(build.ProjectName),
+		ID:            aws.StringValue(build.Id),
+		Status:        aws.StringValue(build.BuildStatus),
+		SourceVersion: aws.StringValue(build.SourceVersion),
+		Commit:        aws.StringValue(build.ResolvedSourceVersion),
+		Initiator:     aws.StringValue(build.Initiator),
+		Number:        aws.Int64Value(build.BuildNumber),
+		CurrentPhase:  aws.StringValue(build.CurrentPhase),
+		Start:         build.StartTime,
+		Finish:        build.EndTime,
+	}
+
+	if build.Environment != nil {
+		record.Image = aws.StringValue(build.Environment.Image)
+		record.ComputeType = aws.StringValue(build.Environment.ComputeType)
+	}
+
+	for _, phase := range build.Phases {
+		p := PhaseRecord{
+			Type:            aws.StringValue(phase.PhaseType),
+			Status:          aws.StringValue(phase.PhaseStatus),
+			Start:           phase.StartTime,
+			Finish:          phase.EndTime,
+			DurationSeconds: aws.Int64Value(phase.DurationInSeconds),
+		}
+
+		for _, context := range phase.Contexts {
+			if aws.StringValue(context.Message) != "" {
+				p.Contexts = append(p.Contexts, fmt.Sprintf("%s: %s", aws.StringValue(context.StatusCode), aws.StringValue(context.Message)))
+			}
+		}
+
+		record.Phases = append(record.Phases, p)
+	}
+
+	record.DurationSeconds = durationSeconds(build.StartTime, build.EndTime)
+
+	return record
+}
+
+// FailedPhase is the first phase of the build that did not succeed, if any
+func (r BuildRecord) FailedPhase() *PhaseRecord {
+	for i, phase := range r.Phases {
+		if phase.Status != "" && phase.Status != codebuild.StatusTypeSucceeded {
+			return &r.Phases[i]
+		}
+	}
+	return nil
+}
+
+// Icon is the status of the build as an icon
+func (r BuildRecord) Icon() string {
+	return getBuildIcon(&r.Status)
+}
+
+// Duration is how long the build took, or has taken so far
+func (r BuildRecord) Duration() time.Duration {
+	return time.Duration(r.DurationSeconds) * time.Second
+}
+
+// key identifies the project the record is for, across contexts and regions
+func (r BuildRecord) key() string {
+	return r.Context + "/" + r.Region + "/" + r.Project
+}
+
+// buildField adapts a BuildRecord function for use as a column value
+func buildField(f func(r BuildRecord) string) func(record interface{}) string {
+	return func(record interface{}) string {
+		return f(record.(BuildRecord))
+	}
+}
+
+// orDash shows a dash for an empty value, so the gap is obvious in a table
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// formatRecordTime renders a record time for people, using - when we do not know
+func formatRecordTime(r BuildRecord, t *time.Time) string {
+	if r.Status == StatusUnknown {
+		return "-"
+	}
+	return formatTime(t)
+}
+
+// rawTime renders a time for machines
+func rawTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+var (
+	statusColumn = column{
+		name:   "status",
+		header: "Status",
+		icon:   true,
+		value:  buildField(func(r BuildRecord) string { return r.Icon() }),
+		raw:    buildField(func(r BuildRecord) string { return r.Status }),
+	}
+	// branchColumn keeps the source_version name it has always had in CSV
+	// and --columns, as scripts read it
+	branchColumn = column{
+		name:   "source_version",
+		header: "Branch",
+		value:  buildField(func(r BuildRecord) string { return branchName(r.SourceVersion) }),
+		raw:    buildField(func(r BuildRecord) string { return r.SourceVersion }),
+	}
+	commitColumn = column{
+		name:   "commit",
+		header: "Commit",
+		value:  buildField(func(r BuildRecord) string { return shortCommit(r.Commit) }),
+		raw:    buildField(func(r BuildRecord) string { return r.Commit }),
+	}
+	initiatorColumn = column{
+		name:   "initiator",
+		header: "Initiator",
+		value:  buildField(func(r BuildRecord) string { return r.Initiator }),
+	}
+	startColumn = column{
+		name:   "start",
+		header: "Started",
+		value:  buildField(func(r BuildRecord) string { return formatRecordTime(r, r.Start) }),
+		raw:    buildField(func(r BuildRecord) string { return rawTime(r.Start) }),
+	}
+	finishColumn = column{
+		name:   "finish",
+		header: "Finished",
+		value:  buildField(func(r BuildRecord) string { return formatRecordTime(r, r.Finish) }),
+		raw:    buildField(func(r BuildRecord) string { return rawTime(r.Finish) }),
+	}
+	idColumn = column{
+		name:   "id",
+		header: "Build",
+		wide:   true,
+		value:  buildField(func(r BuildRecord) string { return r.ID }),
+	}
+	contextColumn = column{
+		name:   "context",
+		header: "Context",
+		value:  buildField(func(r BuildRecord) string { return r.Context }),
+	}
+	accountColumn = column{
+		name:   "account",
+		header: "Account",
+		value:  buildField(func(r BuildRecord) string { return r.Account }),
+	}
+	regionColumn = column{
+		name:   "region",
+		header: "Region",
+		value:  buildField(func(r BuildRecord) string { return r.Region }),
+	}
+	durationColumn = column{
+		name:   "duration_seconds",
+		header: "Duration",
+		value: buildField(func(r BuildRecord) string {
+			if r.Start == nil {
+				return ""
+			}
+			return r.Duration().String()
+		}),
+		raw: buildField(func(r BuildRecord) string { return strconv.FormatInt(r.DurationSeconds, 10) }),
+	}
+
+	overviewTable = table{columns: []column{
+		statusColumn,
+		{name: "project", header: "Name", value: buildField(func(r BuildRecord) string { return r.Project })},
+		branchColumn,
+		commitColumn,
+		startColumn,
+		finishColumn,
+		durationColumn,
+		idColumn,
+		initiatorColumn.asWide(),
+	}}
+
+	buildsTable = table{columns: []column{
+		statusColumn,
+		branchColumn,
+		commitColumn,
+		startColumn,
+		finishColumn,
+		durationColumn,
+		initiatorColumn,
+		idColumn,
+	}}
+)
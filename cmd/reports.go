@@ -0,0 +1,238 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/codebuild"
+	"github.com/benmatselby/knope/client"
+	"github.com/benmatselby/knope/ui"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// ReportsOptions defines what arguments/options the user can provide
+type ReportsOptions struct {
+	Args     []string
+	Group    string
+	Limit    int
+	Output   string
+	Template string
+	JSONPath string
+}
+
+// NewReportsCommand creates a new `reports` command
+func NewReportsCommand(client client.API) *cobra.Command {
+	var opts ReportsOptions
+
+	cmd := &cobra.Command{
+		Use:   "reports [build-id|project:latest]",
+		Short: "List the test reports for a build or report group",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Args = args
+			opts.Output = viper.GetString("output")
+			opts.Template = viper.GetString("template")
+			opts.JSONPath = viper.GetString("jsonpath")
+			return DisplayReports(client, opts, os.Stdout)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&opts.Group, "group", "", "Name or ARN of the report group to list the reports for, rather than a build")
+	flags.IntVar(&opts.Limit, "limit", 20, "Maximum number of reports to list from the report group (0 means no limit)")
+
+	return cmd
+}
+
+// DisplayReports will render a summary of each test report for a build, or
+// the latest reports in a report group
+func DisplayReports(api client.API, opts ReportsOptions, w io.Writer) error {
+	var reports []*codebuild.Report
+	var err error
+
+	switch {
+	case len(opts.Args) > 0:
+		reports, err = buildReports(api, opts.Args[0], codebuild.ReportTypeTest)
+	case opts.Group != "":
+		reports, err = groupReports(api, opts.Group, opts.Limit)
+	default:
+		return fmt.Errorf("please specify a build id or a report group")
+	}
+	if err != nil {
+		return err
+	}
+
+	records := []ReportRecord{}
+	for _, report := range reports {
+		records = append(records, newReportRecord(report))
+	}
+
+	return render(w, renderOptions{format: opts.Output, template: opts.Template, jsonpath: opts.JSONPath}, records, reportsTable)
+}
+
+// buildReports gets the reports of a type that a build created
+func buildReports(api client.API, id string, reportType string) ([]*codebuild.Report, error) {
+	buildID, err := resolveBuildID(api, id)
+	if err != nil {
+		return nil, err
+	}
+
+	build, err := getBuild(api, buildID)
+	if err != nil {
+		return nil, err
+	}
+
+	reports, err := client.GetReports(api, build.ReportArns)
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []*codebuild.Report
+	for _, report := range reports {
+		if aws.StringValue(report.Type) == reportType {
+			matched = append(matched, report)
+		}
+	}
+
+	return matched, nil
+}
+
+// groupReports gets the newest reports in a report group
+func groupReports(api client.API, group string, limit int) ([]*codebuild.Report, error) {
+	arn, err := resolveReportGroup(api, group)
+	if err != nil {
+		return nil, err
+	}
+
+	arns, err := client.NewReportIterator(api, &codebuild.ListReportsForReportGroupInput{
+		ReportGroupArn: arn,
+		SortOrder:      aws.String(codebuild.SortOrderTypeDescending),
+	}, limit).All()
+	if err != nil {
+		return nil, err
+	}
+
+	return client.GetReports(api, arns)
+}
+
+// resolveReportGroup turns the name of a report group into its ARN, which
+// is what the API wants
+func resolveReportGroup(api client.API, group string) (*string, error) {
+	if strings.HasPrefix(group, "arn:") {
+		return aws.String(group), nil
+	}
+
+	arns, err := client.NewReportGroupIterator(api, nil, 0).All()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, arn := range arns {
+		if arnResource(aws.StringValue(arn)) == group {
+			return arn, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unable to find report group %s", group)
+}
+
+// arnResource is the name at the end of an ARN, such as the report group
+// name in arn:aws:codebuild:eu-west-2:123456789012:report-group/unit
+func arnResource(arn string) string {
+	if i := strings.Index(arn, "/"); i >= 0 {
+		return arn[i+1:]
+	}
+	return arn
+}
+
+// ReportRecord gives us a struct to store the summary of a test report
+type ReportRecord struct {
+	Group           string     `json:"group" yaml:"group"`
+	ARN             string     `json:"arn" yaml:"arn"`
+	Build           string     `json:"build,omitempty" yaml:"build,omitempty"`
+	Type            string     `json:"type" yaml:"type"`
+	Status          string     `json:"status" yaml:"status"`
+	Created         *time.Time `json:"created,omitempty" yaml:"created,omitempty"`
+	Total           int64      `json:"total" yaml:"total"`
+	Passed          int64      `json:"passed" yaml:"passed"`
+	Failed          int64      `json:"failed" yaml:"failed"`
+	Skipped         int64      `json:"skipped" yaml:"skipped"`
+	DurationSeconds float64    `json:"duration_seconds" yaml:"duration_seconds"`
+}
+
+// newReportRecord flattens a report into a record. Errors count as failures.
+func newReportRecord(report *codebuild.Report) ReportRecord {
+	record := ReportRecord{
+		Group:   arnResource(aws.StringValue(report.ReportGroupArn)),
+		ARN:     aws.StringValue(report.Arn),
+		Build:   arnResource(aws.StringValue(report.ExecutionId)),
+		Type:    aws.StringValue(report.Type),
+		Status:  aws.StringValue(report.Status),
+		Created: report.Created,
+	}
+
+	if summary := report.TestSummary; summary != nil {
+		record.Total = aws.Int64Value(summary.Total)
+		record.Passed = aws.Int64Value(summary.StatusCounts["SUCCEEDED"])
+		record.Failed = aws.Int64Value(summary.StatusCounts["FAILED"]) + aws.Int64Value(summary.StatusCounts["ERROR"])
+		record.Skipped = aws.Int64Value(summary.StatusCounts["SKIPPED"])
+		record.DurationSeconds = float64(aws.Int64Value(summary.DurationInNanoSeconds)) / float64(time.Second)
+	}
+
+	return record
+}
+
+// Icon is the status of the report as an icon
+func (r ReportRecord) Icon() string {
+	if r.Status == codebuild.ReportStatusTypeGenerating {
+		return ui.AppProgress
+	}
+	return getBuildIcon(&r.Status)
+}
+
+// reportField adapts a ReportRecord function for use as a column value
+func reportField(f func(r ReportRecord) string) func(record interface{}) string {
+	return func(record interface{}) string {
+		return f(record.(ReportRecord))
+	}
+}
+
+// formatTestSeconds renders a number of seconds to the millisecond, as tests are quick
+func formatTestSeconds(seconds float64) string {
+	return time.Duration(seconds * float64(time.Second)).Round(time.Millisecond).String()
+}
+
+var reportsTable = table{columns: []column{
+	{
+		name:   "status",
+		header: "Status",
+		icon:   true,
+		value:  reportField(func(r ReportRecord) string { return r.Icon() }),
+		raw:    reportField(func(r ReportRecord) string { return r.Status }),
+	},
+	{name: "group", header: "Group", value: reportField(func(r ReportRecord) string { return r.Group })},
+	{name: "build", header: "Build", value: reportField(func(r ReportRecord) string { return r.Build })},
+	{name: "total", header: "Total", value: reportField(func(r ReportRecord) string { return strconv.FormatInt(r.Total, 10) })},
+	{name: "passed", header: "Passed", value: reportField(func(r ReportRecord) string { return strconv.FormatInt(r.Passed, 10) })},
+	{name: "failed", header: "Failed", value: reportField(func(r ReportRecord) string { return strconv.FormatInt(r.Failed, 10) })},
+	{name: "skipped", header: "Skipped", value: reportField(func(r ReportRecord) string { return strconv.FormatInt(r.Skipped, 10) })},
+	{
+		name:   "duration_seconds",
+		header: "Duration",
+		value:  reportField(func(r ReportRecord) string { return formatTestSeconds(r.DurationSeconds) }),
+		raw:    reportField(func(r ReportRecord) string { return strconv.FormatFloat(r.DurationSeconds, 'f', 3, 64) }),
+	},
+	{
+		name:   "created",
+		header: "Created",
+		value:  reportField(func(r ReportRecord) string { return formatTime(r.Created) }),
+		raw:    reportField(func(r ReportRecord) string { return rawTime(r.Created) }),
+	},
+	{name: "arn", header: "Report", wide: true, value: reportField(func(r ReportRecord) string { return r.ARN })},
+}}
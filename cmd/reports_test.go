@@ -0,0 +1,164 @@
+package cmd_test
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/codebuild"
+	"github.com/benmatselby/knope/client"
+	"github.com/benmatselby/knope/cmd"
+	"github.com/golang/mock/gomock"
+)
+
+const (
+	testUnitGroupARN = "arn:aws:codebuild:eu-west-2:123456789012:report-group/project-one-unit"
+	testUnitARN      = "arn:aws:codebuild:eu-west-2:123456789012:report/project-one-unit:abc"
+	testCoverageARN  = "arn:aws:codebuild:eu-west-2:123456789012:report/project-one-coverage:def"
+)
+
+// testReports are the reports a build of project-one creates
+func testReports() []*codebuild.Report {
+	created := time.Date(2019, time.July, 19, 23, 10, 0, 0, time.UTC)
+
+	return []*codebuild.Report{
+		{
+			Arn:            aws.String(testUnitARN),
+			ReportGroupArn: aws.String(testUnitGroupARN),
+			ExecutionId:    aws.String("arn:aws:codebuild:eu-west-2:123456789012:build/project-one:1"),
+			Type:           aws.String("TEST"),
+			Status:         aws.String("FAILED"),
+			Created:        &created,
+			TestSummary: &codebuild.TestReportSummary{
+				Total:                 aws.Int64(10),
+				DurationInNanoSeconds: aws.Int64(1500000000),
+				StatusCounts: map[string]*int64{
+					"SUCCEEDED": aws.Int64(7),
+					"FAILED":    aws.Int64(1),
+					"ERROR":     aws.Int64(1),
+					"SKIPPED":   aws.Int64(1),
+				},
+			},
+		},
+		{
+			Arn:            aws.String(testCoverageARN),
+			ReportGroupArn: aws.String("arn:aws:codebuild:eu-west-2:123456789012:report-group/project-one-coverage"),
+			ExecutionId:    aws.String("arn:aws:codebuild:eu-west-2:123456789012:build/project-one:1"),
+			Type:           aws.String("CODE_COVERAGE"),
+			Status:         aws.String("SUCCEEDED"),
+			Created:        &created,
+		},
+	}
+}
+
+func TestNewReportsCommand(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := client.NewMockAPI(ctrl)
+
+	cmd := cmd.NewReportsCommand(client)
+
+	use := "reports [build-id|project:latest]"
+	short := "List the test reports for a build or report group"
+
+	if cmd.Use != use {
+		t.Fatalf("expected use: %s; got %s", use, cmd.Use)
+	}
+
+	if cmd.Short != short {
+		t.Fatalf("expected use: %s; got %s", short, cmd.Short)
+	}
+}
+
+func TestDisplayReports(t *testing.T) {
+	tt := []struct {
+		name     string
+		opts     cmd.ReportsOptions
+		expected string
+		err      string
+	}{
+		{
+			name: "can list the test reports for a build",
+			opts: cmd.ReportsOptions{Args: []string{"project-one:1"}},
+			expected: `Status  Group            Build         Total Passed Failed Skipped Duration Created
+❌       project-one-unit project-one:1 10    7      2      1       1.5s     19-07-2019 23:10
+`,
+		},
+		{
+			name: "can list the reports in a report group",
+			opts: cmd.ReportsOptions{Group: "project-one-unit", Limit: 20, Output: "csv"},
+			expected: `status,group,build,total,passed,failed,skipped,duration_seconds,created,arn
+FAILED,project-one-unit,project-one:1,10,7,2,1,1.500,2019-07-19T23:10:00Z,` + testUnitARN + `
+`,
+		},
+		{
+			name: "tells you about report groups it cannot find",
+			opts: cmd.ReportsOptions{Group: "project-two-unit"},
+			err:  "unable to find report group project-two-unit",
+		},
+		{
+			name: "needs a build or report group",
+			opts: cmd.ReportsOptions{},
+			err:  "please specify a build id or a report group",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			client := client.NewMockAPI(ctrl)
+
+			client.
+				EXPECT().
+				BatchGetBuilds(gomock.Any()).
+				Return(&codebuild.BatchGetBuildsOutput{Builds: []*codebuild.Build{
+					{Id: aws.String("project-one:1"), ReportArns: aws.StringSlice([]string{testUnitARN, testCoverageARN})},
+				}}, nil).
+				AnyTimes()
+
+			client.
+				EXPECT().
+				ListReportGroups(gomock.Any()).
+				Return(&codebuild.ListReportGroupsOutput{ReportGroups: aws.StringSlice([]string{testUnitGroupARN})}, nil).
+				AnyTimes()
+
+			client.
+				EXPECT().
+				ListReportsForReportGroup(gomock.Any()).
+				DoAndReturn(func(input *codebuild.ListReportsForReportGroupInput) (*codebuild.ListReportsForReportGroupOutput, error) {
+					if aws.StringValue(input.ReportGroupArn) != testUnitGroupARN {
+						t.Fatalf("expected the reports for %s; got %s", testUnitGroupARN, aws.StringValue(input.ReportGroupArn))
+					}
+					return &codebuild.ListReportsForReportGroupOutput{Reports: aws.StringSlice([]string{testUnitARN})}, nil
+				}).
+				AnyTimes()
+
+			client.
+				EXPECT().
+				BatchGetReports(gomock.Any()).
+				Return(&codebuild.BatchGetReportsOutput{Reports: testReports()}, nil).
+				AnyTimes()
+
+			var b bytes.Buffer
+			writer := bufio.NewWriter(&b)
+
+			err := cmd.DisplayReports(client, tc.opts, writer)
+			writer.Flush()
+
+			if b.String() != tc.expected {
+				t.Fatalf("expected '%s'; got '%s'", tc.expected, b.String())
+			}
+
+			if tc.err == "" && err != nil {
+				t.Fatalf("expected no error; got %v", err)
+			}
+
+			if tc.err != "" && (err == nil || err.Error() != tc.err) {
+				t.Fatalf("expected err to be %s; got %v", tc.err, err)
+			}
+		})
+	}
+}
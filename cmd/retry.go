@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/codebuild"
+	"github.com/benmatselby/knope/client"
+	"github.com/spf13/cobra"
+)
+
+// RetryBuildOptions defines what arguments/options the user can provide
+type RetryBuildOptions struct {
+	Args []string
+}
+
+// NewRetryBuildCommand creates a new `retry` command
+func NewRetryBuildCommand(client client.API) *cobra.Command {
+	var opts RetryBuildOptions
+
+	cmd := &cobra.Command{
+		Use:   "retry [build-id|project:latest]",
+		Short: "Retry a finished build",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Args = args
+			return RetryBuild(client, opts, os.Stdout)
+		},
+	}
+
+	return cmd
+}
+
+// RetryBuild will retry the build and render the new build ID and status
+func RetryBuild(api client.API, opts RetryBuildOptions, w io.Writer) error {
+	if len(opts.Args) == 0 {
+		return fmt.Errorf("please specify a build id")
+	}
+
+	id, err := resolveBuildID(api, opts.Args[0])
+	if err != nil {
+		return err
+	}
+
+	retried, err := api.RetryBuild(&codebuild.RetryBuildInput{Id: id})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "%s %s\n", getBuildIcon(retried.Build.BuildStatus), aws.StringValue(retried.Build.Id))
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package cmd_test
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/codebuild"
+	"github.com/benmatselby/knope/client"
+	"github.com/benmatselby/knope/cmd"
+	"github.com/golang/mock/gomock"
+)
+
+func TestNewRetryBuildCommand(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := client.NewMockAPI(ctrl)
+
+	cmd := cmd.NewRetryBuildCommand(client)
+
+	use := "retry [build-id|project:latest]"
+	short := "Retry a finished build"
+
+	if cmd.Use != use {
+		t.Fatalf("expected use: %s; got %s", use, cmd.Use)
+	}
+
+	if cmd.Short != short {
+		t.Fatalf("expected use: %s; got %s", short, cmd.Short)
+	}
+}
+
+func TestRetryBuild(t *testing.T) {
+	tt := []struct {
+		name     string
+		id       string
+		latest   []*string
+		retried  string
+		expected string
+		err      string
+		listErr  error
+		retryErr error
+	}{
+		{name: "can retry a build by id", id: "project-one:1", retried: "project-one:1", expected: "🏗 project-one:2\n"},
+		{name: "can retry the latest build", id: "project-one:latest", latest: []*string{aws.String("project-one:1")}, retried: "project-one:1", expected: "🏗 project-one:2\n"},
+		{name: "tells you when there is no latest build", id: "project-one:latest", err: "there are no builds for project project-one"},
+		{name: "returns the error from listing builds", id: "project-one:latest", listErr: errors.New("there was an error"), err: "there was an error"},
+		{name: "returns the error from retrying the build", id: "project-one:1", retried: "project-one:1", retryErr: errors.New("there was an error"), err: "there was an error"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			client := client.NewMockAPI(ctrl)
+
+			client.
+				EXPECT().
+				ListBuildsForProject(gomock.Any()).
+				Return(&codebuild.ListBuildsForProjectOutput{Ids: tc.latest}, tc.listErr).
+				AnyTimes()
+
+			if tc.retried != "" {
+				client.
+					EXPECT().
+					RetryBuild(&codebuild.RetryBuildInput{Id: aws.String(tc.retried)}).
+					Return(&codebuild.RetryBuildOutput{Build: &codebuild.Build{
+						Id:          aws.String("project-one:2"),
+						BuildStatus: aws.String("IN_PROGRESS"),
+					}}, tc.retryErr)
+			}
+
+			var b bytes.Buffer
+			writer := bufio.NewWriter(&b)
+
+			err := cmd.RetryBuild(client, cmd.RetryBuildOptions{Args: []string{tc.id}}, writer)
+			writer.Flush()
+
+			if b.String() != tc.expected {
+				t.Fatalf("expected '%s'; got '%s'", tc.expected, b.String())
+			}
+
+			if tc.err == "" && err != nil {
+				t.Fatalf("expected no error; got %v", err)
+			}
+
+			if tc.err != "" && (err == nil || err.Error() != tc.err) {
+				t.Fatalf("expected err to be %s; got %v", tc.err, err)
+			}
+		})
+	}
+}
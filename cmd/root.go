@@ -3,19 +3,26 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/benmatselby/knope/client"
+	"github.com/benmatselby/knope/config"
+	"github.com/benmatselby/knope/ui"
 	"github.com/benmatselby/knope/version"
 
-	homedir "github.com/mitchellh/go-homedir"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
 var cfgFile string
 
+// fileConfig is the config as it is in the file, before any context is applied
+var fileConfig config.Config
+
 // NewRootCommand will return the application
-func NewRootCommand(client client.API) *cobra.Command {
+func NewRootCommand(client client.API, logs client.LogsAPI) *cobra.Command {
 	var cmd = &cobra.Command{
 		Use:     "knope",
 		Short:   "CLI tool for retrieving data from AWS CodeBuild",
@@ -26,11 +33,57 @@ func NewRootCommand(client client.API) *cobra.Command {
 	// Cobra supports persistent flags, which, if defined here,
 	// will be global for your application.
 	cmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.benmatselby/knope.yaml)")
+	cmd.PersistentFlags().String("context", "", "Named context from the config file to use, overriding current_context")
+	cmd.PersistentFlags().String("role-arn", "", "IAM role to assume, or a comma separated chain of roles")
+	cmd.PersistentFlags().String("external-id", "", "External ID to pass when assuming the role")
+	cmd.PersistentFlags().String("mfa-serial", "", "MFA device to ask for a code from when assuming the role")
+	cmd.PersistentFlags().String("session-duration", "", "How long assumed role credentials last, e.g. 1h (default 15m)")
+	cmd.PersistentFlags().StringP("output", "o", OutputTable, "Output format: "+strings.Join(OutputFormats, "|"))
+	cmd.PersistentFlags().String("template", "", "Go template applied to each record, e.g. '{{.Project}} {{.Status}}'")
+	cmd.PersistentFlags().String("jsonpath", "", "JSONPath template applied to each record, e.g. '{.project} {.status}'")
+	viper.BindPFlag(config.KeyContext, cmd.PersistentFlags().Lookup("context"))
+	viper.BindPFlag(config.KeyRoleARN, cmd.PersistentFlags().Lookup("role-arn"))
+	viper.BindPFlag(config.KeyExternalID, cmd.PersistentFlags().Lookup("external-id"))
+	viper.BindPFlag(config.KeyMFASerial, cmd.PersistentFlags().Lookup("mfa-serial"))
+	viper.BindPFlag(config.KeySessionDuration, cmd.PersistentFlags().Lookup("session-duration"))
+	viper.BindPFlag("output", cmd.PersistentFlags().Lookup("output"))
+	viper.BindPFlag("template", cmd.PersistentFlags().Lookup("template"))
+	viper.BindPFlag("jsonpath", cmd.PersistentFlags().Lookup("jsonpath"))
+
+	cmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if err := readConfig(false); err != nil {
+			return err
+		}
+
+		if err := applyConfig(cmd.Flags()); err != nil {
+			return err
+		}
+
+		return validateOutput(viper.GetString("output"), viper.GetString("template"), viper.GetString("jsonpath"))
+	}
 
 	cmd.AddCommand(
+		NewBatchCommand(client),
+		NewBuildCommand(client),
+		NewCheckCommand(client),
+		NewConfigCommand(),
+		NewContextCommand(),
+		NewCoverageCommand(client),
+		NewFlakyCommand(client),
+		NewListBatchesCommand(client),
 		NewListBuildsForProjectCommand(client),
 		NewListProjectsCommand(client),
+		NewLogsCommand(client, logs),
 		NewOverviewCommand(client),
+		NewPhasesCommand(client),
+		NewProjectCommand(client),
+		NewReportsCommand(client),
+		NewRetryBuildCommand(client),
+		NewStartBuildCommand(client),
+		NewStatsCommand(client),
+		NewStopBuildCommand(client),
+		NewTestsCommand(client),
+		NewUICommand(client, logs),
 	)
 
 	return cmd
@@ -39,39 +92,119 @@ func NewRootCommand(client client.API) *cobra.Command {
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	initConfig()
-
+	logs := client.NewLogsClient()
 	client := client.NewClient()
 
-	cmd := NewRootCommand(&client)
+	cmd := NewRootCommand(&client, &logs)
 
 	if err := cmd.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		code := 1
+		if exitErr, ok := err.(*ExitError); ok {
+			code = exitErr.Code
+		}
+
+		if message := err.Error(); message != "" {
+			fmt.Println(message)
+		}
+		os.Exit(code)
 	}
 }
 
-// initConfig reads in config file and ENV variables if set.
-func initConfig() {
+// ExitError is returned by commands which exit with a particular code, so
+// they can be used to gate scripts and pipelines
+type ExitError struct {
+	Code int
+	// Err explains the exit, and can be nil when the command has already said why
+	Err error
+}
+
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return ""
+	}
+	return e.Err.Error()
+}
+
+// configPath is the config file from the flag, or the default one
+func configPath() (string, error) {
 	if cfgFile != "" {
-		// Use config file from the flag.
-		viper.SetConfigFile(cfgFile)
-	} else {
-		// Find home directory.
-		home, err := homedir.Dir()
+		return cfgFile, nil
+	}
+
+	return config.DefaultPath()
+}
+
+// readConfig reads in the config file and ENV variables if set. It is
+// fine for the default config file not to exist, or any config file when
+// optional is set.
+func readConfig(optional bool) error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+
+	viper.SetConfigFile(path)
+	viper.SetConfigType("yaml")
+	viper.SetEnvPrefix("knope")
+	viper.AutomaticEnv() // read in environment variables that match
+	config.SetDefaults(viper.GetViper())
+
+	if err := viper.ReadInConfig(); err != nil {
+		if (optional || cfgFile == "") && os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("unable to read config file %s: %v", path, err)
+	}
+
+	return nil
+}
+
+// applyConfig sets up the app from the config. A context overrides the
+// config file, but not flags the user gave.
+func applyConfig(flags *pflag.FlagSet) error {
+	c, err := config.Load(viper.GetViper())
+	if err != nil {
+		return err
+	}
+	fileConfig = c
+
+	if name := activeContext(c); name != "" {
+		context, err := c.Context(name)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			return err
+		}
+
+		for key, value := range context.Settings() {
+			if flag := flags.Lookup(strings.Replace(key, "_", "-", -1)); flag != nil && flag.Changed {
+				continue
+			}
+			viper.Set(key, value)
 		}
+	}
 
-		viper.AddConfigPath(home + ".benmatselby/")
-		viper.SetConfigName("knope")
+	if err := ui.UseIcons(c.Icons); err != nil {
+		return err
 	}
 
-	viper.AutomaticEnv() // read in environment variables that match
+	if c.SessionDuration != "" {
+		if _, err := time.ParseDuration(c.SessionDuration); err != nil {
+			return fmt.Errorf("invalid session_duration %q: %v", c.SessionDuration, err)
+		}
+	}
 
-	// If a config file is found, read it in.
-	if err := viper.ReadInConfig(); err == nil {
-		fmt.Println("Using config file:", viper.ConfigFileUsed())
+	if c.DateFormat != "" {
+		ui.AppDateTimeFormat = c.DateFormat
 	}
+
+	return nil
+}
+
+// activeContext is the context asked for with --context, falling back to
+// the current context in the config file
+func activeContext(c config.Config) string {
+	if name := viper.GetString(config.KeyContext); name != "" {
+		return name
+	}
+
+	return c.CurrentContext
 }
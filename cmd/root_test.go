@@ -11,9 +11,10 @@ import (
 func TestNewRootCommand(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
+	logs := client.NewMockLogsAPI(ctrl)
 	client := client.NewMockAPI(ctrl)
 
-	cmd := cmd.NewRootCommand(client)
+	cmd := cmd.NewRootCommand(client, logs)
 
 	use := "knope"
 	short := "CLI tool for retrieving data from AWS CodeBuild"
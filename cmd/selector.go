@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/codebuild"
+	"github.com/benmatselby/knope/client"
+	"github.com/spf13/pflag"
+)
+
+// ProjectSelector picks out projects by how they are configured, rather
+// than by their name
+type ProjectSelector struct {
+	// Tags are KEY=VALUE, or just KEY to match any value, and must all match
+	Tags        []string
+	SourceType  string
+	Image       string
+	ComputeType string
+}
+
+// addSelectorFlags adds the flags to pick out projects by their configuration
+func addSelectorFlags(flags *pflag.FlagSet, selector *ProjectSelector) {
+	flags.StringArrayVar(&selector.Tags, "tag", nil, "Only include projects with the tag, as KEY=VALUE or KEY, and can be given more than once")
+	flags.StringVar(&selector.SourceType, "source-type", "", "Only include projects with the source type, e.g. GITHUB or CODECOMMIT")
+	flags.StringVar(&selector.Image, "image", "", "Only include projects using the image, with or without its tag")
+	flags.StringVar(&selector.ComputeType, "compute-type", "", "Only include projects with the compute type, e.g. BUILD_GENERAL1_SMALL")
+}
+
+// empty is whether the selector would pick every project
+func (s ProjectSelector) empty() bool {
+	return len(s.Tags) == 0 && s.SourceType == "" && s.Image == "" && s.ComputeType == ""
+}
+
+// matches is whether the project is one the selector picks out
+func (s ProjectSelector) matches(project *codebuild.Project) bool {
+	if s.SourceType != "" && (project.Source == nil || !strings.EqualFold(aws.StringValue(project.Source.Type), s.SourceType)) {
+		return false
+	}
+
+	environment := project.Environment
+	if environment == nil {
+		environment = &codebuild.ProjectEnvironment{}
+	}
+
+	if s.ComputeType != "" && !strings.EqualFold(aws.StringValue(environment.ComputeType), s.ComputeType) {
+		return false
+	}
+
+	if s.Image != "" {
+		image := aws.StringValue(environment.Image)
+		if image != s.Image && !strings.HasPrefix(image, s.Image+":") && !strings.HasPrefix(image, s.Image+"@") {
+			return false
+		}
+	}
+
+	tags := map[string]string{}
+	for _, tag := range project.Tags {
+		tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+	}
+
+	for _, want := range s.Tags {
+		parts := strings.SplitN(want, "=", 2)
+		value, ok := tags[parts[0]]
+		if !ok || (len(parts) == 2 && value != parts[1]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// projectCache remembers the configuration of projects by name, so watching
+// the overview does not ask for it again on every poll
+type projectCache map[string]*codebuild.Project
+
+// get gives the configuration of the named projects, fetching those it has
+// not seen before. A nil cache fetches every project each time.
+func (c projectCache) get(api client.API, names []string) ([]*codebuild.Project, error) {
+	var missing []*string
+	for _, name := range names {
+		if _, ok := c[name]; !ok {
+			missing = append(missing, aws.String(name))
+		}
+	}
+
+	if len(missing) > 0 {
+		fetched, err := client.GetProjects(api, missing)
+		if err != nil {
+			return nil, err
+		}
+
+		if c == nil {
+			return fetched, nil
+		}
+
+		for _, project := range fetched {
+			c[aws.StringValue(project.Name)] = project
+		}
+	}
+
+	var projects []*codebuild.Project
+	for _, name := range names {
+		if project, ok := c[name]; ok {
+			projects = append(projects, project)
+		}
+	}
+
+	return projects, nil
+}
+
+// selectProjects narrows the project names down to those the selector
+// picks out. The configuration of every project is fetched in as few calls
+// to BatchGetProjects as we can, and only when there is something to select.
+// Projects already in the cache are not fetched again.
+func selectProjects(api client.API, names []string, selector ProjectSelector, cache projectCache) ([]string, error) {
+	if selector.empty() || len(names) == 0 {
+		return names, nil
+	}
+
+	for _, tag := range selector.Tags {
+		if strings.HasPrefix(tag, "=") || tag == "" {
+			return nil, fmt.Errorf("invalid --tag %q, expected KEY=VALUE or KEY", tag)
+		}
+	}
+
+	projects, err := cache.get(api, names)
+	if err != nil {
+		return nil, err
+	}
+
+	var selected []string
+	for _, project := range projects {
+		if selector.matches(project) {
+			selected = append(selected, aws.StringValue(project.Name))
+		}
+	}
+
+	return selected, nil
+}
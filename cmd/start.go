@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/codebuild"
+	"github.com/benmatselby/knope/client"
+	"github.com/spf13/cobra"
+)
+
+// StartBuildOptions defines what arguments/options the user can provide
+type StartBuildOptions struct {
+	Args          []string
+	Project       string
+	SourceVersion string
+	Env           []string
+	Buildspec     string
+	ComputeType   string
+	Timeout       int
+	Wait          bool
+	PollInterval  time.Duration
+}
+
+// NewStartBuildCommand creates a new `start` command
+func NewStartBuildCommand(client client.API) *cobra.Command {
+	var opts StartBuildOptions
+
+	cmd := &cobra.Command{
+		Use:   "start",
+		Short: "Start a build for a given project",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Args = args
+			return StartBuild(client, opts, os.Stdout)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&opts.Project, "project", "", "Name of the project to start a build for")
+	flags.StringVar(&opts.SourceVersion, "source-version", "", "Branch, tag, commit or pull request (e.g. pr/123) to build")
+	flags.StringArrayVar(&opts.Env, "env", nil, "Environment variable override as KEY=VALUE, optionally prefixed with PARAMETER_STORE: or SECRETS_MANAGER:")
+	flags.StringVar(&opts.Buildspec, "buildspec", "", "Path to a buildspec file to use instead of the project's")
+	flags.StringVar(&opts.ComputeType, "compute-type", "", "Compute type override (e.g. BUILD_GENERAL1_LARGE)")
+	flags.IntVar(&opts.Timeout, "timeout", 0, "Build timeout override in minutes")
+	flags.BoolVar(&opts.Wait, "wait", false, "Wait for the build to finish")
+	flags.DurationVar(&opts.PollInterval, "poll-interval", 10*time.Second, "How often to check the build status when waiting")
+
+	return cmd
+}
+
+// StartBuild will start a build and render its ID and status
+func StartBuild(api client.API, opts StartBuildOptions, w io.Writer) error {
+	if opts.Project == "" {
+		return fmt.Errorf("please specify a project name")
+	}
+
+	input := &codebuild.StartBuildInput{ProjectName: aws.String(opts.Project)}
+
+	if opts.SourceVersion != "" {
+		input.SourceVersion = aws.String(opts.SourceVersion)
+	}
+
+	for _, env := range opts.Env {
+		variable, err := parseEnvironmentVariable(env)
+		if err != nil {
+			return err
+		}
+		input.EnvironmentVariablesOverride = append(input.EnvironmentVariablesOverride, variable)
+	}
+
+	if opts.Buildspec != "" {
+		buildspec, err := ioutil.ReadFile(opts.Buildspec)
+		if err != nil {
+			return err
+		}
+		input.BuildspecOverride = aws.String(string(buildspec))
+	}
+
+	if opts.ComputeType != "" {
+		input.ComputeTypeOverride = aws.String(opts.ComputeType)
+	}
+
+	if opts.Timeout > 0 {
+		input.TimeoutInMinutesOverride = aws.Int64(int64(opts.Timeout))
+	}
+
+	started, err := api.StartBuild(input)
+	if err != nil {
+		return err
+	}
+
+	build := started.Build
+	fmt.Fprintf(w, "%s %s\n", getBuildIcon(build.BuildStatus), aws.StringValue(build.Id))
+
+	if !opts.Wait {
+		return nil
+	}
+
+	build, err = waitForBuild(api, build.Id, opts.PollInterval)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "%s %s\n", getBuildIcon(build.BuildStatus), aws.StringValue(build.Id))
+
+	return buildStatusError(build)
+}
+
+// parseEnvironmentVariable turns KEY=VALUE, or TYPE:KEY=VALUE, into an
+// environment variable CodeBuild understands.
+func parseEnvironmentVariable(value string) (*codebuild.EnvironmentVariable, error) {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return nil, fmt.Errorf("invalid environment variable %q, expected KEY=VALUE", value)
+	}
+
+	name := parts[0]
+	variableType := codebuild.EnvironmentVariableTypePlaintext
+
+	if index := strings.Index(name, ":"); index != -1 {
+		variableType = strings.ToUpper(name[:index])
+		name = name[index+1:]
+	}
+
+	switch variableType {
+	case codebuild.EnvironmentVariableTypePlaintext,
+		codebuild.EnvironmentVariableTypeParameterStore,
+		codebuild.EnvironmentVariableTypeSecretsManager:
+	default:
+		return nil, fmt.Errorf("invalid environment variable type %q", variableType)
+	}
+
+	return &codebuild.EnvironmentVariable{
+		Name:  aws.String(name),
+		Value: aws.String(parts[1]),
+		Type:  aws.String(variableType),
+	}, nil
+}
+
+// waitForBuild polls the build until it is no longer in progress
+func waitForBuild(api client.API, id *string, interval time.Duration) (*codebuild.Build, error) {
+	for {
+		build, err := getBuild(api, id)
+		if err != nil {
+			return nil, err
+		}
+
+		if aws.StringValue(build.BuildStatus) != codebuild.StatusTypeInProgress {
+			return build, nil
+		}
+
+		time.Sleep(interval)
+	}
+}
+
+// buildStatusError returns an error if the build did not succeed
+func buildStatusError(build *codebuild.Build) error {
+	if buildFailed(aws.StringValue(build.BuildStatus)) {
+		return fmt.Errorf("build %s finished with status %s", aws.StringValue(build.Id), aws.StringValue(build.BuildStatus))
+	}
+
+	return nil
+}
+
+// buildFailed is whether the status is one of a build that did not succeed
+// through no fault of the user, unlike one that was stopped
+func buildFailed(status string) bool {
+	switch status {
+	case codebuild.StatusTypeFailed, codebuild.StatusTypeFault, codebuild.StatusTypeTimedOut:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,193 @@
+package cmd_test
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/codebuild"
+	"github.com/benmatselby/knope/client"
+	"github.com/benmatselby/knope/cmd"
+	"github.com/golang/mock/gomock"
+)
+
+func TestNewStartBuildCommand(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := client.NewMockAPI(ctrl)
+
+	cmd := cmd.NewStartBuildCommand(client)
+
+	use := "start"
+	short := "Start a build for a given project"
+
+	if cmd.Use != use {
+		t.Fatalf("expected use: %s; got %s", use, cmd.Use)
+	}
+
+	if cmd.Short != short {
+		t.Fatalf("expected use: %s; got %s", short, cmd.Short)
+	}
+}
+
+func TestStartBuild(t *testing.T) {
+	tt := []struct {
+		name     string
+		opts     cmd.StartBuildOptions
+		input    *codebuild.StartBuildInput
+		statuses []string
+		expected string
+		err      string
+		startErr error
+	}{
+		{
+			name:     "can start a build",
+			opts:     cmd.StartBuildOptions{Project: "project-one"},
+			input:    &codebuild.StartBuildInput{ProjectName: aws.String("project-one")},
+			expected: "🏗 project-one:1234\n",
+		},
+		{
+			name: "can start a build with overrides",
+			opts: cmd.StartBuildOptions{
+				Project:       "project-one",
+				SourceVersion: "pr/123",
+				Env:           []string{"STAGE=dev", "PARAMETER_STORE:TOKEN=/ci/token", "secrets_manager:DB=arn:aws:secretsmanager:db"},
+				ComputeType:   "BUILD_GENERAL1_LARGE",
+				Timeout:       30,
+			},
+			input: &codebuild.StartBuildInput{
+				ProjectName:   aws.String("project-one"),
+				SourceVersion: aws.String("pr/123"),
+				EnvironmentVariablesOverride: []*codebuild.EnvironmentVariable{
+					{Name: aws.String("STAGE"), Value: aws.String("dev"), Type: aws.String("PLAINTEXT")},
+					{Name: aws.String("TOKEN"), Value: aws.String("/ci/token"), Type: aws.String("PARAMETER_STORE")},
+					{Name: aws.String("DB"), Value: aws.String("arn:aws:secretsmanager:db"), Type: aws.String("SECRETS_MANAGER")},
+				},
+				ComputeTypeOverride:      aws.String("BUILD_GENERAL1_LARGE"),
+				TimeoutInMinutesOverride: aws.Int64(30),
+			},
+			expected: "🏗 project-one:1234\n",
+		},
+		{
+			name:     "can wait for a build to succeed",
+			opts:     cmd.StartBuildOptions{Project: "project-one", Wait: true},
+			input:    &codebuild.StartBuildInput{ProjectName: aws.String("project-one")},
+			statuses: []string{"IN_PROGRESS", "SUCCEEDED"},
+			expected: "🏗 project-one:1234\n✅ project-one:1234\n",
+		},
+		{
+			name:     "returns an error when the build fails",
+			opts:     cmd.StartBuildOptions{Project: "project-one", Wait: true},
+			input:    &codebuild.StartBuildInput{ProjectName: aws.String("project-one")},
+			statuses: []string{"TIMED_OUT"},
+			expected: "🏗 project-one:1234\n🕳 project-one:1234\n",
+			err:      "build project-one:1234 finished with status TIMED_OUT",
+		},
+		{
+			name: "requires a project",
+			opts: cmd.StartBuildOptions{},
+			err:  "please specify a project name",
+		},
+		{
+			name: "rejects malformed environment variables",
+			opts: cmd.StartBuildOptions{Project: "project-one", Env: []string{"STAGE"}},
+			err:  `invalid environment variable "STAGE", expected KEY=VALUE`,
+		},
+		{
+			name: "rejects unknown environment variable types",
+			opts: cmd.StartBuildOptions{Project: "project-one", Env: []string{"VAULT:STAGE=dev"}},
+			err:  `invalid environment variable type "VAULT"`,
+		},
+		{
+			name:     "returns the error from starting the build",
+			opts:     cmd.StartBuildOptions{Project: "project-one"},
+			input:    &codebuild.StartBuildInput{ProjectName: aws.String("project-one")},
+			startErr: errors.New("there was an error"),
+			err:      "there was an error",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			client := client.NewMockAPI(ctrl)
+
+			if tc.input != nil {
+				client.
+					EXPECT().
+					StartBuild(tc.input).
+					Return(&codebuild.StartBuildOutput{Build: &codebuild.Build{
+						Id:          aws.String("project-one:1234"),
+						BuildStatus: aws.String("IN_PROGRESS"),
+					}}, tc.startErr)
+			}
+
+			for _, status := range tc.statuses {
+				client.
+					EXPECT().
+					BatchGetBuilds(&codebuild.BatchGetBuildsInput{Ids: []*string{aws.String("project-one:1234")}}).
+					Return(&codebuild.BatchGetBuildsOutput{Builds: []*codebuild.Build{{
+						Id:          aws.String("project-one:1234"),
+						BuildStatus: aws.String(status),
+					}}}, nil)
+			}
+
+			var b bytes.Buffer
+			writer := bufio.NewWriter(&b)
+
+			err := cmd.StartBuild(client, tc.opts, writer)
+			writer.Flush()
+
+			if b.String() != tc.expected {
+				t.Fatalf("expected '%s'; got '%s'", tc.expected, b.String())
+			}
+
+			if tc.err == "" && err != nil {
+				t.Fatalf("expected no error; got %v", err)
+			}
+
+			if tc.err != "" && (err == nil || err.Error() != tc.err) {
+				t.Fatalf("expected err to be %s; got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestStartBuildCanOverrideTheBuildspec(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := client.NewMockAPI(ctrl)
+
+	file, err := ioutil.TempFile("", "buildspec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+
+	buildspec := "version: 0.2\n"
+	file.WriteString(buildspec)
+	file.Close()
+
+	client.
+		EXPECT().
+		StartBuild(&codebuild.StartBuildInput{
+			ProjectName:       aws.String("project-one"),
+			BuildspecOverride: aws.String(buildspec),
+		}).
+		Return(&codebuild.StartBuildOutput{Build: &codebuild.Build{
+			Id:          aws.String("project-one:1234"),
+			BuildStatus: aws.String("IN_PROGRESS"),
+		}}, nil)
+
+	var b bytes.Buffer
+	opts := cmd.StartBuildOptions{Project: "project-one", Buildspec: file.Name()}
+
+	if err := cmd.StartBuild(client, opts, &b); err != nil {
+		t.Fatalf("expected no error; got %v", err)
+	}
+}
@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/codebuild"
+	"github.com/benmatselby/knope/client"
+	"github.com/benmatselby/knope/config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// StatsOptions defines what arguments/options the user can provide
+type StatsOptions struct {
+	Args     []string
+	Projects []string
+	Filter   string
+	Since    string
+	Selector ProjectSelector
+	Output   string
+	Template string
+	JSONPath string
+}
+
+// NewStatsCommand creates a new `stats` command
+func NewStatsCommand(client client.API) *cobra.Command {
+	var opts StatsOptions
+
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Show build statistics and success rates per project",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Args = args
+			if !cmd.Flags().Changed("filter") {
+				opts.Filter = viper.GetString(config.KeyFilter)
+			}
+			opts.Output = viper.GetString("output")
+			opts.Template = viper.GetString("template")
+			opts.JSONPath = viper.GetString("jsonpath")
+			return DisplayStats(client, opts, os.Stdout)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringSliceVar(&opts.Projects, "project", nil, "Projects to report on, defaults to every project matching --filter")
+	flags.StringVar(&opts.Filter, "filter", ".*", "Regex to filter the projects reported on")
+	flags.StringVar(&opts.Since, "since", "30d", "How far back to look, e.g. 30d, 2w, 12h or 2020-10-01")
+	addSelectorFlags(flags, &opts.Selector)
+
+	return cmd
+}
+
+// DisplayStats will render the statistics for the builds of each project
+// since a point in time
+func DisplayStats(api client.API, opts StatsOptions, w io.Writer) error {
+	since, err := parseSince(opts.Since, time.Now())
+	if err != nil {
+		return err
+	}
+
+	projects, err := windowProjects(api, opts.Projects, opts.Filter)
+	if err != nil {
+		return err
+	}
+
+	if projects, err = selectProjects(api, projects, opts.Selector, nil); err != nil {
+		return err
+	}
+
+	window, err := fetchWindow(api, projects, since)
+	if err != nil {
+		return err
+	}
+
+	records := []StatsRecord{}
+	for _, project := range projects {
+		records = append(records, newStatsRecord(project, window[project]))
+	}
+
+	sort.Slice(records, func(i, j int) bool { return records[i].Project < records[j].Project })
+
+	return render(w, renderOptions{format: opts.Output, template: opts.Template, jsonpath: opts.JSONPath}, records, statsTable)
+}
+
+// newStatsRecord works out the statistics for the builds, which are newest first.
+// Rates and durations only consider finished builds.
+func newStatsRecord(project string, builds []*codebuild.Build) StatsRecord {
+	record := StatsRecord{Project: project, Builds: len(builds)}
+
+	var durations, queued []float64
+	streak := 0
+
+	for i := len(builds) - 1; i >= 0; i-- {
+		build := builds[i]
+		status := aws.StringValue(build.BuildStatus)
+
+		for _, phase := range build.Phases {
+			if aws.StringValue(phase.PhaseType) == codebuild.BuildPhaseTypeQueued && phase.DurationInSeconds != nil {
+				queued = append(queued, float64(aws.Int64Value(phase.DurationInSeconds)))
+			}
+		}
+
+		switch status {
+		case codebuild.StatusTypeInProgress:
+			continue
+		case codebuild.StatusTypeSucceeded:
+			record.Succeeded++
+			streak = 0
+		case codebuild.StatusTypeFailed, codebuild.StatusTypeFault:
+			record.Failed++
+			streak++
+		case codebuild.StatusTypeTimedOut:
+			record.TimedOut++
+			streak++
+		case codebuild.StatusTypeStopped:
+			record.Stopped++
+		}
+
+		if streak > record.LongestFailureStreak {
+			record.LongestFailureStreak = streak
+		}
+
+		if build.StartTime != nil && build.EndTime != nil {
+			durations = append(durations, build.EndTime.Sub(*build.StartTime).Seconds())
+		}
+	}
+
+	if finished := record.Succeeded + record.Failed + record.TimedOut + record.Stopped; finished > 0 {
+		record.SuccessRate = float64(record.Succeeded) / float64(finished)
+		record.FailureRate = float64(record.Failed) / float64(finished)
+		record.TimeoutRate = float64(record.TimedOut) / float64(finished)
+	}
+
+	record.MeanDurationSeconds = mean(durations)
+	record.P50DurationSeconds = percentile(durations, 50)
+	record.P95DurationSeconds = percentile(durations, 95)
+	record.MeanQueueSeconds = mean(queued)
+
+	return record
+}
+
+// StatsRecord gives us a struct to store the statistics for a project
+type StatsRecord struct {
+	Project              string  `json:"project" yaml:"project"`
+	Builds               int     `json:"builds" yaml:"builds"`
+	Succeeded            int     `json:"succeeded" yaml:"succeeded"`
+	Failed               int     `json:"failed" yaml:"failed"`
+	TimedOut             int     `json:"timed_out" yaml:"timed_out"`
+	Stopped              int     `json:"stopped" yaml:"stopped"`
+	SuccessRate          float64 `json:"success_rate" yaml:"success_rate"`
+	FailureRate          float64 `json:"failure_rate" yaml:"failure_rate"`
+	TimeoutRate          float64 `json:"timeout_rate" yaml:"timeout_rate"`
+	MeanDurationSeconds  float64 `json:"mean_duration_seconds" yaml:"mean_duration_seconds"`
+	P50DurationSeconds   float64 `json:"p50_duration_seconds" yaml:"p50_duration_seconds"`
+	P95DurationSeconds   float64 `json:"p95_duration_seconds" yaml:"p95_duration_seconds"`
+	MeanQueueSeconds     float64 `json:"mean_queue_seconds" yaml:"mean_queue_seconds"`
+	LongestFailureStreak int     `json:"longest_failure_streak" yaml:"longest_failure_streak"`
+}
+
+// statsField adapts a StatsRecord function for use as a column value
+func statsField(f func(r StatsRecord) string) func(record interface{}) string {
+	return func(record interface{}) string {
+		return f(record.(StatsRecord))
+	}
+}
+
+var statsTable = table{columns: []column{
+	{name: "project", header: "Name", value: statsField(func(r StatsRecord) string { return r.Project })},
+	{name: "builds", header: "Builds", value: statsField(func(r StatsRecord) string { return strconv.Itoa(r.Builds) })},
+	{
+		name:   "success_rate",
+		header: "Success",
+		value:  statsField(func(r StatsRecord) string { return formatPercent(r.SuccessRate) }),
+		raw:    statsField(func(r StatsRecord) string { return strconv.FormatFloat(r.SuccessRate, 'f', 4, 64) }),
+	},
+	{
+		name:   "failure_rate",
+		header: "Failure",
+		value:  statsField(func(r StatsRecord) string { return formatPercent(r.FailureRate) }),
+		raw:    statsField(func(r StatsRecord) string { return strconv.FormatFloat(r.FailureRate, 'f', 4, 64) }),
+	},
+	{
+		name:   "timeout_rate",
+		header: "Timeout",
+		value:  statsField(func(r StatsRecord) string { return formatPercent(r.TimeoutRate) }),
+		raw:    statsField(func(r StatsRecord) string { return strconv.FormatFloat(r.TimeoutRate, 'f', 4, 64) }),
+	},
+	secondsColumn("mean_duration_seconds", "Mean", func(r StatsRecord) float64 { return r.MeanDurationSeconds }),
+	secondsColumn("p50_duration_seconds", "p50", func(r StatsRecord) float64 { return r.P50DurationSeconds }),
+	secondsColumn("p95_duration_seconds", "p95", func(r StatsRecord) float64 { return r.P95DurationSeconds }),
+	secondsColumn("mean_queue_seconds", "Queued", func(r StatsRecord) float64 { return r.MeanQueueSeconds }),
+	{name: "longest_failure_streak", header: "Streak", value: statsField(func(r StatsRecord) string { return strconv.Itoa(r.LongestFailureStreak) })},
+	{name: "succeeded", header: "Succeeded", wide: true, value: statsField(func(r StatsRecord) string { return strconv.Itoa(r.Succeeded) })},
+	{name: "failed", header: "Failed", wide: true, value: statsField(func(r StatsRecord) string { return strconv.Itoa(r.Failed) })},
+	{name: "timed_out", header: "Timed out", wide: true, value: statsField(func(r StatsRecord) string { return strconv.Itoa(r.TimedOut) })},
+	{name: "stopped", header: "Stopped", wide: true, value: statsField(func(r StatsRecord) string { return strconv.Itoa(r.Stopped) })},
+}}
+
+// secondsColumn shows a number of seconds as a duration, or as is for machines
+func secondsColumn(name, header string, f func(r StatsRecord) float64) column {
+	return column{
+		name:   name,
+		header: header,
+		value:  statsField(func(r StatsRecord) string { return formatSeconds(f(r)) }),
+		raw:    statsField(func(r StatsRecord) string { return strconv.FormatFloat(f(r), 'f', 1, 64) }),
+	}
+}
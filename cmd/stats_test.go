@@ -0,0 +1,233 @@
+package cmd_test
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/codebuild"
+	"github.com/benmatselby/knope/client"
+	"github.com/benmatselby/knope/cmd"
+	"github.com/golang/mock/gomock"
+)
+
+func TestNewStatsCommand(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := client.NewMockAPI(ctrl)
+
+	cmd := cmd.NewStatsCommand(client)
+
+	use := "stats"
+	short := "Show build statistics and success rates per project"
+
+	if cmd.Use != use {
+		t.Fatalf("expected use: %s; got %s", use, cmd.Use)
+	}
+
+	if cmd.Short != short {
+		t.Fatalf("expected use: %s; got %s", short, cmd.Short)
+	}
+}
+
+type testWindowBuild struct {
+	Status   string
+	Start    time.Time
+	Duration time.Duration
+	Queued   int64
+	Phases   []*codebuild.BuildPhase
+	Version  string
+}
+
+// testWindowBuilds returns the builds for a project, newest first, as
+// ListBuildsForProject and BatchGetBuilds would
+func testWindowBuilds(project string, builds []testWindowBuild) ([]*string, []*codebuild.Build) {
+	var ids []*string
+	var out []*codebuild.Build
+	for i, b := range builds {
+		id := aws.String(fmt.Sprintf("%s:%d", project, len(builds)-i))
+		start := b.Start
+		build := &codebuild.Build{
+			Id:                    id,
+			ProjectName:           aws.String(project),
+			BuildStatus:           aws.String(b.Status),
+			StartTime:             &start,
+			Phases:                b.Phases,
+			ResolvedSourceVersion: aws.String(b.Version),
+		}
+		if b.Status != "IN_PROGRESS" {
+			end := start.Add(b.Duration)
+			build.EndTime = &end
+		}
+		if b.Queued > 0 {
+			build.Phases = append([]*codebuild.BuildPhase{{PhaseType: aws.String("QUEUED"), DurationInSeconds: aws.Int64(b.Queued)}}, build.Phases...)
+		}
+		ids = append(ids, id)
+		out = append(out, build)
+	}
+	return ids, out
+}
+
+func TestDisplayStats(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2019, time.July, d, 9, 0, 0, 0, time.UTC) }
+
+	builds := []testWindowBuild{
+		{Status: "IN_PROGRESS", Start: day(21)},
+		{Status: "SUCCEEDED", Start: day(20), Duration: 10 * time.Minute, Queued: 30},
+		{Status: "FAILED", Start: day(19), Duration: 5 * time.Minute, Queued: 60},
+		{Status: "TIMED_OUT", Start: day(18), Duration: 20 * time.Minute},
+		{Status: "FAILED", Start: day(17), Duration: 5 * time.Minute},
+		{Status: "SUCCEEDED", Start: day(16), Duration: 10 * time.Minute},
+		{Status: "SUCCEEDED", Start: time.Date(2019, time.June, 1, 9, 0, 0, 0, time.UTC), Duration: time.Hour},
+	}
+
+	tt := []struct {
+		name     string
+		opts     cmd.StatsOptions
+		listErr  error
+		expected string
+		err      string
+	}{
+		{
+			name: "can report the statistics for a project",
+			opts: cmd.StatsOptions{Projects: []string{"project-one"}, Since: "2019-07-01"},
+			expected: `Name        Builds Success Failure Timeout Mean  p50   p95   Queued Streak
+project-one 6      40.0%   40.0%   20.0%   10m0s 10m0s 20m0s 45s    3
+`,
+		},
+		{
+			name: "can report the statistics as csv",
+			opts: cmd.StatsOptions{Projects: []string{"project-one"}, Since: "2019-07-01", Output: "csv"},
+			expected: `project,builds,success_rate,failure_rate,timeout_rate,mean_duration_seconds,p50_duration_seconds,p95_duration_seconds,mean_queue_seconds,longest_failure_streak,succeeded,failed,timed_out,stopped
+project-one,6,0.4000,0.4000,0.2000,600.0,600.0,1200.0,45.0,3,2,2,1,0
+`,
+		},
+		{
+			name: "can report on projects matching the filter",
+			opts: cmd.StatsOptions{Filter: "one", Since: "2019-07-18"},
+			expected: `Name        Builds Success Failure Timeout Mean   p50   p95   Queued Streak
+project-one 4      33.3%   33.3%   33.3%   11m40s 10m0s 20m0s 45s    2
+`,
+		},
+		{
+			name: "can report on projects picked out by tag",
+			opts: cmd.StatsOptions{Selector: cmd.ProjectSelector{Tags: []string{"team=payments"}}, Since: "2019-07-18"},
+			expected: `Name        Builds Success Failure Timeout Mean   p50   p95   Queued Streak
+project-one 4      33.3%   33.3%   33.3%   11m40s 10m0s 20m0s 45s    2
+`,
+		},
+		{
+			name:    "returns the error from listing builds",
+			opts:    cmd.StatsOptions{Projects: []string{"project-one"}, Since: "30d"},
+			listErr: errors.New("there was an error"),
+			err:     "unable to get the builds for project-one: there was an error",
+		},
+		{
+			name: "tells you when since is not understood",
+			opts: cmd.StatsOptions{Projects: []string{"project-one"}, Since: "yesterday"},
+			err:  `invalid --since "yesterday", expected something like 30d, 2w, 12h or 2020-10-01`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			client := client.NewMockAPI(ctrl)
+
+			ids, out := testWindowBuilds("project-one", builds)
+
+			client.
+				EXPECT().
+				ListProjects(gomock.Any()).
+				Return(&codebuild.ListProjectsOutput{Projects: aws.StringSlice([]string{"project-one", "project-two"})}, nil).
+				AnyTimes()
+
+			client.
+				EXPECT().
+				BatchGetProjects(gomock.Any()).
+				Return(&codebuild.BatchGetProjectsOutput{Projects: []*codebuild.Project{
+					{Name: aws.String("project-one"), Tags: []*codebuild.Tag{{Key: aws.String("team"), Value: aws.String("payments")}}},
+					{Name: aws.String("project-two")},
+				}}, nil).
+				AnyTimes()
+
+			client.
+				EXPECT().
+				ListBuildsForProject(gomock.Any()).
+				Return(&codebuild.ListBuildsForProjectOutput{Ids: ids}, tc.listErr).
+				AnyTimes()
+
+			client.
+				EXPECT().
+				BatchGetBuilds(gomock.Any()).
+				Return(&codebuild.BatchGetBuildsOutput{Builds: out}, nil).
+				AnyTimes()
+
+			var b bytes.Buffer
+			writer := bufio.NewWriter(&b)
+
+			err := cmd.DisplayStats(client, tc.opts, writer)
+			writer.Flush()
+
+			if b.String() != tc.expected {
+				t.Fatalf("expected '%s'; got '%s'", tc.expected, b.String())
+			}
+
+			if tc.err == "" && err != nil {
+				t.Fatalf("expected no error; got %v", err)
+			}
+
+			if tc.err != "" && (err == nil || err.Error() != tc.err) {
+				t.Fatalf("expected err to be %s; got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestDisplayStatsLimitsConcurrentProjects(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := client.NewMockAPI(ctrl)
+
+	var projects []string
+	for i := 0; i < 50; i++ {
+		projects = append(projects, fmt.Sprintf("project-%d", i))
+	}
+
+	var mu sync.Mutex
+	inFlight, most := 0, 0
+	client.
+		EXPECT().
+		ListBuildsForProject(gomock.Any()).
+		DoAndReturn(func(input *codebuild.ListBuildsForProjectInput) (*codebuild.ListBuildsForProjectOutput, error) {
+			mu.Lock()
+			inFlight++
+			if inFlight > most {
+				most = inFlight
+			}
+			mu.Unlock()
+
+			time.Sleep(time.Millisecond)
+
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+			return &codebuild.ListBuildsForProjectOutput{}, nil
+		}).
+		Times(len(projects))
+
+	var b bytes.Buffer
+	if err := cmd.DisplayStats(client, cmd.StatsOptions{Projects: projects, Since: "2019-07-01"}, &b); err != nil {
+		t.Fatalf("expected no error; got %v", err)
+	}
+
+	if most > 8 {
+		t.Fatalf("expected at most 8 projects to be fetched at once; got %d", most)
+	}
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/codebuild"
+	"github.com/benmatselby/knope/client"
+	"github.com/benmatselby/knope/ui"
+	"github.com/spf13/cobra"
+)
+
+// StopBuildOptions defines what arguments/options the user can provide
+type StopBuildOptions struct {
+	Args          []string
+	Project       string
+	AllInProgress bool
+	Yes           bool
+}
+
+// NewStopBuildCommand creates a new `stop` command
+func NewStopBuildCommand(client client.API) *cobra.Command {
+	var opts StopBuildOptions
+
+	cmd := &cobra.Command{
+		Use:   "stop [build-id|project:latest]",
+		Short: "Stop an in progress build",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Args = args
+			return StopBuild(client, opts, os.Stdin, os.Stdout)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&opts.Project, "project", "", "Name of the project to stop builds for, used with --all-in-progress")
+	flags.BoolVar(&opts.AllInProgress, "all-in-progress", false, "Stop every in progress build for the project")
+	flags.BoolVarP(&opts.Yes, "yes", "y", false, "Do not ask for confirmation")
+
+	return cmd
+}
+
+// StopBuild will stop the build(s) asked for and render what was stopped
+func StopBuild(api client.API, opts StopBuildOptions, r io.Reader, w io.Writer) error {
+	var ids []*string
+
+	if opts.AllInProgress {
+		if opts.Project == "" {
+			return fmt.Errorf("please specify a project name")
+		}
+
+		iter := client.NewBuildIterator(api, &codebuild.ListBuildsForProjectInput{
+			ProjectName: aws.String(opts.Project),
+		}, 0)
+		for iter.Next() {
+			if aws.StringValue(iter.Build().BuildStatus) == codebuild.StatusTypeInProgress {
+				ids = append(ids, iter.Build().Id)
+			}
+		}
+		if err := iter.Err(); err != nil {
+			return err
+		}
+
+		if len(ids) == 0 {
+			fmt.Fprintf(w, "There are no in progress builds for %s\n", opts.Project)
+			return nil
+		}
+
+		if !opts.Yes && !confirm(r, w, fmt.Sprintf("Stop %d in progress build(s) for %s?", len(ids), opts.Project)) {
+			return nil
+		}
+	} else {
+		if len(opts.Args) == 0 {
+			return fmt.Errorf("please specify a build id")
+		}
+
+		id, err := resolveBuildID(api, opts.Args[0])
+		if err != nil {
+			return err
+		}
+		ids = append(ids, id)
+	}
+
+	for _, id := range ids {
+		stopped, err := api.StopBuild(&codebuild.StopBuildInput{Id: id})
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(w, "%s %s\n", ui.AppStale, aws.StringValue(stopped.Build.Id))
+	}
+
+	return nil
+}
+
+// confirm asks the user a yes/no question, defaulting to no
+func confirm(r io.Reader, w io.Writer, question string) bool {
+	fmt.Fprintf(w, "%s [y/N] ", question)
+
+	answer, _ := bufio.NewReader(r).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+
+	return answer == "y" || answer == "yes"
+}
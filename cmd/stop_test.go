@@ -0,0 +1,163 @@
+package cmd_test
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/codebuild"
+	"github.com/benmatselby/knope/client"
+	"github.com/benmatselby/knope/cmd"
+	"github.com/golang/mock/gomock"
+)
+
+func TestNewStopBuildCommand(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := client.NewMockAPI(ctrl)
+
+	cmd := cmd.NewStopBuildCommand(client)
+
+	use := "stop [build-id|project:latest]"
+	short := "Stop an in progress build"
+
+	if cmd.Use != use {
+		t.Fatalf("expected use: %s; got %s", use, cmd.Use)
+	}
+
+	if cmd.Short != short {
+		t.Fatalf("expected use: %s; got %s", short, cmd.Short)
+	}
+}
+
+func TestStopBuild(t *testing.T) {
+	tt := []struct {
+		name     string
+		opts     cmd.StopBuildOptions
+		input    string
+		builds   map[string]string
+		stopped  []string
+		expected string
+		err      string
+		stopErr  error
+	}{
+		{
+			name:     "can stop a build by id",
+			opts:     cmd.StopBuildOptions{Args: []string{"project-one:1"}},
+			stopped:  []string{"project-one:1"},
+			expected: "🕳 project-one:1\n",
+		},
+		{
+			name:     "can stop the latest build for a project",
+			opts:     cmd.StopBuildOptions{Args: []string{"project-one:latest"}},
+			builds:   map[string]string{"project-one:2": "IN_PROGRESS"},
+			stopped:  []string{"project-one:2"},
+			expected: "🕳 project-one:2\n",
+		},
+		{
+			name:     "can stop all in progress builds after confirmation",
+			opts:     cmd.StopBuildOptions{Project: "project-one", AllInProgress: true},
+			input:    "y\n",
+			builds:   map[string]string{"project-one:2": "IN_PROGRESS", "project-one:1": "SUCCEEDED"},
+			stopped:  []string{"project-one:2"},
+			expected: "Stop 1 in progress build(s) for project-one? [y/N] 🕳 project-one:2\n",
+		},
+		{
+			name:     "can skip the confirmation",
+			opts:     cmd.StopBuildOptions{Project: "project-one", AllInProgress: true, Yes: true},
+			builds:   map[string]string{"project-one:2": "IN_PROGRESS", "project-one:1": "SUCCEEDED"},
+			stopped:  []string{"project-one:2"},
+			expected: "🕳 project-one:2\n",
+		},
+		{
+			name:     "does not stop anything without confirmation",
+			opts:     cmd.StopBuildOptions{Project: "project-one", AllInProgress: true},
+			input:    "\n",
+			builds:   map[string]string{"project-one:2": "IN_PROGRESS"},
+			expected: "Stop 1 in progress build(s) for project-one? [y/N] ",
+		},
+		{
+			name:     "tells you when there is nothing to stop",
+			opts:     cmd.StopBuildOptions{Project: "project-one", AllInProgress: true},
+			builds:   map[string]string{"project-one:1": "SUCCEEDED"},
+			expected: "There are no in progress builds for project-one\n",
+		},
+		{
+			name: "requires a project when stopping all in progress builds",
+			opts: cmd.StopBuildOptions{AllInProgress: true},
+			err:  "please specify a project name",
+		},
+		{
+			name: "requires a build id",
+			opts: cmd.StopBuildOptions{},
+			err:  "please specify a build id",
+		},
+		{
+			name:    "returns the error from stopping the build",
+			opts:    cmd.StopBuildOptions{Args: []string{"project-one:1"}},
+			stopped: []string{"project-one:1"},
+			stopErr: errors.New("there was an error"),
+			err:     "there was an error",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			client := client.NewMockAPI(ctrl)
+
+			var ids []*string
+			var builds []*codebuild.Build
+			for _, id := range []string{"project-one:2", "project-one:1"} {
+				if status, ok := tc.builds[id]; ok {
+					ids = append(ids, aws.String(id))
+					builds = append(builds, &codebuild.Build{Id: aws.String(id), BuildStatus: aws.String(status)})
+				}
+			}
+
+			client.
+				EXPECT().
+				ListBuildsForProject(gomock.Any()).
+				Return(&codebuild.ListBuildsForProjectOutput{Ids: ids}, nil).
+				AnyTimes()
+
+			client.
+				EXPECT().
+				BatchGetBuilds(gomock.Any()).
+				Return(&codebuild.BatchGetBuildsOutput{Builds: builds}, nil).
+				AnyTimes()
+
+			for _, id := range tc.stopped {
+				client.
+					EXPECT().
+					StopBuild(&codebuild.StopBuildInput{Id: aws.String(id)}).
+					Return(&codebuild.StopBuildOutput{Build: &codebuild.Build{
+						Id:          aws.String(id),
+						BuildStatus: aws.String("IN_PROGRESS"),
+					}}, tc.stopErr)
+			}
+
+			var b bytes.Buffer
+			writer := bufio.NewWriter(&b)
+
+			err := cmd.StopBuild(client, tc.opts, strings.NewReader(tc.input), writer)
+			writer.Flush()
+
+			if b.String() != tc.expected {
+				t.Fatalf("expected '%s'; got '%s'", tc.expected, b.String())
+			}
+
+			if tc.err == "" && err != nil {
+				t.Fatalf("expected no error; got %v", err)
+			}
+
+			if tc.err != "" && (err == nil || err.Error() != tc.err) {
+				t.Fatalf("expected err to be %s; got %v", tc.err, err)
+			}
+		})
+	}
+}
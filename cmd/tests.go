@@ -0,0 +1,203 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/codebuild"
+	"github.com/benmatselby/knope/client"
+	"github.com/benmatselby/knope/ui"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// failedTestStatuses are the statuses of test cases which count as failed
+var failedTestStatuses = []string{"FAILED", "ERROR"}
+
+// TestsOptions defines what arguments/options the user can provide
+type TestsOptions struct {
+	Args     []string
+	All      bool
+	Output   string
+	Template string
+	JSONPath string
+}
+
+// NewTestsCommand creates a new `tests` command
+func NewTestsCommand(client client.API) *cobra.Command {
+	var opts TestsOptions
+
+	cmd := &cobra.Command{
+		Use:   "tests [build-id|project:latest|report-arn]",
+		Short: "Show the failed test cases for a build or test report",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Args = args
+			opts.Output = viper.GetString("output")
+			opts.Template = viper.GetString("template")
+			opts.JSONPath = viper.GetString("jsonpath")
+			return DisplayTests(client, opts, os.Stdout)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.BoolVar(&opts.All, "all", false, "Show every test case, not just those that failed")
+
+	return cmd
+}
+
+// DisplayTests will render the test cases, with their messages, from every
+// test report of a build, or from a single report
+func DisplayTests(api client.API, opts TestsOptions, w io.Writer) error {
+	if len(opts.Args) == 0 {
+		return fmt.Errorf("please specify a build id or report arn")
+	}
+
+	var reports []*string
+	if strings.HasPrefix(opts.Args[0], "arn:") {
+		reports = append(reports, aws.String(opts.Args[0]))
+	} else {
+		found, err := buildReports(api, opts.Args[0], codebuild.ReportTypeTest)
+		if err != nil {
+			return err
+		}
+		for _, report := range found {
+			reports = append(reports, report.Arn)
+		}
+	}
+
+	// A filter only takes one status, so ask for each of those counted as
+	// failed in the reports, or for everything
+	filters := []*codebuild.TestCaseFilter{nil}
+	if !opts.All {
+		filters = nil
+		for _, status := range failedTestStatuses {
+			filters = append(filters, &codebuild.TestCaseFilter{Status: aws.String(status)})
+		}
+	}
+
+	records := []TestCaseRecord{}
+	for _, report := range reports {
+		for _, filter := range filters {
+			testCases, err := fetchTestCases(api, report, filter)
+			if err != nil {
+				return err
+			}
+
+			for _, testCase := range testCases {
+				records = append(records, newTestCaseRecord(reportGroupName(aws.StringValue(report)), testCase))
+			}
+		}
+	}
+
+	return render(w, renderOptions{format: opts.Output, template: opts.Template, jsonpath: opts.JSONPath}, records, testCasesTable)
+}
+
+// fetchTestCases pages through the test cases of a report
+func fetchTestCases(api client.API, report *string, filter *codebuild.TestCaseFilter) ([]*codebuild.TestCase, error) {
+	var testCases []*codebuild.TestCase
+
+	input := &codebuild.DescribeTestCasesInput{ReportArn: report, Filter: filter}
+	for {
+		out, err := api.DescribeTestCases(input)
+		if err != nil {
+			return nil, err
+		}
+
+		testCases = append(testCases, out.TestCases...)
+
+		if aws.StringValue(out.NextToken) == "" {
+			return testCases, nil
+		}
+		input.NextToken = out.NextToken
+	}
+}
+
+// reportGroupName works out the report group from a report ARN, such as
+// arn:aws:codebuild:eu-west-2:123456789012:report/unit:1234
+func reportGroupName(arn string) string {
+	name := arnResource(arn)
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		return name[:i]
+	}
+	return name
+}
+
+// TestCaseRecord gives us a struct to store a test case from a test report
+type TestCaseRecord struct {
+	Group           string  `json:"group" yaml:"group"`
+	Prefix          string  `json:"prefix,omitempty" yaml:"prefix,omitempty"`
+	Name            string  `json:"name" yaml:"name"`
+	Status          string  `json:"status" yaml:"status"`
+	DurationSeconds float64 `json:"duration_seconds" yaml:"duration_seconds"`
+	Message         string  `json:"message,omitempty" yaml:"message,omitempty"`
+}
+
+// newTestCaseRecord flattens a test case into a record
+func newTestCaseRecord(group string, testCase *codebuild.TestCase) TestCaseRecord {
+	return TestCaseRecord{
+		Group:           group,
+		Prefix:          aws.StringValue(testCase.Prefix),
+		Name:            aws.StringValue(testCase.Name),
+		Status:          aws.StringValue(testCase.Status),
+		DurationSeconds: float64(aws.Int64Value(testCase.DurationInNanoSeconds)) / float64(time.Second),
+		Message:         aws.StringValue(testCase.Message),
+	}
+}
+
+// Icon is the status of the test case as an icon. Test cases use their own
+// statuses, such as ERROR and SKIPPED.
+func (r TestCaseRecord) Icon() string {
+	switch r.Status {
+	case "SUCCEEDED":
+		return ui.AppSuccess
+	case "FAILED", "ERROR":
+		return ui.AppFailure
+	case "SKIPPED":
+		return ui.AppStale
+	default:
+		return ui.AppUnknown
+	}
+}
+
+// Summary is the first line of the message, which is all that fits in a table
+func (r TestCaseRecord) Summary() string {
+	return strings.TrimSpace(strings.SplitN(strings.TrimSpace(r.Message), "\n", 2)[0])
+}
+
+// testCaseField adapts a TestCaseRecord function for use as a column value
+func testCaseField(f func(r TestCaseRecord) string) func(record interface{}) string {
+	return func(record interface{}) string {
+		return f(record.(TestCaseRecord))
+	}
+}
+
+var testCasesTable = table{columns: []column{
+	{
+		name:   "status",
+		header: "Status",
+		icon:   true,
+		value:  testCaseField(func(r TestCaseRecord) string { return r.Icon() }),
+		raw:    testCaseField(func(r TestCaseRecord) string { return r.Status }),
+	},
+	{name: "group", header: "Group", wide: true, value: testCaseField(func(r TestCaseRecord) string { return r.Group })},
+	{name: "prefix", header: "Prefix", value: testCaseField(func(r TestCaseRecord) string { return r.Prefix })},
+	{name: "name", header: "Name", value: testCaseField(func(r TestCaseRecord) string { return r.Name })},
+	{
+		name:   "duration_seconds",
+		header: "Duration",
+		value:  testCaseField(func(r TestCaseRecord) string { return formatTestSeconds(r.DurationSeconds) }),
+		raw:    testCaseField(func(r TestCaseRecord) string { return strconv.FormatFloat(r.DurationSeconds, 'f', 3, 64) }),
+	},
+	{
+		name:   "message",
+		header: "Message",
+		value:  testCaseField(func(r TestCaseRecord) string { return r.Summary() }),
+		raw:    testCaseField(func(r TestCaseRecord) string { return r.Message }),
+	},
+}}
@@ -0,0 +1,184 @@
+package cmd_test
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/codebuild"
+	"github.com/benmatselby/knope/client"
+	"github.com/benmatselby/knope/cmd"
+	"github.com/golang/mock/gomock"
+)
+
+func TestNewTestsCommand(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := client.NewMockAPI(ctrl)
+
+	cmd := cmd.NewTestsCommand(client)
+
+	use := "tests [build-id|project:latest|report-arn]"
+	short := "Show the failed test cases for a build or test report"
+
+	if cmd.Use != use {
+		t.Fatalf("expected use: %s; got %s", use, cmd.Use)
+	}
+
+	if cmd.Short != short {
+		t.Fatalf("expected use: %s; got %s", short, cmd.Short)
+	}
+}
+
+func TestDisplayTests(t *testing.T) {
+	testCases := []*codebuild.TestCase{
+		{
+			ReportArn:             aws.String(testUnitARN),
+			Prefix:                aws.String("knope/cmd"),
+			Name:                  aws.String("TestDisplayBuild"),
+			Status:                aws.String("FAILED"),
+			DurationInNanoSeconds: aws.Int64(250000000),
+			Message:               aws.String("build_test.go:42: expected 'a'; got 'b'\nmore detail"),
+		},
+		{
+			ReportArn:             aws.String(testUnitARN),
+			Prefix:                aws.String("knope/cmd"),
+			Name:                  aws.String("TestDisplayLogs"),
+			Status:                aws.String("SUCCEEDED"),
+			DurationInNanoSeconds: aws.Int64(10000000),
+		},
+		{
+			ReportArn:             aws.String(testUnitARN),
+			Prefix:                aws.String("knope/cmd"),
+			Name:                  aws.String("TestFollowLogs"),
+			Status:                aws.String("ERROR"),
+			DurationInNanoSeconds: aws.Int64(5000000),
+			Message:               aws.String("panic: runtime error: invalid memory address"),
+		},
+	}
+
+	tt := []struct {
+		name         string
+		opts         cmd.TestsOptions
+		statusesSent []string
+		pageErr      error
+		expected     string
+		err          string
+	}{
+		{
+			name:         "can show the failed and errored test cases for a build",
+			opts:         cmd.TestsOptions{Args: []string{"project-one:1"}},
+			statusesSent: []string{"FAILED", "ERROR"},
+			expected: `Status  Prefix    Name             Duration Message
+❌       knope/cmd TestDisplayBuild 250ms    build_test.go:42: expected 'a'; got 'b'
+❌       knope/cmd TestFollowLogs   5ms      panic: runtime error: invalid memory address
+`,
+		},
+		{
+			name:         "can show every test case for a report",
+			opts:         cmd.TestsOptions{Args: []string{testUnitARN}, All: true, Output: "wide"},
+			statusesSent: []string{""},
+			expected: `Status  Group            Prefix    Name             Duration Message
+❌       project-one-unit knope/cmd TestDisplayBuild 250ms    build_test.go:42: expected 'a'; got 'b'
+✅       project-one-unit knope/cmd TestDisplayLogs  10ms     
+❌       project-one-unit knope/cmd TestFollowLogs   5ms      panic: runtime error: invalid memory address
+`,
+		},
+		{
+			name:    "returns the error from describing the test cases",
+			opts:    cmd.TestsOptions{Args: []string{testUnitARN}},
+			pageErr: errors.New("there was an error"),
+			err:     "there was an error",
+		},
+		{
+			name: "needs a build or report",
+			opts: cmd.TestsOptions{},
+			err:  "please specify a build id or report arn",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			client := client.NewMockAPI(ctrl)
+
+			var statusesSent []string
+
+			client.
+				EXPECT().
+				BatchGetBuilds(gomock.Any()).
+				Return(&codebuild.BatchGetBuildsOutput{Builds: []*codebuild.Build{
+					{Id: aws.String("project-one:1"), ReportArns: aws.StringSlice([]string{testUnitARN, testCoverageARN})},
+				}}, nil).
+				AnyTimes()
+
+			client.
+				EXPECT().
+				BatchGetReports(gomock.Any()).
+				Return(&codebuild.BatchGetReportsOutput{Reports: testReports()}, nil).
+				AnyTimes()
+
+			client.
+				EXPECT().
+				DescribeTestCases(gomock.Any()).
+				DoAndReturn(func(input *codebuild.DescribeTestCasesInput) (*codebuild.DescribeTestCasesOutput, error) {
+					if aws.StringValue(input.ReportArn) != testUnitARN {
+						t.Fatalf("expected the test cases for %s; got %s", testUnitARN, aws.StringValue(input.ReportArn))
+					}
+
+					if tc.pageErr != nil {
+						return nil, tc.pageErr
+					}
+
+					status := ""
+					if input.Filter != nil {
+						status = aws.StringValue(input.Filter.Status)
+					}
+
+					var matches []*codebuild.TestCase
+					for _, testCase := range testCases {
+						if status == "" || aws.StringValue(testCase.Status) == status {
+							matches = append(matches, testCase)
+						}
+					}
+
+					if aws.StringValue(input.NextToken) == "" {
+						statusesSent = append(statusesSent, status)
+						if len(matches) > 1 {
+							return &codebuild.DescribeTestCasesOutput{TestCases: matches[:1], NextToken: aws.String("page-2")}, nil
+						}
+						return &codebuild.DescribeTestCasesOutput{TestCases: matches}, nil
+					}
+
+					return &codebuild.DescribeTestCasesOutput{TestCases: matches[1:]}, nil
+				}).
+				AnyTimes()
+
+			var b bytes.Buffer
+			writer := bufio.NewWriter(&b)
+
+			err := cmd.DisplayTests(client, tc.opts, writer)
+			writer.Flush()
+
+			if b.String() != tc.expected {
+				t.Fatalf("expected '%s'; got '%s'", tc.expected, b.String())
+			}
+
+			if tc.err == "" && err != nil {
+				t.Fatalf("expected no error; got %v", err)
+			}
+
+			if tc.err != "" && (err == nil || err.Error() != tc.err) {
+				t.Fatalf("expected err to be %s; got %v", tc.err, err)
+			}
+
+			if tc.err == "" && !reflect.DeepEqual(statusesSent, tc.statusesSent) {
+				t.Fatalf("expected the status filters to be %q; got %q", tc.statusesSent, statusesSent)
+			}
+		})
+	}
+}
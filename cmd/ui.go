@@ -0,0 +1,573 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go/service/codebuild"
+	"github.com/benmatselby/knope/client"
+	"github.com/benmatselby/knope/config"
+	"github.com/benmatselby/knope/ui"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// UIOptions defines what arguments/options the user can provide
+type UIOptions struct {
+	Args       []string
+	Filter     string
+	Favourites []string
+	Limit      int
+}
+
+const (
+	pageProjects = "projects"
+	pageBuilds   = "builds"
+	pageBuild    = "build"
+	pageConfirm  = "confirm"
+
+	// browserLogLines is how much of the log we show at the bottom of a build
+	browserLogLines = 100
+)
+
+// NewUICommand creates a new `ui` command
+func NewUICommand(client client.API, logs client.LogsAPI) *cobra.Command {
+	var opts UIOptions
+
+	cmd := &cobra.Command{
+		Use:   "ui",
+		Short: "Browse projects and builds in an interactive terminal UI",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Args = args
+			if !cmd.Flags().Changed("filter") {
+				opts.Filter = viper.GetString(config.KeyFilter)
+			}
+			opts.Favourites = viper.GetStringSlice(config.KeyFavourites)
+			return NewBrowser(client, logs, opts).Run()
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&opts.Filter, "filter", ".*", "Regex to filter the projects displayed")
+	flags.IntVar(&opts.Limit, "limit", 50, "Maximum number of builds to show for a project")
+
+	return cmd
+}
+
+// Browser is the interactive terminal UI. It lists the projects, lets you
+// drill into their builds and then a single build, and start, stop or
+// retry builds along the way. Everything that talks to AWS happens in the
+// background, so a slow or throttled call never freezes the UI.
+type Browser struct {
+	api  client.API
+	logs client.LogsAPI
+	opts UIOptions
+
+	app      *tview.Application
+	root     *tview.Flex
+	header   *tview.TextView
+	footer   *tview.TextView
+	pages    *tview.Pages
+	projects *tview.Table
+	builds   *tview.Table
+	build    *tview.TextView
+
+	page          string
+	project       string
+	buildID       string
+	projectRecord []BuildRecord
+	buildRecords  []BuildRecord
+	// message is how the last action went, until the user moves page
+	message string
+	// err is why the current page could not be loaded, if it could not
+	err error
+
+	// loading counts the fetches in the background, whose results are
+	// applied on the UI goroutine through updates
+	loading  int
+	updates  chan func()
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewBrowser creates the terminal UI. Nothing is loaded until it is started.
+func NewBrowser(api client.API, logs client.LogsAPI, opts UIOptions) *Browser {
+	b := &Browser{
+		api:      api,
+		logs:     logs,
+		opts:     opts,
+		app:      tview.NewApplication(),
+		header:   tview.NewTextView(),
+		footer:   tview.NewTextView(),
+		pages:    tview.NewPages(),
+		projects: tview.NewTable().SetSelectable(true, false).SetFixed(1, 0),
+		builds:   tview.NewTable().SetSelectable(true, false).SetFixed(1, 0),
+		build:    tview.NewTextView().SetScrollable(true),
+		updates:  make(chan func()),
+		done:     make(chan struct{}),
+	}
+
+	b.projects.SetSelectedFunc(func(row, column int) {
+		if project := b.selectedProject(); project != "" {
+			b.showBuilds(project)
+		}
+	})
+
+	b.builds.SetSelectedFunc(func(row, column int) {
+		if build := b.selectedBuild(); build != "" {
+			b.showBuild(build)
+		}
+	})
+
+	b.pages.
+		AddPage(pageProjects, b.projects, true, true).
+		AddPage(pageBuilds, b.builds, true, false).
+		AddPage(pageBuild, b.build, true, false)
+
+	b.root = tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(b.header, 1, 0, false).
+		AddItem(b.pages, 0, 1, true).
+		AddItem(b.footer, 1, 0, false)
+
+	b.app.SetRoot(b.root, true).SetInputCapture(b.capture)
+
+	return b
+}
+
+// Run starts the UI, blocking until the user quits
+func (b *Browser) Run() error {
+	defer b.stop()
+
+	go func() {
+		for {
+			select {
+			case update := <-b.updates:
+				b.app.QueueUpdateDraw(update)
+			case <-b.done:
+				return
+			}
+		}
+	}()
+
+	b.Start()
+	return b.app.Run()
+}
+
+// Start shows the projects, loading them in the background
+func (b *Browser) Start() {
+	b.showProjects()
+}
+
+// Sync waits for everything loading in the background and applies it, as
+// Run would on the UI goroutine, which is handy for testing
+func (b *Browser) Sync() {
+	for b.loading > 0 {
+		(<-b.updates)()
+	}
+}
+
+// stop lets anything still loading give up once the UI has gone
+func (b *Browser) stop() {
+	b.stopOnce.Do(func() { close(b.done) })
+}
+
+// load runs fetch in the background and then applies what it returns on
+// the UI goroutine, showing that something is loading in the meantime
+func (b *Browser) load(fetch func() func()) {
+	b.loading++
+	b.updateBars()
+
+	go func() {
+		apply := fetch()
+		update := func() {
+			b.loading--
+			apply()
+			b.updateBars()
+		}
+
+		select {
+		case b.updates <- update:
+		case <-b.done:
+		}
+	}()
+}
+
+// Draw renders the UI onto the screen, which is handy for testing
+func (b *Browser) Draw(screen tcell.Screen) {
+	width, height := screen.Size()
+	b.root.SetRect(0, 0, width, height)
+	b.root.Draw(screen)
+	screen.Show()
+}
+
+// HandleKey processes a key press as if it came from the terminal
+func (b *Browser) HandleKey(event *tcell.EventKey) {
+	if event = b.capture(event); event == nil {
+		return
+	}
+
+	if focus := b.app.GetFocus(); focus != nil {
+		if handler := focus.InputHandler(); handler != nil {
+			handler(event, func(p tview.Primitive) { b.app.SetFocus(p) })
+		}
+	}
+}
+
+// capture handles the shortcuts, returning nil when the key was used
+func (b *Browser) capture(event *tcell.EventKey) *tcell.EventKey {
+	if b.page == pageConfirm {
+		return event
+	}
+
+	if event.Key() == tcell.KeyEscape {
+		b.back()
+		return nil
+	}
+
+	if event.Key() != tcell.KeyRune {
+		return event
+	}
+
+	switch event.Rune() {
+	case 'q':
+		b.stop()
+		b.app.Stop()
+	case 'R':
+		b.refresh()
+	case 's':
+		project := b.project
+		if b.page == pageProjects {
+			project = b.selectedProject()
+		}
+		if project != "" {
+			b.confirm(fmt.Sprintf("Start a build of %s?", project), func() { b.startBuild(project) })
+		}
+	case 'x':
+		if id := b.currentBuild(); id != "" {
+			b.confirm(fmt.Sprintf("Stop %s?", id), func() { b.stopBuild(id) })
+		}
+	case 'r':
+		if id := b.currentBuild(); id != "" {
+			b.confirm(fmt.Sprintf("Retry %s?", id), func() { b.retryBuild(id) })
+		}
+	default:
+		return event
+	}
+
+	return nil
+}
+
+// back goes up a level
+func (b *Browser) back() {
+	switch b.page {
+	case pageBuild:
+		b.showBuilds(b.project)
+	case pageBuilds:
+		b.showProjects()
+	}
+}
+
+// refresh reloads the current page
+func (b *Browser) refresh() {
+	switch b.page {
+	case pageBuild:
+		b.showBuild(b.buildID)
+	case pageBuilds:
+		b.showBuilds(b.project)
+	default:
+		b.showProjects()
+	}
+}
+
+// showProjects lists the projects with the status of their last build
+func (b *Browser) showProjects() {
+	if b.projects.GetRowCount() == 0 {
+		setHeaderRow(b.projects, "Status", "Project", "Started", "Finished")
+	}
+	b.switchTo(pageProjects, b.projects)
+
+	b.load(func() func() {
+		records, err := fetchOverview(b.api, OverviewOptions{Filter: b.opts.Filter, Favourites: b.opts.Favourites}, nil)
+		return func() {
+			if b.page == pageProjects {
+				b.err = err
+			}
+			if err != nil {
+				return
+			}
+			b.projectRecord = records
+
+			row, _ := b.projects.GetSelection()
+			b.projects.Clear()
+			setHeaderRow(b.projects, "Status", "Project", "Started", "Finished")
+			for i, record := range records {
+				setRow(b.projects, i+1, record.Icon(), record.Project, formatRecordTime(record, record.Start), formatRecordTime(record, record.Finish))
+			}
+			selectRow(b.projects, row)
+		}
+	})
+}
+
+// showBuilds lists the builds for a project
+func (b *Browser) showBuilds(project string) {
+	if project != b.project {
+		// Rather than showing the builds of the last project while loading
+		b.buildRecords = nil
+		b.builds.Clear()
+		setHeaderRow(b.builds, "Status", "Build", "Source version", "Commit", "Started", "Duration")
+		b.builds.Select(1, 0)
+	}
+	b.project = project
+	b.switchTo(pageBuilds, b.builds)
+
+	b.load(func() func() {
+		var records []BuildRecord
+		iter := client.NewBuildIterator(b.api, &codebuild.ListBuildsForProjectInput{ProjectName: aws.String(project)}, b.opts.Limit)
+		for iter.Next() {
+			records = append(records, newBuildRecord(iter.Build()))
+		}
+
+		return func() {
+			// The user has moved on to another project since
+			if project != b.project {
+				return
+			}
+			if b.page == pageBuilds {
+				b.err = iter.Err()
+			}
+			if iter.Err() != nil {
+				return
+			}
+			b.buildRecords = records
+
+			row, _ := b.builds.GetSelection()
+			b.builds.Clear()
+			setHeaderRow(b.builds, "Status", "Build", "Source version", "Commit", "Started", "Duration")
+			for i, record := range records {
+				setRow(b.builds, i+1, record.Icon(), record.ID, record.SourceVersion, shortCommit(record.Commit), formatTime(record.Start), record.Duration().String())
+			}
+			selectRow(b.builds, row)
+		}
+	})
+}
+
+// showBuild shows the details, phases and the end of the logs for a build
+func (b *Browser) showBuild(id string) {
+	if id != b.buildID {
+		b.build.SetText("")
+	}
+	b.buildID = id
+	b.switchTo(pageBuild, b.build)
+
+	b.load(func() func() {
+		var details bytes.Buffer
+		build, err := getBuild(b.api, aws.String(id))
+		if err == nil {
+			err = renderBuild(&details, build, BuildOptions{})
+		}
+
+		if err == nil && build.Logs != nil && build.Logs.GroupName != nil && build.Logs.StreamName != nil {
+			events, err := b.logs.GetLogEvents(&cloudwatchlogs.GetLogEventsInput{
+				LogGroupName:  build.Logs.GroupName,
+				LogStreamName: build.Logs.StreamName,
+				Limit:         aws.Int64(browserLogLines),
+			})
+			if err == nil {
+				fmt.Fprintf(&details, "\nLogs\n")
+				for _, event := range events.Events {
+					fmt.Fprintf(&details, "%s\n", strings.TrimRight(aws.StringValue(event.Message), "\n"))
+				}
+			}
+		}
+
+		return func() {
+			// The user has moved on to another build since
+			if id != b.buildID {
+				return
+			}
+			if b.page == pageBuild {
+				b.err = err
+			}
+			if err != nil {
+				return
+			}
+			b.build.SetText(details.String()).ScrollToEnd()
+		}
+	})
+}
+
+// startBuild starts a build of the project
+func (b *Browser) startBuild(project string) {
+	b.load(func() func() {
+		started, err := b.api.StartBuild(&codebuild.StartBuildInput{ProjectName: aws.String(project)})
+		return func() {
+			b.afterAction(err, func() string { return fmt.Sprintf("%s Started %s", ui.AppProgress, aws.StringValue(started.Build.Id)) })
+		}
+	})
+}
+
+// stopBuild stops the build
+func (b *Browser) stopBuild(id string) {
+	b.load(func() func() {
+		_, err := b.api.StopBuild(&codebuild.StopBuildInput{Id: aws.String(id)})
+		return func() {
+			b.afterAction(err, func() string { return fmt.Sprintf("%s Stopped %s", ui.AppStale, id) })
+		}
+	})
+}
+
+// retryBuild retries the build
+func (b *Browser) retryBuild(id string) {
+	b.load(func() func() {
+		retried, err := b.api.RetryBuild(&codebuild.RetryBuildInput{Id: aws.String(id)})
+		return func() {
+			b.afterAction(err, func() string {
+				return fmt.Sprintf("%s Retried as %s", ui.AppProgress, aws.StringValue(retried.Build.Id))
+			})
+		}
+	})
+}
+
+// afterAction tells the user how an action went, and refreshes the page
+func (b *Browser) afterAction(err error, success func() string) {
+	if err != nil {
+		b.message = fmt.Sprintf("%s %s", ui.AppFailure, err)
+	} else {
+		b.message = success()
+	}
+	b.refresh()
+}
+
+// confirm asks the user before doing something
+func (b *Browser) confirm(question string, action func()) {
+	previous := b.page
+	modal := tview.NewModal().
+		SetText(question).
+		AddButtons([]string{"Yes", "No"}).
+		SetDoneFunc(func(index int, label string) {
+			b.pages.RemovePage(pageConfirm)
+			b.page = previous
+			b.app.SetFocus(b.pages)
+			if label == "Yes" {
+				action()
+			}
+		})
+
+	b.page = pageConfirm
+	b.pages.AddPage(pageConfirm, modal, true, true)
+	b.app.SetFocus(modal)
+}
+
+// switchTo shows a page and updates the header and footer, forgetting how
+// the last page went when it is a different one
+func (b *Browser) switchTo(page string, primitive tview.Primitive) {
+	if page != b.page {
+		b.message = ""
+		b.err = nil
+	}
+	b.page = page
+	b.pages.SwitchToPage(page)
+	b.app.SetFocus(primitive)
+	b.updateBars()
+}
+
+// updateBars shows where the user is in the header, and what is going on
+// and what they can do in the footer
+func (b *Browser) updateBars() {
+	page := b.page
+	if page == pageConfirm {
+		return
+	}
+
+	crumbs := []string{"Projects"}
+	help := "enter: builds  s: start  R: refresh  q: quit"
+	if page == pageBuilds || page == pageBuild {
+		crumbs = append(crumbs, b.project)
+		help = "enter: details  s: start  x: stop  r: retry  R: refresh  esc: back  q: quit"
+	}
+	if page == pageBuild {
+		crumbs = append(crumbs, b.buildID)
+		help = "x: stop  r: retry  R: refresh  esc: back  q: quit"
+	}
+
+	b.header.SetText("knope  " + strings.Join(crumbs, " › "))
+	if b.message != "" {
+		help = b.message + "  |  " + help
+	}
+	if b.err != nil {
+		help = fmt.Sprintf("%s %s  |  %s", ui.AppFailure, b.err, help)
+	}
+	if b.loading > 0 {
+		help = fmt.Sprintf("%s Loading…  |  %s", ui.AppProgress, help)
+	}
+	b.footer.SetText(help)
+}
+
+// selectedProject is the project currently highlighted
+func (b *Browser) selectedProject() string {
+	row, _ := b.projects.GetSelection()
+	if row < 1 || row > len(b.projectRecord) {
+		return ""
+	}
+	return b.projectRecord[row-1].Project
+}
+
+// selectedBuild is the build currently highlighted
+func (b *Browser) selectedBuild() string {
+	row, _ := b.builds.GetSelection()
+	if row < 1 || row > len(b.buildRecords) {
+		return ""
+	}
+	return b.buildRecords[row-1].ID
+}
+
+// currentBuild is the build being looked at, or highlighted
+func (b *Browser) currentBuild() string {
+	switch b.page {
+	case pageBuild:
+		return b.buildID
+	case pageBuilds:
+		return b.selectedBuild()
+	}
+	return ""
+}
+
+// setHeaderRow adds the column headers to a table
+func setHeaderRow(t *tview.Table, headers ...string) {
+	for column, header := range headers {
+		t.SetCell(0, column, tview.NewTableCell(header).SetSelectable(false).SetAttributes(tcell.AttrBold))
+	}
+}
+
+// setRow adds a row of values to a table
+func setRow(t *tview.Table, row int, values ...string) {
+	for column, value := range values {
+		t.SetCell(row, column, tview.NewTableCell(tview.Escape(value)).SetExpansion(1))
+	}
+}
+
+// selectRow keeps the selection on the same row, within the table
+func selectRow(t *tview.Table, row int) {
+	if row >= t.GetRowCount() {
+		row = t.GetRowCount() - 1
+	}
+	if row < 1 {
+		row = 1
+	}
+	t.Select(row, 0)
+}
+
+// shortCommit is the abbreviated form of a commit SHA
+func shortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
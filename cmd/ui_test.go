@@ -0,0 +1,372 @@
+package cmd_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go/service/codebuild"
+	"github.com/benmatselby/knope/client"
+	"github.com/benmatselby/knope/cmd"
+	"github.com/gdamore/tcell/v2"
+	"github.com/golang/mock/gomock"
+)
+
+func TestNewUICommand(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	logs := client.NewMockLogsAPI(ctrl)
+	client := client.NewMockAPI(ctrl)
+
+	cmd := cmd.NewUICommand(client, logs)
+
+	use := "ui"
+	short := "Browse projects and builds in an interactive terminal UI"
+
+	if cmd.Use != use {
+		t.Fatalf("expected use: %s; got %s", use, cmd.Use)
+	}
+
+	if cmd.Short != short {
+		t.Fatalf("expected use: %s; got %s", short, cmd.Short)
+	}
+}
+
+// newBrowserMocks sets up a single project with a single failed build
+func newBrowserMocks(ctrl *gomock.Controller) (*client.MockAPI, *client.MockLogsAPI) {
+	api := client.NewMockAPI(ctrl)
+	logs := client.NewMockLogsAPI(ctrl)
+
+	start := time.Date(2020, 10, 18, 9, 0, 0, 0, time.UTC)
+	finish := start.Add(5 * time.Minute)
+
+	api.
+		EXPECT().
+		ListProjects(gomock.Any()).
+		Return(&codebuild.ListProjectsOutput{Projects: []*string{aws.String("project-one")}}, nil).
+		AnyTimes()
+
+	api.
+		EXPECT().
+		ListBuildsForProject(gomock.Any()).
+		Return(&codebuild.ListBuildsForProjectOutput{Ids: []*string{aws.String("project-one:2")}}, nil).
+		AnyTimes()
+
+	api.
+		EXPECT().
+		BatchGetBuilds(gomock.Any()).
+		Return(&codebuild.BatchGetBuildsOutput{Builds: []*codebuild.Build{{
+			Id:            aws.String("project-one:2"),
+			ProjectName:   aws.String("project-one"),
+			BuildNumber:   aws.Int64(2),
+			BuildStatus:   aws.String("FAILED"),
+			SourceVersion: aws.String("refs/heads/main"),
+			StartTime:     &start,
+			EndTime:       &finish,
+			Logs: &codebuild.LogsLocation{
+				GroupName:  aws.String("/aws/codebuild/project-one"),
+				StreamName: aws.String("2"),
+			},
+		}}}, nil).
+		AnyTimes()
+
+	logs.
+		EXPECT().
+		GetLogEvents(gomock.Any()).
+		Return(&cloudwatchlogs.GetLogEventsOutput{Events: []*cloudwatchlogs.OutputLogEvent{
+			{Message: aws.String("make: *** [test] Error 1\n")},
+		}}, nil).
+		AnyTimes()
+
+	return api, logs
+}
+
+// drawBrowser renders the browser and returns what is on screen
+func drawBrowser(t *testing.T, browser *cmd.Browser) string {
+	screen := tcell.NewSimulationScreen("UTF-8")
+	if err := screen.Init(); err != nil {
+		t.Fatalf("expected no error; got %v", err)
+	}
+	defer screen.Fini()
+	screen.SetSize(120, 30)
+
+	browser.Draw(screen)
+
+	cells, width, _ := screen.GetContents()
+	var b strings.Builder
+	for i, cell := range cells {
+		if i > 0 && i%width == 0 {
+			b.WriteString("\n")
+		}
+		if len(cell.Runes) > 0 {
+			b.WriteString(string(cell.Runes))
+		} else {
+			b.WriteString(" ")
+		}
+	}
+
+	return b.String()
+}
+
+func key(k tcell.Key) *tcell.EventKey {
+	return tcell.NewEventKey(k, 0, tcell.ModNone)
+}
+
+func press(r rune) *tcell.EventKey {
+	return tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone)
+}
+
+func TestBrowserNavigation(t *testing.T) {
+	tt := []struct {
+		name     string
+		keys     []*tcell.EventKey
+		expected []string
+	}{
+		{
+			name:     "starts by listing the projects",
+			expected: []string{"knope  Projects", "project-one", "enter: builds"},
+		},
+		{
+			name:     "can drill into the builds for a project",
+			keys:     []*tcell.EventKey{key(tcell.KeyEnter)},
+			expected: []string{"Projects › project-one", "project-one:2", "refs/heads/main", "5m0s"},
+		},
+		{
+			name:     "can drill into a build",
+			keys:     []*tcell.EventKey{key(tcell.KeyEnter), key(tcell.KeyEnter)},
+			expected: []string{"Projects › project-one › project-one:2", "FAILED", "Logs", "make: *** [test] Error 1"},
+		},
+		{
+			name:     "can go back to the builds",
+			keys:     []*tcell.EventKey{key(tcell.KeyEnter), key(tcell.KeyEnter), key(tcell.KeyEscape)},
+			expected: []string{"Projects › project-one ", "refs/heads/main"},
+		},
+		{
+			name:     "can go back to the projects",
+			keys:     []*tcell.EventKey{key(tcell.KeyEnter), key(tcell.KeyEscape)},
+			expected: []string{"knope  Projects ", "enter: builds"},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			api, logs := newBrowserMocks(ctrl)
+
+			browser := cmd.NewBrowser(api, logs, cmd.UIOptions{Filter: ".*", Limit: 50})
+			browser.Start()
+			browser.Sync()
+			for _, k := range tc.keys {
+				browser.HandleKey(k)
+				browser.Sync()
+			}
+
+			screen := drawBrowser(t, browser)
+			for _, expected := range tc.expected {
+				if !strings.Contains(screen, expected) {
+					t.Fatalf("expected screen to contain '%s'; got\n%s", expected, screen)
+				}
+			}
+		})
+	}
+}
+
+func TestBrowserActions(t *testing.T) {
+	tt := []struct {
+		name     string
+		keys     []*tcell.EventKey
+		start    bool
+		stop     bool
+		retry    bool
+		expected string
+	}{
+		{
+			name:     "can start a build of a project",
+			keys:     []*tcell.EventKey{press('s'), key(tcell.KeyEnter)},
+			start:    true,
+			expected: "Started project-one:3",
+		},
+		{
+			name:     "can stop a build",
+			keys:     []*tcell.EventKey{key(tcell.KeyEnter), press('x'), key(tcell.KeyEnter)},
+			stop:     true,
+			expected: "Stopped project-one:2",
+		},
+		{
+			name:     "can retry a build",
+			keys:     []*tcell.EventKey{key(tcell.KeyEnter), key(tcell.KeyEnter), press('r'), key(tcell.KeyEnter)},
+			retry:    true,
+			expected: "Retried as project-one:3",
+		},
+		{
+			name:     "does nothing when the user says no",
+			keys:     []*tcell.EventKey{key(tcell.KeyEnter), press('x'), key(tcell.KeyTab), key(tcell.KeyEnter)},
+			expected: "enter: details",
+		},
+		{
+			name:     "asks before doing anything",
+			keys:     []*tcell.EventKey{key(tcell.KeyEnter), press('r')},
+			expected: "Retry project-one:2?",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			api, logs := newBrowserMocks(ctrl)
+
+			started := &codebuild.Build{Id: aws.String("project-one:3"), BuildStatus: aws.String("IN_PROGRESS")}
+
+			if tc.start {
+				api.
+					EXPECT().
+					StartBuild(&codebuild.StartBuildInput{ProjectName: aws.String("project-one")}).
+					Return(&codebuild.StartBuildOutput{Build: started}, nil)
+			}
+
+			if tc.stop {
+				api.
+					EXPECT().
+					StopBuild(&codebuild.StopBuildInput{Id: aws.String("project-one:2")}).
+					Return(&codebuild.StopBuildOutput{}, nil)
+			}
+
+			if tc.retry {
+				api.
+					EXPECT().
+					RetryBuild(&codebuild.RetryBuildInput{Id: aws.String("project-one:2")}).
+					Return(&codebuild.RetryBuildOutput{Build: started}, nil)
+			}
+
+			browser := cmd.NewBrowser(api, logs, cmd.UIOptions{Filter: ".*", Limit: 50})
+			browser.Start()
+			browser.Sync()
+			for _, k := range tc.keys {
+				browser.HandleKey(k)
+				browser.Sync()
+			}
+
+			screen := drawBrowser(t, browser)
+			if !strings.Contains(screen, tc.expected) {
+				t.Fatalf("expected screen to contain '%s'; got\n%s", tc.expected, screen)
+			}
+		})
+	}
+}
+
+func TestBrowserLoadsInTheBackground(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Any call to AWS while the browser is being created fails the test
+	api := client.NewMockAPI(ctrl)
+	logs := client.NewMockLogsAPI(ctrl)
+	browser := cmd.NewBrowser(api, logs, cmd.UIOptions{Filter: ".*", Limit: 50})
+	drawBrowser(t, browser)
+
+	listed := make(chan struct{})
+	api.
+		EXPECT().
+		ListProjects(gomock.Any()).
+		DoAndReturn(func(input *codebuild.ListProjectsInput) (*codebuild.ListProjectsOutput, error) {
+			<-listed
+			return &codebuild.ListProjectsOutput{}, nil
+		})
+
+	browser.Start()
+	if screen := drawBrowser(t, browser); !strings.Contains(screen, "Loading…") {
+		t.Fatalf("expected screen to contain 'Loading…'; got\n%s", screen)
+	}
+
+	close(listed)
+	browser.Sync()
+	if screen := drawBrowser(t, browser); strings.Contains(screen, "Loading…") {
+		t.Fatalf("expected screen not to contain 'Loading…'; got\n%s", screen)
+	}
+}
+
+func TestBrowserShowsWhyAPageCouldNotBeLoaded(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	api := client.NewMockAPI(ctrl)
+	logs := client.NewMockLogsAPI(ctrl)
+	api.
+		EXPECT().
+		ListProjects(gomock.Any()).
+		Return(nil, errors.New("Rate exceeded"))
+
+	browser := cmd.NewBrowser(api, logs, cmd.UIOptions{Filter: ".*", Limit: 50})
+	browser.Start()
+	browser.Sync()
+
+	screen := drawBrowser(t, browser)
+	if !strings.Contains(screen, "Rate exceeded") {
+		t.Fatalf("expected screen to contain 'Rate exceeded'; got\n%s", screen)
+	}
+}
+
+func TestBrowserGetsABuildOnceToShowIt(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	api := client.NewMockAPI(ctrl)
+	logs := client.NewMockLogsAPI(ctrl)
+
+	api.
+		EXPECT().
+		ListProjects(gomock.Any()).
+		Return(&codebuild.ListProjectsOutput{Projects: []*string{aws.String("project-one")}}, nil).
+		AnyTimes()
+
+	api.
+		EXPECT().
+		ListBuildsForProject(gomock.Any()).
+		Return(&codebuild.ListBuildsForProjectOutput{Ids: []*string{aws.String("project-one:2")}}, nil).
+		AnyTimes()
+
+	requests := 0
+	api.
+		EXPECT().
+		BatchGetBuilds(gomock.Any()).
+		DoAndReturn(func(input *codebuild.BatchGetBuildsInput) (*codebuild.BatchGetBuildsOutput, error) {
+			requests++
+			return &codebuild.BatchGetBuildsOutput{Builds: []*codebuild.Build{{
+				Id:          aws.String("project-one:2"),
+				ProjectName: aws.String("project-one"),
+				BuildStatus: aws.String("FAILED"),
+				Logs: &codebuild.LogsLocation{
+					GroupName:  aws.String("/aws/codebuild/project-one"),
+					StreamName: aws.String("2"),
+				},
+			}}}, nil
+		}).
+		AnyTimes()
+
+	logs.
+		EXPECT().
+		GetLogEvents(&cloudwatchlogs.GetLogEventsInput{
+			LogGroupName:  aws.String("/aws/codebuild/project-one"),
+			LogStreamName: aws.String("2"),
+			Limit:         aws.Int64(100),
+		}).
+		Return(&cloudwatchlogs.GetLogEventsOutput{}, nil)
+
+	browser := cmd.NewBrowser(api, logs, cmd.UIOptions{Filter: ".*", Limit: 50})
+	browser.Start()
+	browser.Sync()
+	browser.HandleKey(key(tcell.KeyEnter))
+	browser.Sync()
+
+	before := requests
+	browser.HandleKey(key(tcell.KeyEnter))
+	browser.Sync()
+
+	if requests-before != 1 {
+		t.Fatalf("expected to get the build once; got %d requests", requests-before)
+	}
+}
@@ -0,0 +1,226 @@
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/codebuild"
+	"github.com/benmatselby/knope/client"
+)
+
+// parseSince turns --since into the time the window starts. It accepts days
+// and weeks, such as 30d or 2w, Go durations, such as 12h, or a date, such
+// as 2020-10-01.
+func parseSince(since string, now time.Time) (time.Time, error) {
+	return parseTimeFlag("--since", since, now)
+}
+
+// parseUntil turns --until into the time the window ends. It accepts the
+// same as --since, but a date includes the whole of that day.
+func parseUntil(until string, now time.Time) (time.Time, error) {
+	end, err := parseTimeFlag("--until", until, now)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if _, err := time.Parse("2006-01-02", until); err == nil {
+		end = end.Add(24 * time.Hour)
+	}
+
+	return end, nil
+}
+
+// parseTimeFlag turns a flag such as --since into a time, either a date or
+// how long before now
+func parseTimeFlag(flag, value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if date, err := time.Parse("2006-01-02", value); err == nil {
+		return date, nil
+	}
+
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if strings.HasSuffix(value, suffix) {
+			count, err := strconv.Atoi(strings.TrimSuffix(value, suffix))
+			if err != nil || count < 0 {
+				break
+			}
+			return now.Add(-time.Duration(count) * unit), nil
+		}
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < 0 {
+		return time.Time{}, fmt.Errorf("invalid %s %q, expected something like 30d, 2w, 12h or 2020-10-01", flag, value)
+	}
+
+	return now.Add(-duration), nil
+}
+
+// windowProjects works out which projects to look at, either those asked
+// for, or every project matching the filter
+func windowProjects(api client.API, projects []string, filter string) ([]string, error) {
+	if len(projects) > 0 {
+		return projects, nil
+	}
+
+	matcher, err := regexp.Compile(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := client.NewProjectIterator(api, &codebuild.ListProjectsInput{SortOrder: aws.String("ASCENDING")}, 0).All()
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []string
+	for _, name := range names {
+		if matcher.MatchString(aws.StringValue(name)) {
+			matched = append(matched, aws.StringValue(name))
+		}
+	}
+
+	return matched, nil
+}
+
+// fetchBuildsSince gets the builds for a project that started since the
+// time, newest first. Builds are listed newest first, so we stop paging
+// once we reach an older one.
+func fetchBuildsSince(api client.API, project string, since time.Time) ([]*codebuild.Build, error) {
+	var builds []*codebuild.Build
+
+	iter := client.NewBuildIterator(api, &codebuild.ListBuildsForProjectInput{
+		ProjectName: aws.String(project),
+		SortOrder:   aws.String(codebuild.SortOrderTypeDescending),
+	}, 0)
+	for iter.Next() {
+		build := iter.Build()
+		if build.StartTime != nil && build.StartTime.Before(since) {
+			break
+		}
+		builds = append(builds, build)
+	}
+
+	return builds, iter.Err()
+}
+
+// projectWorkers is how many projects we look at once. A broad filter can
+// match hundreds of projects, and asking about them all at once gets us
+// throttled by CodeBuild.
+const projectWorkers = 8
+
+// eachProject calls f for each of the projects, a few projects at a time,
+// and waits for them all
+func eachProject(projects []string, f func(project string)) {
+	queue := make(chan string)
+	go func() {
+		defer close(queue)
+		for _, project := range projects {
+			queue <- project
+		}
+	}()
+
+	workers := projectWorkers
+	if len(projects) < workers {
+		workers = len(projects)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for project := range queue {
+				f(project)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// fetchWindow gets the builds since the time for each project, a few
+// projects at a time. It gives up on the rest after the first error.
+func fetchWindow(api client.API, projects []string, since time.Time) (map[string][]*codebuild.Build, error) {
+	window := map[string][]*codebuild.Build{}
+	var mu sync.Mutex
+	var firstErr error
+
+	eachProject(projects, func(project string) {
+		mu.Lock()
+		failed := firstErr != nil
+		mu.Unlock()
+		if failed {
+			return
+		}
+
+		builds, err := fetchBuildsSince(api, project, since)
+
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("unable to get the builds for %s: %v", project, err)
+			}
+			return
+		}
+		window[project] = builds
+	})
+
+	return window, firstErr
+}
+
+// percentile uses the nearest rank method to find the pth percentile
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+
+	return sorted[rank-1]
+}
+
+// mean is the average of the values
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	total := 0.0
+	for _, v := range values {
+		total += v
+	}
+
+	return total / float64(len(values))
+}
+
+// formatSeconds renders a number of seconds as a duration for people
+func formatSeconds(seconds float64) string {
+	return time.Duration(seconds * float64(time.Second)).Round(time.Second).String()
+}
+
+// formatPercent renders a rate between 0 and 1 for people
+func formatPercent(rate float64) string {
+	return fmt.Sprintf("%.1f%%", rate*100)
+}
+
+// formatTrend renders a change between 0 and 1 for people, with its sign
+func formatTrend(change float64) string {
+	return fmt.Sprintf("%+.1f%%", change*100)
+}
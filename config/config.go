@@ -0,0 +1,310 @@
+// Package config holds the settings knope reads from its config file,
+// which lives at ~/.benmatselby/knope.yaml by default.
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/spf13/viper"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// The keys that can be set in the config file
+const (
+	// KeyRegion is the AWS region to talk to
+	KeyRegion string = "region"
+	// KeyProfile is the AWS profile to use for credentials
+	KeyProfile string = "profile"
+	// KeyRoleARN is an IAM role to assume once we have credentials, or a comma separated chain of them
+	KeyRoleARN string = "role_arn"
+	// KeyExternalID is passed on when assuming the role, for accounts that ask for one
+	KeyExternalID string = "external_id"
+	// KeyMFASerial is the MFA device to ask for a code from when assuming the role
+	KeyMFASerial string = "mfa_serial"
+	// KeySessionDuration is how long assumed role credentials last
+	KeySessionDuration string = "session_duration"
+	// KeyFilter is the default regex used to filter projects
+	KeyFilter string = "filter"
+	// KeyOutput is the default output format
+	KeyOutput string = "output"
+	// KeyDateFormat is the Go layout used to render dates and times
+	KeyDateFormat string = "date_format"
+	// KeyIcons is the icon set used to show the status of builds
+	KeyIcons string = "icons"
+	// KeyFavourites are the projects listed first
+	KeyFavourites string = "favourites"
+	// KeyCurrentContext is the context used when --context is not given
+	KeyCurrentContext string = "current_context"
+	// KeyContext is the context asked for with --context
+	KeyContext string = "context"
+)
+
+// Keys lists everything the user can set, in the order we show them
+var Keys = []string{KeyRegion, KeyProfile, KeyRoleARN, KeyExternalID, KeyMFASerial, KeySessionDuration, KeyFilter, KeyOutput, KeyDateFormat, KeyIcons, KeyFavourites}
+
+// Config is the typed form of the config file
+type Config struct {
+	Region          string             `mapstructure:"region" yaml:"region"`
+	Profile         string             `mapstructure:"profile" yaml:"profile"`
+	RoleARN         string             `mapstructure:"role_arn" yaml:"role_arn"`
+	ExternalID      string             `mapstructure:"external_id" yaml:"external_id,omitempty"`
+	MFASerial       string             `mapstructure:"mfa_serial" yaml:"mfa_serial,omitempty"`
+	SessionDuration string             `mapstructure:"session_duration" yaml:"session_duration,omitempty"`
+	Filter          string             `mapstructure:"filter" yaml:"filter"`
+	Output          string             `mapstructure:"output" yaml:"output"`
+	DateFormat      string             `mapstructure:"date_format" yaml:"date_format"`
+	Icons           string             `mapstructure:"icons" yaml:"icons"`
+	Favourites      []string           `mapstructure:"favourites" yaml:"favourites"`
+	CurrentContext  string             `mapstructure:"current_context" yaml:"current_context,omitempty"`
+	Contexts        map[string]Context `mapstructure:"contexts" yaml:"contexts,omitempty"`
+}
+
+// Context is a named AWS account and region to talk to, so people with
+// several accounts can switch between them with --context
+type Context struct {
+	Profile    string `mapstructure:"profile" yaml:"profile,omitempty"`
+	Region     string `mapstructure:"region" yaml:"region,omitempty"`
+	RoleARN    string `mapstructure:"role_arn" yaml:"role_arn,omitempty"`
+	ExternalID string `mapstructure:"external_id" yaml:"external_id,omitempty"`
+	MFASerial  string `mapstructure:"mfa_serial" yaml:"mfa_serial,omitempty"`
+	Filter     string `mapstructure:"filter" yaml:"filter,omitempty"`
+}
+
+// Settings are the config keys the context overrides, skipping those it
+// leaves alone
+func (c Context) Settings() map[string]string {
+	settings := map[string]string{}
+	for key, value := range map[string]string{
+		KeyProfile:    c.Profile,
+		KeyRegion:     c.Region,
+		KeyRoleARN:    c.RoleARN,
+		KeyExternalID: c.ExternalID,
+		KeyMFASerial:  c.MFASerial,
+		KeyFilter:     c.Filter,
+	} {
+		if value != "" {
+			settings[key] = value
+		}
+	}
+	return settings
+}
+
+// ContextNames lists the contexts in the config, sorted
+func (c Config) ContextNames() []string {
+	names := []string{}
+	for name := range c.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Resolve finds the named context, filling in anything it leaves alone
+// from the top level of the config
+func (c Config) Resolve(name string) (Context, error) {
+	context, err := c.Context(name)
+	if err != nil {
+		return context, err
+	}
+
+	if context.Profile == "" {
+		context.Profile = c.Profile
+	}
+	if context.Region == "" {
+		context.Region = c.Region
+	}
+	if context.RoleARN == "" {
+		context.RoleARN = c.RoleARN
+	}
+	if context.ExternalID == "" {
+		context.ExternalID = c.ExternalID
+	}
+	if context.MFASerial == "" {
+		context.MFASerial = c.MFASerial
+	}
+	if context.Filter == "" {
+		context.Filter = c.Filter
+	}
+
+	return context, nil
+}
+
+// Context finds the named context
+func (c Config) Context(name string) (Context, error) {
+	context, ok := c.Contexts[name]
+	if !ok {
+		if len(c.Contexts) == 0 {
+			return context, fmt.Errorf("unknown context %q, there are no contexts in the config file", name)
+		}
+		return context, fmt.Errorf("unknown context %q, expected one of %s", name, strings.Join(c.ContextNames(), ", "))
+	}
+
+	return context, nil
+}
+
+// Default is the config used when nothing else has been set
+func Default() Config {
+	return Config{
+		Filter:     ".*",
+		Output:     "table",
+		DateFormat: "02-01-2006 15:04",
+		Icons:      "emoji",
+		Favourites: []string{},
+	}
+}
+
+// DefaultPath is where we look for the config file when --config is not used
+func DefaultPath() (string, error) {
+	home, err := homedir.Dir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".benmatselby", "knope.yaml"), nil
+}
+
+// CacheDir is where we keep assumed role credentials until they expire
+func CacheDir() (string, error) {
+	home, err := homedir.Dir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".benmatselby", "cache", "knope"), nil
+}
+
+// SetDefaults registers the defaults, and the AWS environment variables
+// people already use, with viper. knope's own settings are read from
+// KNOPE_ environment variables, but AWS_PROFILE, AWS_REGION and
+// AWS_DEFAULT_REGION work as they always have.
+func SetDefaults(v *viper.Viper) {
+	defaults := Default()
+	v.SetDefault(KeyFilter, defaults.Filter)
+	v.SetDefault(KeyOutput, defaults.Output)
+	v.SetDefault(KeyDateFormat, defaults.DateFormat)
+	v.SetDefault(KeyIcons, defaults.Icons)
+	v.SetDefault(KeyFavourites, defaults.Favourites)
+
+	v.BindEnv(KeyProfile, "AWS_PROFILE")
+	if os.Getenv("AWS_REGION") == "" && os.Getenv("AWS_DEFAULT_REGION") != "" {
+		v.BindEnv(KeyRegion, "AWS_DEFAULT_REGION")
+	} else {
+		v.BindEnv(KeyRegion, "AWS_REGION")
+	}
+}
+
+// Load reads the typed config out of viper
+func Load(v *viper.Viper) (Config, error) {
+	var c Config
+	if err := v.Unmarshal(&c); err != nil {
+		return c, err
+	}
+
+	if c.Favourites == nil {
+		c.Favourites = []string{}
+	}
+
+	return c, nil
+}
+
+// IsKey tells us if key is one the user can set
+func IsKey(key string) bool {
+	for _, known := range Keys {
+		if key == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Set changes a single key in the config file, keeping everything else in
+// the file as it was. Favourites are given as a comma separated list.
+func Set(path, key, value string) error {
+	file, err := read(path)
+	if err != nil {
+		return err
+	}
+
+	var setting interface{} = value
+	if key == KeyFavourites {
+		favourites := []string{}
+		for _, f := range strings.Split(value, ",") {
+			if f = strings.TrimSpace(f); f != "" {
+				favourites = append(favourites, f)
+			}
+		}
+		setting = favourites
+	}
+
+	found := false
+	for i := range file {
+		if file[i].Key == key {
+			file[i].Value = setting
+			found = true
+		}
+	}
+	if !found {
+		file = append(file, yaml.MapItem{Key: key, Value: setting})
+	}
+
+	return write(path, file)
+}
+
+// Init writes the default config to path, refusing to replace an existing
+// file unless force is set
+func Init(path string, force bool) error {
+	if _, err := os.Stat(path); err == nil && !force {
+		return fmt.Errorf("config file %s already exists, use --force to replace it", path)
+	}
+
+	out, err := yaml.Marshal(Default())
+	if err != nil {
+		return err
+	}
+
+	var file yaml.MapSlice
+	if err := yaml.Unmarshal(out, &file); err != nil {
+		return err
+	}
+
+	return write(path, file)
+}
+
+// read loads the config file as is, treating a missing file as empty
+func read(path string) (yaml.MapSlice, error) {
+	var file yaml.MapSlice
+
+	contents, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return file, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(contents, &file); err != nil {
+		return nil, fmt.Errorf("unable to read config file %s: %v", path, err)
+	}
+
+	return file, nil
+}
+
+// write saves the config file, creating the directory if needed
+func write(path string, file yaml.MapSlice) error {
+	out, err := yaml.Marshal(file)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, out, 0600)
+}
@@ -0,0 +1,267 @@
+package config_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/benmatselby/knope/config"
+	"github.com/spf13/viper"
+)
+
+func tempConfig(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "knope")
+	if err != nil {
+		t.Fatalf("expected no error; got %v", err)
+	}
+
+	return filepath.Join(dir, ".benmatselby", "knope.yaml"), func() { os.RemoveAll(dir) }
+}
+
+func TestDefaultPath(t *testing.T) {
+	path, err := config.DefaultPath()
+	if err != nil {
+		t.Fatalf("expected no error; got %v", err)
+	}
+
+	if filepath.Base(path) != "knope.yaml" || filepath.Base(filepath.Dir(path)) != ".benmatselby" {
+		t.Fatalf("expected path to end in .benmatselby/knope.yaml; got %s", path)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tt := []struct {
+		name     string
+		file     string
+		expected config.Config
+	}{
+		{
+			name:     "uses the defaults when nothing is set",
+			expected: config.Default(),
+		},
+		{
+			name: "reads the values from the config file",
+			file: "region: eu-west-2\nprofile: prod\nfilter: ^api\noutput: wide\ndate_format: 2006-01-02 15:04\nicons: text\nfavourites:\n  - api\n  - web\ncurrent_context: dev\ncontexts:\n  dev:\n    profile: dev\n    role_arn: arn:aws:iam::123456789012:role/dev\n",
+			expected: config.Config{
+				Region:         "eu-west-2",
+				Profile:        "prod",
+				Filter:         "^api",
+				Output:         "wide",
+				DateFormat:     "2006-01-02 15:04",
+				Icons:          "text",
+				Favourites:     []string{"api", "web"},
+				CurrentContext: "dev",
+				Contexts: map[string]config.Context{
+					"dev": {Profile: "dev", RoleARN: "arn:aws:iam::123456789012:role/dev"},
+				},
+			},
+		},
+		{
+			name: "falls back to the defaults for anything not in the file",
+			file: "region: eu-west-2\n",
+			expected: config.Config{
+				Region:     "eu-west-2",
+				Filter:     ".*",
+				Output:     "table",
+				DateFormat: "02-01-2006 15:04",
+				Icons:      "emoji",
+				Favourites: []string{},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			path, cleanup := tempConfig(t)
+			defer cleanup()
+
+			v := viper.New()
+			config.SetDefaults(v)
+
+			if tc.file != "" {
+				os.MkdirAll(filepath.Dir(path), 0700)
+				if err := ioutil.WriteFile(path, []byte(tc.file), 0600); err != nil {
+					t.Fatalf("expected no error; got %v", err)
+				}
+				v.SetConfigFile(path)
+				if err := v.ReadInConfig(); err != nil {
+					t.Fatalf("expected no error; got %v", err)
+				}
+			}
+
+			c, err := config.Load(v)
+			if err != nil {
+				t.Fatalf("expected no error; got %v", err)
+			}
+
+			if !reflect.DeepEqual(c, tc.expected) {
+				t.Fatalf("expected %+v; got %+v", tc.expected, c)
+			}
+		})
+	}
+}
+
+func TestSetDefaultsReadsTheAWSEnvironment(t *testing.T) {
+	tt := []struct {
+		name    string
+		env     map[string]string
+		profile string
+		region  string
+	}{
+		{name: "reads the profile", env: map[string]string{"AWS_PROFILE": "prod"}, profile: "prod"},
+		{name: "reads the region", env: map[string]string{"AWS_REGION": "eu-west-1"}, region: "eu-west-1"},
+		{name: "falls back to the default region", env: map[string]string{"AWS_DEFAULT_REGION": "eu-west-2"}, region: "eu-west-2"},
+		{name: "prefers the region to the default region", env: map[string]string{"AWS_REGION": "eu-west-1", "AWS_DEFAULT_REGION": "eu-west-2"}, region: "eu-west-1"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, name := range []string{"AWS_PROFILE", "AWS_REGION", "AWS_DEFAULT_REGION"} {
+				previous, set := os.LookupEnv(name)
+				os.Setenv(name, tc.env[name])
+				defer func(name string) {
+					if set {
+						os.Setenv(name, previous)
+					} else {
+						os.Unsetenv(name)
+					}
+				}(name)
+			}
+
+			v := viper.New()
+			config.SetDefaults(v)
+
+			if profile := v.GetString(config.KeyProfile); profile != tc.profile {
+				t.Fatalf("expected profile %s; got %s", tc.profile, profile)
+			}
+
+			if region := v.GetString(config.KeyRegion); region != tc.region {
+				t.Fatalf("expected region %s; got %s", tc.region, region)
+			}
+		})
+	}
+}
+
+func TestSet(t *testing.T) {
+	tt := []struct {
+		name     string
+		existing string
+		key      string
+		value    string
+		expected string
+	}{
+		{name: "can create the config file", key: "region", value: "eu-west-1", expected: "region: eu-west-1\n"},
+		{name: "can change an existing value", existing: "region: eu-west-1\noutput: json\n", key: "region", value: "us-east-1", expected: "region: us-east-1\noutput: json\n"},
+		{name: "keeps settings it does not know about", existing: "custom: value\n", key: "icons", value: "text", expected: "custom: value\nicons: text\n"},
+		{name: "can set the favourites", key: "favourites", value: "api, web,", expected: "favourites:\n- api\n- web\n"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			path, cleanup := tempConfig(t)
+			defer cleanup()
+
+			if tc.existing != "" {
+				os.MkdirAll(filepath.Dir(path), 0700)
+				ioutil.WriteFile(path, []byte(tc.existing), 0600)
+			}
+
+			if err := config.Set(path, tc.key, tc.value); err != nil {
+				t.Fatalf("expected no error; got %v", err)
+			}
+
+			contents, _ := ioutil.ReadFile(path)
+			if string(contents) != tc.expected {
+				t.Fatalf("expected '%s'; got '%s'", tc.expected, string(contents))
+			}
+		})
+	}
+}
+
+func TestInit(t *testing.T) {
+	path, cleanup := tempConfig(t)
+	defer cleanup()
+
+	if err := config.Init(path, false); err != nil {
+		t.Fatalf("expected no error; got %v", err)
+	}
+
+	expected := "region: \"\"\nprofile: \"\"\nrole_arn: \"\"\nfilter: .*\noutput: table\ndate_format: 02-01-2006 15:04\nicons: emoji\nfavourites: []\n"
+	contents, _ := ioutil.ReadFile(path)
+	if string(contents) != expected {
+		t.Fatalf("expected '%s'; got '%s'", expected, string(contents))
+	}
+
+	err := config.Init(path, false)
+	if err == nil || err.Error() != "config file "+path+" already exists, use --force to replace it" {
+		t.Fatalf("expected an error as the file exists; got %v", err)
+	}
+
+	if err := config.Init(path, true); err != nil {
+		t.Fatalf("expected no error; got %v", err)
+	}
+}
+
+func TestContext(t *testing.T) {
+	c := config.Config{Contexts: map[string]config.Context{
+		"prod": {Profile: "prod", Region: "eu-west-1", Filter: "^api"},
+		"dev":  {Profile: "dev"},
+	}}
+
+	tt := []struct {
+		name     string
+		config   config.Config
+		context  string
+		expected map[string]string
+		err      string
+	}{
+		{name: "returns the settings the context overrides", config: c, context: "prod", expected: map[string]string{"profile": "prod", "region": "eu-west-1", "filter": "^api"}},
+		{name: "skips the settings the context leaves alone", config: c, context: "dev", expected: map[string]string{"profile": "dev"}},
+		{name: "tells you about unknown contexts", config: c, context: "staging", err: `unknown context "staging", expected one of dev, prod`},
+		{name: "tells you when there are no contexts", config: config.Config{}, context: "staging", err: `unknown context "staging", there are no contexts in the config file`},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			context, err := tc.config.Context(tc.context)
+
+			if tc.err != "" {
+				if err == nil || err.Error() != tc.err {
+					t.Fatalf("expected err to be %s; got %v", tc.err, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected no error; got %v", err)
+			}
+
+			if !reflect.DeepEqual(context.Settings(), tc.expected) {
+				t.Fatalf("expected %v; got %v", tc.expected, context.Settings())
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	c := config.Config{
+		Profile: "default",
+		Region:  "eu-west-2",
+		Filter:  ".*",
+		Contexts: map[string]config.Context{
+			"prod": {Profile: "prod", RoleARN: "arn:aws:iam::123456789012:role/readonly"},
+		},
+	}
+
+	context, err := c.Resolve("prod")
+	if err != nil {
+		t.Fatalf("expected no error; got %v", err)
+	}
+
+	expected := config.Context{Profile: "prod", Region: "eu-west-2", RoleARN: "arn:aws:iam::123456789012:role/readonly", Filter: ".*"}
+	if context != expected {
+		t.Fatalf("expected %+v; got %+v", expected, context)
+	}
+}
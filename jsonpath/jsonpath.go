@@ -0,0 +1,225 @@
+// Package jsonpath implements the subset of kubectl style JSONPath templates
+// knope needs, such as `{.project} {.phases[*].type}`.
+//
+// Text outside of braces is printed as is. Inside braces an expression can
+// optionally start with $ or @, followed by any number of `.field`, `.*`,
+// `[n]`, `[*]` or `['field']` steps. When an expression matches more than
+// one value they are separated by a space, and missing fields render as
+// nothing rather than failing.
+package jsonpath
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// step is a single part of an expression, such as .name or [0]
+type step struct {
+	field    string
+	index    int
+	wildcard bool
+	isIndex  bool
+}
+
+// segment is either literal text or an expression to evaluate
+type segment struct {
+	text  string
+	steps []step
+	expr  bool
+}
+
+// Template is a parsed JSONPath template
+type Template struct {
+	segments []segment
+}
+
+// Parse turns a template such as `{.project}: {.status}` into a Template
+func Parse(text string) (*Template, error) {
+	t := &Template{}
+
+	for len(text) > 0 {
+		open := strings.Index(text, "{")
+		if open == -1 {
+			t.segments = append(t.segments, segment{text: text})
+			break
+		}
+
+		if open > 0 {
+			t.segments = append(t.segments, segment{text: text[:open]})
+		}
+
+		end := strings.Index(text[open:], "}")
+		if end == -1 {
+			return nil, fmt.Errorf("unclosed expression in jsonpath %q", text)
+		}
+
+		steps, err := parseExpression(text[open+1 : open+end])
+		if err != nil {
+			return nil, err
+		}
+
+		t.segments = append(t.segments, segment{steps: steps, expr: true})
+		text = text[open+end+1:]
+	}
+
+	return t, nil
+}
+
+// parseExpression turns the inside of {} into steps
+func parseExpression(expr string) ([]step, error) {
+	expr = strings.TrimSpace(expr)
+	if strings.HasPrefix(expr, "$") || strings.HasPrefix(expr, "@") {
+		expr = expr[1:]
+	}
+
+	var steps []step
+	for len(expr) > 0 {
+		switch expr[0] {
+		case '.':
+			expr = expr[1:]
+			if strings.HasPrefix(expr, ".") {
+				return nil, fmt.Errorf("recursive descent is not supported in jsonpath")
+			}
+
+			end := strings.IndexAny(expr, ".[")
+			if end == -1 {
+				end = len(expr)
+			}
+
+			name := expr[:end]
+			expr = expr[end:]
+
+			if name == "*" {
+				steps = append(steps, step{wildcard: true})
+			} else if name != "" {
+				steps = append(steps, step{field: name})
+			}
+		case '[':
+			end := strings.Index(expr, "]")
+			if end == -1 {
+				return nil, fmt.Errorf("unclosed [ in jsonpath expression")
+			}
+
+			inside := strings.TrimSpace(expr[1:end])
+			expr = expr[end+1:]
+
+			if inside == "*" {
+				steps = append(steps, step{wildcard: true})
+			} else if len(inside) >= 2 && (inside[0] == '\'' || inside[0] == '"') && inside[len(inside)-1] == inside[0] {
+				steps = append(steps, step{field: inside[1 : len(inside)-1]})
+			} else {
+				index, err := strconv.Atoi(inside)
+				if err != nil {
+					return nil, fmt.Errorf("invalid index %q in jsonpath expression", inside)
+				}
+				steps = append(steps, step{index: index, isIndex: true})
+			}
+		default:
+			return nil, fmt.Errorf("unexpected %q in jsonpath expression", expr)
+		}
+	}
+
+	return steps, nil
+}
+
+// Execute renders the template for data. Data is converted to its JSON form
+// first, so field names are the JSON ones.
+func (t *Template) Execute(w io.Writer, data interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	var document interface{}
+	if err := json.Unmarshal(raw, &document); err != nil {
+		return err
+	}
+
+	for _, s := range t.segments {
+		if !s.expr {
+			if _, err := io.WriteString(w, s.text); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var values []string
+		for _, value := range evaluate(s.steps, document) {
+			formatted, err := format(value)
+			if err != nil {
+				return err
+			}
+			values = append(values, formatted)
+		}
+
+		if _, err := io.WriteString(w, strings.Join(values, " ")); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// evaluate walks the steps, returning every value that matches
+func evaluate(steps []step, document interface{}) []interface{} {
+	values := []interface{}{document}
+
+	for _, s := range steps {
+		var next []interface{}
+		for _, value := range values {
+			switch v := value.(type) {
+			case map[string]interface{}:
+				if s.wildcard {
+					var keys []string
+					for key := range v {
+						keys = append(keys, key)
+					}
+					sort.Strings(keys)
+					for _, key := range keys {
+						next = append(next, v[key])
+					}
+				} else if !s.isIndex {
+					if field, ok := v[s.field]; ok {
+						next = append(next, field)
+					}
+				}
+			case []interface{}:
+				if s.wildcard {
+					next = append(next, v...)
+				} else if s.isIndex {
+					index := s.index
+					if index < 0 {
+						index += len(v)
+					}
+					if index >= 0 && index < len(v) {
+						next = append(next, v[index])
+					}
+				}
+			}
+		}
+		values = next
+	}
+
+	return values
+}
+
+// format renders a single value
+func format(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	default:
+		out, err := json.Marshal(v)
+		return string(out), err
+	}
+}
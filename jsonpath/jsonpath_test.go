@@ -0,0 +1,95 @@
+package jsonpath_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/benmatselby/knope/jsonpath"
+)
+
+type testPhase struct {
+	Type   string `json:"type"`
+	Status string `json:"status,omitempty"`
+}
+
+type testRecord struct {
+	Project  string            `json:"project"`
+	Duration int64             `json:"duration_seconds"`
+	Passed   bool              `json:"passed"`
+	Phases   []testPhase       `json:"phases,omitempty"`
+	Tags     map[string]string `json:"tags,omitempty"`
+}
+
+func TestExecute(t *testing.T) {
+	record := testRecord{
+		Project:  "project-one",
+		Duration: 600,
+		Passed:   true,
+		Phases: []testPhase{
+			{Type: "INSTALL", Status: "SUCCEEDED"},
+			{Type: "BUILD", Status: "FAILED"},
+			{Type: "COMPLETED"},
+		},
+		Tags: map[string]string{"team": "payments", "service": "api"},
+	}
+
+	tt := []struct {
+		name     string
+		template string
+		expected string
+	}{
+		{name: "can print a field", template: "{.project}", expected: "project-one"},
+		{name: "can print text around fields", template: "{.project}: {.duration_seconds}s", expected: "project-one: 600s"},
+		{name: "can start from the root", template: "{$.passed}", expected: "true"},
+		{name: "can index into arrays", template: "{.phases[1].type}", expected: "BUILD"},
+		{name: "can index from the end of arrays", template: "{.phases[-1].type}", expected: "COMPLETED"},
+		{name: "can use wildcards on arrays", template: "{.phases[*].type}", expected: "INSTALL BUILD COMPLETED"},
+		{name: "can use wildcards on objects", template: "{.tags.*}", expected: "api payments"},
+		{name: "can use quoted field names", template: "{.tags['team']}", expected: "payments"},
+		{name: "renders missing fields as nothing", template: "{.finish}|{.phases[2].status}|{.phases[9].type}", expected: "||"},
+		{name: "renders objects as json", template: "{.phases[0]}", expected: `{"status":"SUCCEEDED","type":"INSTALL"}`},
+		{name: "can render the whole document", template: "{.tags}", expected: `{"service":"api","team":"payments"}`},
+		{name: "can render plain text", template: "hello", expected: "hello"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			template, err := jsonpath.Parse(tc.template)
+			if err != nil {
+				t.Fatalf("expected no error; got %v", err)
+			}
+
+			var b bytes.Buffer
+			if err := template.Execute(&b, record); err != nil {
+				t.Fatalf("expected no error; got %v", err)
+			}
+
+			if b.String() != tc.expected {
+				t.Fatalf("expected '%s'; got '%s'", tc.expected, b.String())
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tt := []struct {
+		name     string
+		template string
+		expected string
+	}{
+		{name: "unclosed expression", template: "{.project", expected: `unclosed expression in jsonpath "{.project"`},
+		{name: "unclosed index", template: "{.phases[0}", expected: "unclosed [ in jsonpath expression"},
+		{name: "invalid index", template: "{.phases[a]}", expected: `invalid index "a" in jsonpath expression`},
+		{name: "recursive descent", template: "{..type}", expected: "recursive descent is not supported in jsonpath"},
+		{name: "unexpected characters", template: "{project}", expected: `unexpected "project" in jsonpath expression`},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := jsonpath.Parse(tc.template)
+			if err == nil || err.Error() != tc.expected {
+				t.Fatalf("expected err to be %s; got %v", tc.expected, err)
+			}
+		})
+	}
+}
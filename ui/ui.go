@@ -1,22 +1,91 @@
 package ui
 
-const (
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var (
 	// AppDateFormat defines how all dates should look like
-	AppDateFormat string = "02-01-2006"
+	AppDateFormat = "02-01-2006"
 	// AppDateTimeFormat defines how all date/times should look like
-	AppDateTimeFormat string = "02-01-2006 15:04"
+	AppDateTimeFormat = "02-01-2006 15:04"
 	// AppSuccess is the happy icon
-	AppSuccess string = "✅"
+	AppSuccess = "✅"
 	// AppFailure is the sad icon
-	AppFailure string = "❌"
+	AppFailure = "❌"
 	// AppPending is for stuff that isn't happening yet
-	AppPending string = "🗂"
+	AppPending = "🗂"
 	// AppProgress if something is in progress
-	AppProgress string = "🏗"
+	AppProgress = "🏗"
 	// AppStale when something is out of date and has entered the black hole
-	AppStale string = "🕳"
+	AppStale = "🕳"
 	// AppUnknown when the world is just too darn crazy and we have no idea what is happening
-	AppUnknown string = "❓"
+	AppUnknown = "❓"
 	// AppEmpty is when there is no builds to show
-	AppEmpty string = "📭"
+	AppEmpty = "📭"
+	// AppIconPadding follows icons in tables, as emoji are wider than they look
+	AppIconPadding = " "
 )
+
+const (
+	// ClearScreen moves the cursor to the top left and clears the terminal
+	ClearScreen string = "\033[H\033[2J"
+	// AnsiHighlight makes text stand out by reversing the colours
+	AnsiHighlight string = "\033[7m"
+	// AnsiReset returns text to normal
+	AnsiReset string = "\033[0m"
+)
+
+// Icons is a set of icons used to show the state of things
+type Icons struct {
+	Success  string
+	Failure  string
+	Pending  string
+	Progress string
+	Stale    string
+	Unknown  string
+	Empty    string
+	Padding  string
+}
+
+// IconSets are the icons the user can choose from
+var IconSets = map[string]Icons{
+	"emoji": {Success: "✅", Failure: "❌", Pending: "🗂", Progress: "🏗", Stale: "🕳", Unknown: "❓", Empty: "📭", Padding: " "},
+	"text":  {Success: "OK", Failure: "FAIL", Pending: "WAIT", Progress: "RUN", Stale: "STOP", Unknown: "??", Empty: "NONE"},
+}
+
+// LookupIcons finds the named icon set
+func LookupIcons(name string) (Icons, error) {
+	icons, ok := IconSets[name]
+	if !ok {
+		var names []string
+		for n := range IconSets {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return icons, fmt.Errorf("unknown icon set %q, expected one of %s", name, strings.Join(names, ", "))
+	}
+
+	return icons, nil
+}
+
+// UseIcons switches the app icons to the named set
+func UseIcons(name string) error {
+	icons, err := LookupIcons(name)
+	if err != nil {
+		return err
+	}
+
+	AppSuccess = icons.Success
+	AppFailure = icons.Failure
+	AppPending = icons.Pending
+	AppProgress = icons.Progress
+	AppStale = icons.Stale
+	AppUnknown = icons.Unknown
+	AppEmpty = icons.Empty
+	AppIconPadding = icons.Padding
+
+	return nil
+}